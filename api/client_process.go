@@ -17,12 +17,20 @@ limitations under the License.
 package api
 
 import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/casuallc/vigil/inspection"
 	"github.com/casuallc/vigil/models"
+	"github.com/gorilla/websocket"
 )
 
 // ScanProcesses scans processes matching the query
@@ -120,6 +128,216 @@ func (c *Client) RestartProcess(namespace, name string) error {
 	return nil
 }
 
+// ScaleProcess changes the number of instances of a process with replicas
+func (c *Client) ScaleProcess(namespace, name string, replicas int) (*models.ManagedProcess, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	// 对路径参数进行 URL 编码
+	encodedNamespace := url.QueryEscape(namespace)
+	encodedName := url.QueryEscape(name)
+	body := map[string]int{"replicas": replicas}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/namespaces/%s/processes/%s/scale", encodedNamespace, encodedName), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var process models.ManagedProcess
+	if err := c.getJSONResponse(resp, &process); err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+// PreflightProcess checks whether a process definition can be started on the
+// server. process is checked if it is not nil, otherwise the managed process
+// namespace/name.
+func (c *Client) PreflightProcess(namespace, name string, process *models.ManagedProcess) (models.PreflightResult, error) {
+	var result models.PreflightResult
+	if namespace == "" {
+		namespace = "default"
+	}
+	var body interface{}
+	if process != nil {
+		body = process
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/namespaces/%s/processes/%s/preflight", url.QueryEscape(namespace), url.QueryEscape(name)), body)
+	if err != nil {
+		return result, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return result, c.errorFromResponse(resp)
+	}
+
+	if err := c.getJSONResponse(resp, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// StartNamespace starts all processes in a namespace in dependency order
+func (c *Client) StartNamespace(namespace string) ([]models.ProcessOperationResult, error) {
+	return c.BulkOperation(namespace, models.BulkStart, models.BulkOptions{})
+}
+
+// StopNamespace stops all processes in a namespace, dependents first
+func (c *Client) StopNamespace(namespace string) ([]models.ProcessOperationResult, error) {
+	return c.BulkOperation(namespace, models.BulkStop, models.BulkOptions{})
+}
+
+// BulkOperation runs action on the processes of a namespace matching
+// opts.LabelSelector and returns the result of each process.
+func (c *Client) BulkOperation(namespace string, action models.BulkAction, opts models.BulkOptions) ([]models.ProcessOperationResult, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	q := url.Values{}
+	if opts.LabelSelector != "" {
+		q.Set("labelSelector", opts.LabelSelector)
+	}
+	if opts.Parallelism > 0 {
+		q.Set("parallelism", strconv.Itoa(opts.Parallelism))
+	}
+	if opts.Wait {
+		q.Set("wait", "true")
+	}
+	if opts.Timeout > 0 {
+		q.Set("timeout", opts.Timeout.String())
+	}
+	path := fmt.Sprintf("/api/namespaces/%s/processes:%s", url.QueryEscape(namespace), action)
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	resp, err := c.doRequest("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var results []models.ProcessOperationResult
+	if err := c.getJSONResponse(resp, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ApplyProcesses applies a set of process manifests to a namespace
+func (c *Client) ApplyProcesses(namespace string, req models.ApplyRequest) ([]models.ApplyResult, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	resp, err := c.doRequest("POST", fmt.Sprintf("/api/namespaces/%s/processes:apply", url.QueryEscape(namespace)), req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var results []models.ApplyResult
+	if err := c.getJSONResponse(resp, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RollingRestart starts a rolling restart of the processes of a namespace
+// matching opts.LabelSelector and returns its initial state.
+func (c *Client) RollingRestart(namespace string, opts models.RolloutOptions) (models.Rollout, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	q := url.Values{}
+	if opts.LabelSelector != "" {
+		q.Set("labelSelector", opts.LabelSelector)
+	}
+	if opts.MaxUnavailable > 0 {
+		q.Set("maxUnavailable", strconv.Itoa(opts.MaxUnavailable))
+	}
+	if opts.PauseBetween > 0 {
+		q.Set("pauseBetween", opts.PauseBetween.String())
+	}
+	if opts.MinReady > 0 {
+		q.Set("minReady", opts.MinReady.String())
+	}
+	if opts.Timeout > 0 {
+		q.Set("timeout", opts.Timeout.String())
+	}
+	path := fmt.Sprintf("/api/namespaces/%s/processes:rollingRestart", url.QueryEscape(namespace))
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	return c.rolloutRequest("POST", path, http.StatusAccepted)
+}
+
+// ListRollouts lists the rollouts of a namespace, oldest first
+func (c *Client) ListRollouts(namespace string) ([]models.Rollout, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	resp, err := c.doRequest("GET", fmt.Sprintf("/api/namespaces/%s/rollouts", url.QueryEscape(namespace)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var rollouts []models.Rollout
+	if err := c.getJSONResponse(resp, &rollouts); err != nil {
+		return nil, err
+	}
+	return rollouts, nil
+}
+
+// GetRollout gets the current state of a rollout
+func (c *Client) GetRollout(namespace, id string) (models.Rollout, error) {
+	return c.rolloutRequest("GET", rolloutPath(namespace, id, ""), http.StatusOK)
+}
+
+// CancelRollout cancels a running rollout and returns its final state
+func (c *Client) CancelRollout(namespace, id string) (models.Rollout, error) {
+	return c.rolloutRequest("POST", rolloutPath(namespace, id, "/cancel"), http.StatusOK)
+}
+
+// ResumeRollout continues a failed or cancelled rollout
+func (c *Client) ResumeRollout(namespace, id string) (models.Rollout, error) {
+	return c.rolloutRequest("POST", rolloutPath(namespace, id, "/resume"), http.StatusAccepted)
+}
+
+func rolloutPath(namespace, id, action string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("/api/namespaces/%s/rollouts/%s%s", url.QueryEscape(namespace), url.PathEscape(id), action)
+}
+
+// rolloutRequest sends a request whose response is a single rollout
+func (c *Client) rolloutRequest(method, path string, status int) (models.Rollout, error) {
+	var rollout models.Rollout
+	resp, err := c.doRequest(method, path, nil)
+	if err != nil {
+		return rollout, err
+	}
+
+	if resp.StatusCode != status {
+		return rollout, c.errorFromResponse(resp)
+	}
+
+	err = c.getJSONResponse(resp, &rollout)
+	return rollout, err
+}
+
 // GetProcess gets detailed information about a proc
 func (c *Client) GetProcess(namespace, name string) (models.ManagedProcess, error) {
 	var process models.ManagedProcess
@@ -146,15 +364,18 @@ func (c *Client) GetProcess(namespace, name string) (models.ManagedProcess, erro
 }
 
 // ListProcesses lists all managed processes
-func (c *Client) ListProcesses(namespace string) ([]models.ManagedProcess, error) {
-	var url string
+func (c *Client) ListProcesses(namespace, labelSelector string) ([]models.ManagedProcess, error) {
+	var path string
 	if namespace == "" {
-		url = fmt.Sprintf("/api/processes")
+		path = fmt.Sprintf("/api/processes")
 	} else {
-		url = fmt.Sprintf("/api/namespaces/%s/processes", namespace)
+		path = fmt.Sprintf("/api/namespaces/%s/processes", namespace)
+	}
+	if labelSelector != "" {
+		path += "?labelSelector=" + url.QueryEscape(labelSelector)
 	}
 
-	resp, err := c.doRequest("GET", url, nil)
+	resp, err := c.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -228,3 +449,286 @@ func (c *Client) InspectProcess(namespace, name string, inspectRequest inspectio
 
 	return result, nil
 }
+
+// ListProcessEvents lists lifecycle events of a process, or of all processes
+// in the namespace when name is empty. limit <= 0 uses the server default.
+func (c *Client) ListProcessEvents(namespace, name string, limit int) ([]models.ProcessEvent, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	path := fmt.Sprintf("/api/namespaces/%s/events", url.QueryEscape(namespace))
+	if name != "" {
+		path = fmt.Sprintf("/api/namespaces/%s/processes/%s/events", url.QueryEscape(namespace), url.QueryEscape(name))
+	}
+	if limit > 0 {
+		path += fmt.Sprintf("?limit=%d", limit)
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var events []models.ProcessEvent
+	if err := c.getJSONResponse(resp, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ListJobRuns returns the recorded runs of a job, oldest first. A positive
+// limit returns only the most recent runs.
+func (c *Client) ListJobRuns(namespace, name string, limit int) ([]models.JobRun, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	path := fmt.Sprintf("/api/namespaces/%s/processes/%s/runs", url.QueryEscape(namespace), url.QueryEscape(name))
+	if limit > 0 {
+		path += fmt.Sprintf("?limit=%d", limit)
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var runs []models.JobRun
+	if err := c.getJSONResponse(resp, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ListCrashReports returns the crash reports of a process, oldest first
+func (c *Client) ListCrashReports(namespace, name string) ([]models.CrashReport, error) {
+	resp, err := c.doRequest("GET", crashPath(namespace, name, "", ""), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var reports []models.CrashReport
+	if err := c.getJSONResponse(resp, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetCrashReport gets the metadata of a crash report
+func (c *Client) GetCrashReport(namespace, name, id string) (models.CrashReport, error) {
+	var report models.CrashReport
+	resp, err := c.doRequest("GET", crashPath(namespace, name, id, ""), nil)
+	if err != nil {
+		return report, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return report, c.errorFromResponse(resp)
+	}
+
+	err = c.getJSONResponse(resp, &report)
+	return report, err
+}
+
+// DownloadCrashReport writes the tar.gz of a crash report to w
+func (c *Client) DownloadCrashReport(namespace, name, id string, w io.Writer) error {
+	return c.copyCrashResponse(crashPath(namespace, name, id, "/download"), w)
+}
+
+// ReadCrashFile writes a single file of a crash report, such as stderr.log or
+// proc/maps, to w
+func (c *Client) ReadCrashFile(namespace, name, id, file string, w io.Writer) error {
+	return c.copyCrashResponse(crashPath(namespace, name, id, "/files/"+file), w)
+}
+
+func crashPath(namespace, name, id, suffix string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	path := fmt.Sprintf("/api/namespaces/%s/processes/%s/crashes", url.QueryEscape(namespace), url.QueryEscape(name))
+	if id != "" {
+		path += "/" + url.PathEscape(id) + suffix
+	}
+	return path
+}
+
+// copyCrashResponse copies the body of a successful GET request to w
+func (c *Client) copyCrashResponse(path string, w io.Writer) error {
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return c.errorFromResponse(resp)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// AttachProcess opens an attach WebSocket that follows the output of a process.
+// Binary messages start with the stream byte (models.AttachStreamStdout or
+// models.AttachStreamStderr); with stdin set, messages written to the connection
+// are sent to the process's stdin.
+func (c *Client) AttachProcess(namespace, name string, stdin bool) (*websocket.Conn, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	wsScheme := "ws"
+	if strings.HasPrefix(c.baseURL, "https://") {
+		wsScheme = "wss"
+	}
+	baseURL := strings.TrimPrefix(c.baseURL, "http://")
+	baseURL = strings.TrimPrefix(baseURL, "https://")
+
+	wsURL := fmt.Sprintf("%s://%s/api/namespaces/%s/processes/%s/attach", wsScheme, baseURL, url.QueryEscape(namespace), url.QueryEscape(name))
+	if stdin {
+		wsURL += "?stdin=true"
+	}
+
+	headers := http.Header{}
+	if c.basicUser != "" && c.basicPass != "" {
+		auth := c.basicUser + ":" + c.basicPass
+		encodedAuth := base64.StdEncoding.EncodeToString([]byte(auth))
+		headers.Add("Authorization", "Basic "+encodedAuth)
+	}
+
+	dialer := c.internalWebSocketDialer()
+	conn, resp, err := dialer.Dial(wsURL, headers)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, c.errorFromResponse(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// WatchProcessEvents opens an SSE connection and passes each process event to
+// handler. Events after sinceID are replayed first when sinceID > 0. The method
+// returns when the connection closes or ctx is cancelled.
+func (c *Client) WatchProcessEvents(ctx context.Context, namespace, name string, sinceID int64, handler func(ev models.ProcessEvent)) error {
+	q := url.Values{}
+	if namespace != "" {
+		q.Set("namespace", namespace)
+	}
+	if name != "" {
+		q.Set("name", name)
+	}
+	if sinceID > 0 {
+		q.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/processes/events/watch?%s", c.host, q.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.basicUser != "" && c.basicPass != "" {
+		req.SetBasicAuth(c.basicUser, c.basicPass)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.errorFromResponse(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	var eventType string
+	var dataLines []string
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "" {
+			data := strings.Join(dataLines, "\n")
+			switch eventType {
+			case "event":
+				var ev models.ProcessEvent
+				if err := json.Unmarshal([]byte(data), &ev); err != nil {
+					return fmt.Errorf("failed to unmarshal event: %w", err)
+				}
+				handler(ev)
+			case "error":
+				var e map[string]string
+				_ = json.Unmarshal([]byte(data), &e)
+				return fmt.Errorf("%s", e["message"])
+			}
+			eventType = ""
+			dataLines = nil
+			continue
+		}
+		if strings.HasPrefix(text, "event: ") {
+			eventType = strings.TrimPrefix(text, "event: ")
+		} else if strings.HasPrefix(text, "data: ") {
+			dataLines = append(dataLines, strings.TrimPrefix(text, "data: "))
+		}
+	}
+	return scanner.Err()
+}
+
+// ListSecrets returns the names and timestamps of all secrets.
+func (c *Client) ListSecrets() ([]models.Secret, error) {
+	resp, err := c.doRequest("GET", "/api/secrets", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.errorFromResponse(resp)
+	}
+
+	var secrets []models.Secret
+	if err := c.getJSONResponse(resp, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// SetSecret creates or replaces a secret and reports whether it was created.
+func (c *Client) SetSecret(name, value string) (bool, error) {
+	resp, err := c.doRequest("PUT", fmt.Sprintf("/api/secrets/%s", url.PathEscape(name)), models.Secret{Value: value})
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		resp.Body.Close()
+		return true, nil
+	case http.StatusOK:
+		resp.Body.Close()
+		return false, nil
+	default:
+		return false, c.errorFromResponse(resp)
+	}
+}
+
+// DeleteSecret deletes a secret.
+func (c *Client) DeleteSecret(name string) error {
+	resp, err := c.doRequest("DELETE", fmt.Sprintf("/api/secrets/%s", url.PathEscape(name)), nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return c.errorFromResponse(resp)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/casuallc/vigil/audit"
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+var attachUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// handleAttachProcess follows a process's output over WebSocket.
+// Endpoint: /api/namespaces/{namespace}/processes/{name}/attach?stdin=true
+// The recently buffered output is replayed first, then new output follows across
+// restarts. Each binary message from the server starts with the stream byte
+// (1 stdout, 2 stderr) followed by the data; text messages are notices. With
+// stdin=true (administrators only) client messages are written to the process's
+// stdin, and only one session may hold stdin at a time.
+func (s *Server) handleAttachProcess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := getNamespace(vars)
+	name := vars["name"]
+	stdin := r.URL.Query().Get("stdin") == "true"
+	resource := namespace + "/" + name
+
+	if stdin && !s.isAdmin(r) {
+		s.auditAttach(r, resource, stdin, audit.StatusFailed, "stdin requires administrator privileges", nil)
+		writeError(w, http.StatusForbidden, "writing to stdin requires administrator privileges")
+		return
+	}
+
+	session, err := s.manager.AttachProcess(namespace, name, stdin)
+	if err != nil {
+		s.auditAttach(r, resource, stdin, audit.StatusFailed, err.Error(), nil)
+		switch {
+		case errors.Is(err, proc.ErrInvalidProcess):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, proc.ErrStdinBusy):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusNotFound, err.Error())
+		}
+		return
+	}
+	defer session.Close()
+
+	ws, err := attachUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Attach WebSocket upgrade error: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	started := time.Now()
+	s.auditAttach(r, resource, stdin, audit.StatusSuccess, "attached", nil)
+	var written atomic.Int64
+	defer func() {
+		s.auditAttach(r, resource, stdin, audit.StatusSuccess, "detached", map[string]interface{}{
+			"duration_ms":   time.Since(started).Milliseconds(),
+			"stdin_written": written.Load(),
+		})
+	}()
+
+	// 输出和提示由两个协程写入，WebSocket 不支持并发写
+	var writeMu sync.Mutex
+	write := func(messageType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return ws.WriteMessage(messageType, data)
+	}
+
+	// WS -> stdin，读取失败（客户端断开）时结束会话
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			_, payload, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			if !stdin {
+				continue
+			}
+			n, err := session.Write(payload)
+			written.Add(int64(n))
+			if err != nil {
+				if write(websocket.TextMessage, []byte(fmt.Sprintf("stdin: %v", err))) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// output -> WS
+	send := func(out models.AttachOutput) error {
+		return write(websocket.BinaryMessage, append([]byte{out.Stream}, out.Data...))
+	}
+	for _, out := range session.Backlog {
+		if err := send(out); err != nil {
+			return
+		}
+	}
+	output := session.Output()
+	for {
+		select {
+		case out, ok := <-output:
+			if !ok {
+				writeMu.Lock()
+				ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "process deleted"), time.Now().Add(time.Second))
+				writeMu.Unlock()
+				return
+			}
+			if err := send(out); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// auditAttach records an attach session in the audit log.
+func (s *Server) auditAttach(r *http.Request, resource string, stdin bool, status audit.StatusType, message string, details map[string]interface{}) {
+	if s.auditLogger == nil {
+		return
+	}
+	clientIP := r.RemoteAddr
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		clientIP = forwardedFor
+	}
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["stdin"] = stdin
+	entry := audit.NewLogEntry(s.getCurrentUser(r), clientIP, audit.ActionProcessAttach, resource, status, message, details)
+	if err := s.auditLogger.Log(entry); err != nil {
+		log.Printf("Error logging audit entry: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/config"
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+	"github.com/gorilla/websocket"
+)
+
+func TestHandleAttachProcess(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	server := &Server{manager: manager, config: &config.Config{}}
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+	client := NewClient(ts.URL)
+
+	dir := t.TempDir()
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "repl", Namespace: "test"},
+		Spec: models.Spec{
+			Exec:          models.Exec{Command: "sh", Args: []string{"-c", `echo ready; while read line; do echo "got $line" >&2; done`}},
+			WorkingDir:    dir,
+			Log:           models.LogConfig{Dir: dir},
+			RestartPolicy: models.RestartPolicyNever,
+			Stdin:         true,
+		},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := manager.StartProcess("test", "repl"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	defer manager.DeleteProcess("test", "repl")
+
+	conn, err := client.AttachProcess("test", "repl", true)
+	if err != nil {
+		t.Fatalf("AttachProcess: %v", err)
+	}
+	defer conn.Close()
+	// A second session cannot hold stdin at the same time
+	if _, err := client.AttachProcess("test", "repl", true); err == nil || !strings.Contains(err.Error(), "HTTP 409") {
+		t.Fatalf("second stdin attach: %v, want HTTP 409", err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("hello\n")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	streams := map[byte]string{}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for !strings.Contains(streams[models.AttachStreamStderr], "got hello\n") {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage: %v (received %q)", err, streams)
+		}
+		if messageType != websocket.BinaryMessage || len(message) == 0 {
+			t.Fatalf("unexpected message %d %q", messageType, message)
+		}
+		streams[message[0]] += string(message[1:])
+	}
+	if streams[models.AttachStreamStdout] != "ready\n" {
+		t.Fatalf("stdout = %q, want the replayed output", streams[models.AttachStreamStdout])
+	}
+
+	if _, err := client.AttachProcess("test", "missing", false); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("attach to a missing process: %v, want HTTP 404", err)
+	}
+}
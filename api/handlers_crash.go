@@ -0,0 +1,124 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+	"github.com/gorilla/mux"
+)
+
+// handleListCrashReports returns the crash reports of a process (including the
+// instances of a process with replicas or of a scheduled job), oldest first.
+func (s *Server) handleListCrashReports(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := getNamespace(vars)
+	name := vars["name"]
+
+	if _, err := s.manager.GetProcessStatus(namespace, name); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	reports, err := s.manager.ListCrashReports(namespace, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if reports == nil {
+		reports = []models.CrashReport{}
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// handleGetCrashReport returns the metadata of a crash report: exit status and
+// the files in the archive.
+func (s *Server) handleGetCrashReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	report, err := s.manager.GetCrashReport(getNamespace(vars), vars["name"], vars["id"])
+	if err != nil {
+		writeCrashError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleDownloadCrashReport streams the tar.gz of a crash report. The archive
+// holds the process environment and core dumps, so only administrators may
+// download it.
+func (s *Server) handleDownloadCrashReport(w http.ResponseWriter, r *http.Request) {
+	if !s.isAdmin(r) {
+		writeError(w, http.StatusForbidden, "downloading crash reports requires administrator privileges")
+		return
+	}
+	vars := mux.Vars(r)
+	report, f, err := s.manager.OpenCrashReport(getNamespace(vars), vars["name"], vars["id"])
+	if err != nil {
+		writeCrashError(w, err)
+		return
+	}
+	defer f.Close()
+
+	fileName := fmt.Sprintf("%s-%s.tar.gz", report.Name, report.ID)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fileName, url.PathEscape(fileName)))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Length", strconv.FormatInt(report.Size, 10))
+	_, _ = io.Copy(w, f)
+}
+
+// handleGetCrashFile returns a single file of a crash report, such as
+// stderr.log or proc/maps. Administrators only, like the download.
+func (s *Server) handleGetCrashFile(w http.ResponseWriter, r *http.Request) {
+	if !s.isAdmin(r) {
+		writeError(w, http.StatusForbidden, "reading crash report files requires administrator privileges")
+		return
+	}
+	vars := mux.Vars(r)
+	namespace, name, id := getNamespace(vars), vars["name"], vars["id"]
+	report, err := s.manager.GetCrashReport(namespace, name, id)
+	if err != nil {
+		writeCrashError(w, err)
+		return
+	}
+	found := false
+	for _, f := range report.Files {
+		found = found || f.Name == vars["file"]
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("crash report %s has no file %s", id, vars["file"]))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := s.manager.ReadCrashFile(namespace, name, id, vars["file"], w); err != nil {
+		writeCrashError(w, err)
+	}
+}
+
+func writeCrashError(w http.ResponseWriter, err error) {
+	if errors.Is(err, proc.ErrCrashReportNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
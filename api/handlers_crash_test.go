@@ -0,0 +1,125 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/config"
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+)
+
+func TestHandleCrashReports(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	manager.SetCrashDir(t.TempDir())
+	cfg := &config.Config{}
+	server := &Server{manager: manager, config: cfg}
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+	client := NewClient(ts.URL)
+
+	if _, err := client.ListCrashReports("test", "crasher"); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("ListCrashReports of a missing process: %v, want HTTP 404", err)
+	}
+	dir := t.TempDir()
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "crasher", Namespace: "test"},
+		Spec: models.Spec{
+			Exec:          models.Exec{Command: "sh", Args: []string{"-c", "echo boom >&2; exit 2"}},
+			WorkingDir:    dir,
+			Log:           models.LogConfig{Dir: dir},
+			RestartPolicy: models.RestartPolicyNever,
+			OnCrash:       &models.CrashPolicy{},
+		},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := manager.StartProcess("test", "crasher"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	var reports []models.CrashReport
+	deadline := time.Now().Add(5 * time.Second)
+	for len(reports) == 0 {
+		if reports, err = client.ListCrashReports("test", "crasher"); err != nil {
+			t.Fatalf("ListCrashReports: %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no crash report after 5s")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	id := reports[0].ID
+	report, err := client.GetCrashReport("test", "crasher", id)
+	if err != nil || report.ExitCode != 2 {
+		t.Fatalf("GetCrashReport = %+v, %v", report, err)
+	}
+	if _, err := client.GetCrashReport("test", "crasher", "missing"); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("GetCrashReport missing: %v, want HTTP 404", err)
+	}
+
+	var stderr bytes.Buffer
+	if err := client.ReadCrashFile("test", "crasher", id, "stderr.log", &stderr); err != nil || stderr.String() != "boom\n" {
+		t.Fatalf("ReadCrashFile = %q, %v", stderr.String(), err)
+	}
+	if err := client.ReadCrashFile("test", "crasher", id, "core/core.1", &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("ReadCrashFile missing: %v, want HTTP 404", err)
+	}
+
+	// The archive starts with report.json
+	var archive bytes.Buffer
+	if err := client.DownloadCrashReport("test", "crasher", id, &archive); err != nil {
+		t.Fatalf("DownloadCrashReport: %v", err)
+	}
+	if int64(archive.Len()) != report.Size {
+		t.Fatalf("downloaded %d bytes, want %d", archive.Len(), report.Size)
+	}
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if hdr, err := tar.NewReader(gz).Next(); err != nil || hdr.Name != "report.json" {
+		t.Fatalf("first entry = %+v, %v", hdr, err)
+	}
+
+	// With basic auth, only administrators can read the contents
+	cfg.BasicAuth = config.BasicAuth{Enabled: true, Username: "admin", Password: "secret"}
+	resp, err := http.Get(ts.URL + "/api/namespaces/test/processes/crasher/crashes/" + id + "/download")
+	if err != nil {
+		t.Fatalf("GET download: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("download without credentials: status %d, want 403", resp.StatusCode)
+	}
+}
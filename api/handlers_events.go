@@ -0,0 +1,183 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/casuallc/vigil/audit"
+	"github.com/casuallc/vigil/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultEventLimit is the number of events returned when no limit is given.
+	defaultEventLimit = 100
+	// eventKeepAliveInterval is how often an idle event stream sends a comment line.
+	eventKeepAliveInterval = 30 * time.Second
+)
+
+// parseEventQuery reads the since_id and limit query parameters.
+func parseEventQuery(r *http.Request) (int64, int, error) {
+	var sinceID int64
+	if v := r.URL.Query().Get("since_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			return 0, 0, fmt.Errorf("invalid since_id parameter")
+		}
+		sinceID = id
+	}
+	limit := defaultEventLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid limit parameter")
+		}
+		limit = n
+	}
+	return sinceID, limit, nil
+}
+
+// handleListProcessEvents returns the lifecycle events of a single process.
+func (s *Server) handleListProcessEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	s.listEvents(w, r, getNamespace(vars), vars["name"])
+}
+
+// handleListNamespaceEvents returns the lifecycle events of all processes in a namespace.
+func (s *Server) handleListNamespaceEvents(w http.ResponseWriter, r *http.Request) {
+	s.listEvents(w, r, getNamespace(mux.Vars(r)), "")
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	sinceID, limit, err := parseEventQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := s.manager.ListEvents(namespace, name, sinceID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []models.ProcessEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// handleWatchEvents streams process lifecycle events over SSE.
+// The optional namespace and name query parameters filter the stream; when
+// since_id is given, stored events after that ID are replayed first.
+func (s *Server) handleWatchEvents(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	name := r.URL.Query().Get("name")
+	sinceID, _, err := parseEventQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying so nothing is missed in between
+	events, cancel := s.manager.WatchEvents(namespace, name)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	lastID := sinceID
+	if r.URL.Query().Get("since_id") != "" {
+		history, err := s.manager.ListEvents(namespace, name, sinceID, 0)
+		if err != nil {
+			sseWriteError(w, fmt.Sprintf("failed to list events: %v", err))
+			flusher.Flush()
+			return
+		}
+		for _, ev := range history {
+			sseWriteEvent(w, ev)
+			lastID = ev.ID
+		}
+		flusher.Flush()
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.ID <= lastID {
+				// Already sent during replay
+				continue
+			}
+			sseWriteEvent(w, ev)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// sseWriteEvent writes a process event as an SSE "event" event.
+func sseWriteEvent(w io.Writer, ev models.ProcessEvent) {
+	data, _ := json.Marshal(ev)
+	fmt.Fprintf(w, "event: event\ndata: %s\n\n", data)
+}
+
+// auditWatch records an action triggered by a watched file change in the
+// audit log, together with the changed files and their content hashes.
+func (s *Server) auditWatch(trigger models.WatchTrigger) {
+	if s.auditLogger == nil {
+		return
+	}
+	status := audit.StatusSuccess
+	message := fmt.Sprintf("watched files changed, %s (pid %d)", trigger.Action, trigger.PID)
+	if trigger.Error != "" {
+		status = audit.StatusFailed
+		message += ": " + trigger.Error
+	}
+	details := map[string]interface{}{
+		"action":  trigger.Action,
+		"pid":     trigger.PID,
+		"changes": trigger.Changes,
+	}
+	entry := audit.NewLogEntry("system", "", audit.ActionProcessWatch, trigger.Namespace+"/"+trigger.Name, status, message, details)
+	if err := s.auditLogger.Log(entry); err != nil {
+		log.Printf("Error logging audit entry: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+)
+
+func TestHandleEvents(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	server := &Server{manager: manager}
+	router := server.Router()
+
+	// Each update of the definition records a ConfigChanged event
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "app", Namespace: "test"},
+		Spec:     models.Spec{Exec: models.Exec{Command: "sleep"}},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := manager.UpdateProcess(p); err != nil {
+			t.Fatalf("UpdateProcess: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/namespaces/test/processes/app/events", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var events []models.ProcessEvent
+	if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+		t.Fatalf("failed to unmarshal events: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != models.EventConfigChanged {
+		t.Fatalf("expected 2 ConfigChanged events, got %+v", events)
+	}
+
+	// The watch stream replays events after since_id, then streams new ones
+	req = httptest.NewRequest(http.MethodGet, "/api/processes/events/watch?namespace=test&since_id=1", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req = req.WithContext(ctx)
+	rr = httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(rr, req)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := manager.UpdateProcess(p); err != nil {
+		t.Fatalf("UpdateProcess: %v", err)
+	}
+	<-done
+
+	sse := parseSSEEvents(t, rr.Body.String())
+	if len(sse) != 2 {
+		t.Fatalf("expected 2 streamed events, got %d: %s", len(sse), rr.Body.String())
+	}
+	for i, ev := range sse {
+		var got models.ProcessEvent
+		if ev.event != "event" {
+			t.Errorf("expected event 'event', got %q", ev.event)
+		}
+		if err := json.Unmarshal([]byte(ev.data), &got); err != nil {
+			t.Fatalf("failed to unmarshal event data: %v", err)
+		}
+		if got.ID != int64(i+2) {
+			t.Errorf("expected event id %d, got %d", i+2, got.ID)
+		}
+	}
+}
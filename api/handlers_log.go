@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -77,9 +78,27 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 
 	reader := bufio.NewReader(file)
 	lineNumber := startLine
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	offset -= int64(reader.Buffered())
+	partial := ""
 	ticker := time.NewTicker(logPollInterval)
 	defer ticker.Stop()
 
+	emit := func(line string) {
+		// Trim trailing newline (and carriage return) for the content
+		content := line
+		if len(content) > 0 && content[len(content)-1] == '\n' {
+			content = content[:len(content)-1]
+		}
+		if len(content) > 0 && content[len(content)-1] == '\r' {
+			content = content[:len(content)-1]
+		}
+
+		sseWriteLine(w, LogLine{LineNumber: lineNumber, Content: content})
+		flusher.Flush()
+		lineNumber++
+	}
+
 	for {
 		select {
 		case <-r.Context().Done():
@@ -88,8 +107,31 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 		}
 
 		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
 		if err != nil {
 			if err == io.EOF {
+				// Keep a partially written line until its newline arrives
+				partial += line
+
+				// Follow rotation: the file was renamed away or truncated in place
+				if next, truncated := reopenIfReplaced(path, file, offset); next != nil {
+					if !truncated {
+						// A rotated file is no longer written to, emit what is left of it
+						rest, _ := io.ReadAll(reader)
+						for _, l := range strings.SplitAfter(partial+string(rest), "\n") {
+							if l != "" {
+								emit(l)
+							}
+						}
+					}
+					partial = ""
+					file.Close()
+					file = next
+					reader.Reset(file)
+					offset = 0
+					continue
+				}
+
 				// Wait for new content
 				flusher.Flush()
 				select {
@@ -104,19 +146,33 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Trim trailing newline (and carriage return) for the content
-		content := line
-		if len(content) > 0 && content[len(content)-1] == '\n' {
-			content = content[:len(content)-1]
-		}
-		if len(content) > 0 && content[len(content)-1] == '\r' {
-			content = content[:len(content)-1]
-		}
+		emit(partial + line)
+		partial = ""
+	}
+}
 
-		sseWriteLine(w, LogLine{LineNumber: lineNumber, Content: content})
-		flusher.Flush()
-		lineNumber++
+// reopenIfReplaced checks whether the file at path is no longer the one being read,
+// either because it was rotated (renamed and recreated) or truncated below offset.
+// It returns the newly opened file positioned at the start, or nil if nothing changed.
+func reopenIfReplaced(path string, file *os.File, offset int64) (*os.File, bool) {
+	current, err := file.Stat()
+	if err != nil {
+		return nil, false
+	}
+	latest, err := os.Stat(path)
+	if err != nil {
+		// Between rename and re-create the path may briefly not exist
+		return nil, false
+	}
+	truncated := os.SameFile(current, latest) && latest.Size() < offset
+	if os.SameFile(current, latest) && !truncated {
+		return nil, false
+	}
+	next, err := os.Open(path)
+	if err != nil {
+		return nil, false
 	}
+	return next, truncated
 }
 
 // seekToLine positions the file to the correct starting line based on fromLine.
@@ -329,3 +329,56 @@ func TestHandleLogStreamThroughMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleLogStreamFollowsRotation(t *testing.T) {
+	server := &Server{}
+	tempDir := t.TempDir()
+	logPath := createTestLogFile(t, tempDir)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/files/logs/stream?path="+logPath+"&from_line=0", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		server.handleLogStream(rr, req)
+		close(done)
+	}()
+
+	// Rotate while the stream is waiting at EOF: the last write lands in the
+	// old file right before it is renamed away, then a new file is created.
+	time.Sleep(200 * time.Millisecond)
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	f.WriteString("line6\n")
+	f.Close()
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatalf("failed to rotate log file: %v", err)
+	}
+	if err := os.WriteFile(logPath, []byte("line7\nline8\n"), 0644); err != nil {
+		t.Fatalf("failed to create new log file: %v", err)
+	}
+	<-done
+
+	events := parseSSEEvents(t, rr.Body.String())
+	if len(events) != 8 {
+		t.Fatalf("expected 8 line events across rotation, got %d", len(events))
+	}
+	for i, ev := range events {
+		var line LogLine
+		if err := json.Unmarshal([]byte(ev.data), &line); err != nil {
+			t.Fatalf("failed to unmarshal line data: %v", err)
+		}
+		if line.LineNumber != i+1 {
+			t.Errorf("expected line_number %d, got %d", i+1, line.LineNumber)
+		}
+		expectedContent := fmt.Sprintf("line%d", i+1)
+		if line.Content != expectedContent {
+			t.Errorf("expected content %q, got %q", expectedContent, line.Content)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+)
+
+func TestHandlePreflightProcess(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	server := &Server{manager: manager}
+	router := server.Router()
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Without a body the managed process is checked
+	if rr := do("/api/namespaces/test/processes/web/preflight", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	dir := t.TempDir()
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "web", Namespace: "test"},
+		Spec:     models.Spec{Exec: models.Exec{Command: "sleep", Args: []string{"300"}}, WorkingDir: dir},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	var result models.PreflightResult
+	rr := do("/api/namespaces/test/processes/web/preflight", "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); rr.Code != http.StatusOK || err != nil || !result.Passed {
+		t.Fatalf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// A definition in the body is checked instead, even for a new name
+	p.Spec.WorkingDir = filepath.Join(dir, "missing")
+	body, _ := json.Marshal(p)
+	rr = do("/api/namespaces/test/processes/api/preflight", string(body))
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); rr.Code != http.StatusOK || err != nil {
+		t.Fatalf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+	if result.Passed || result.Name != "api" || len(result.Findings) != 1 || result.Findings[0].Field != "spec.working_dir" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if rr := do("/api/namespaces/test/processes/api/preflight", "{"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
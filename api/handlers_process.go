@@ -17,10 +17,14 @@ limitations under the License.
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/casuallc/vigil/exporter"
 	"github.com/casuallc/vigil/models"
@@ -54,6 +58,10 @@ func (s *Server) handleAddProcess(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.manager.CreateProcess(process); err != nil {
+		if errors.Is(err, proc.ErrInvalidProcess) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -89,6 +97,72 @@ func (s *Server) handleRestartProcess(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Process restarted successfully"})
 }
 
+// handleScaleProcess changes the number of instances of a process with replicas.
+func (s *Server) handleScaleProcess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := getNamespace(vars)
+	name := vars["name"]
+
+	var req struct {
+		Replicas *int `json:"replicas"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Replicas == nil {
+		writeError(w, http.StatusBadRequest, "replicas is required")
+		return
+	}
+
+	if _, err := s.manager.GetProcessStatus(namespace, name); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := s.manager.ScaleProcess(namespace, name, *req.Replicas); err != nil {
+		if errors.Is(err, proc.ErrInvalidProcess) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	process, err := s.manager.GetProcessStatus(namespace, name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, process)
+}
+
+// handleListJobRuns returns the recorded runs of a job, oldest first.
+func (s *Server) handleListJobRuns(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := getNamespace(vars)
+	name := vars["name"]
+
+	_, limit, err := parseEventQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := s.manager.GetProcessStatus(namespace, name); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	runs, err := s.manager.ListJobRuns(namespace, name, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if runs == nil {
+		runs = []models.JobRun{}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
 // handleGetProcess handles getting process details
 func (s *Server) handleGetProcess(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -110,8 +184,12 @@ func (s *Server) handleListProcesses(w http.ResponseWriter, r *http.Request) {
 	namespace := getNamespace(vars)
 
 	// Support legacy API: return all processes if no namespace specified
-	processes, err := s.manager.ListManagedProcesses(namespace)
+	processes, err := s.manager.SelectProcesses(namespace, r.URL.Query().Get("labelSelector"))
 	if err != nil {
+		if errors.Is(err, proc.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -138,6 +216,111 @@ func (s *Server) handleStartProcess(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
 }
 
+// handleStartNamespace starts the processes in a namespace (optionally filtered by
+// labelSelector) in dependency order.
+func (s *Server) handleStartNamespace(w http.ResponseWriter, r *http.Request) {
+	s.handleBulkOperation(w, r, models.BulkStart)
+}
+
+// handleStopNamespace stops the processes in a namespace (optionally filtered by
+// labelSelector), dependents first.
+func (s *Server) handleStopNamespace(w http.ResponseWriter, r *http.Request) {
+	s.handleBulkOperation(w, r, models.BulkStop)
+}
+
+// handleRestartNamespace restarts the processes in a namespace (optionally filtered
+// by labelSelector) in dependency order.
+func (s *Server) handleRestartNamespace(w http.ResponseWriter, r *http.Request) {
+	s.handleBulkOperation(w, r, models.BulkRestart)
+}
+
+// handleDeleteNamespace deletes the processes in a namespace matching labelSelector,
+// dependents first. A selector is required so that a namespace is never emptied by accident.
+func (s *Server) handleDeleteNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("labelSelector") == "" {
+		writeError(w, http.StatusBadRequest, "labelSelector is required")
+		return
+	}
+	s.handleBulkOperation(w, r, models.BulkDelete)
+}
+
+// handleBulkOperation runs a bulk action on the selected processes and returns
+// the result of each process.
+func (s *Server) handleBulkOperation(w http.ResponseWriter, r *http.Request, action models.BulkAction) {
+	namespace := getNamespace(mux.Vars(r))
+
+	opts, err := parseBulkOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := s.manager.BulkOperation(namespace, action, opts)
+	if err != nil {
+		if errors.Is(err, proc.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// parseBulkOptions reads the labelSelector, parallelism, wait and timeout query parameters.
+func parseBulkOptions(r *http.Request) (models.BulkOptions, error) {
+	q := r.URL.Query()
+	opts := models.BulkOptions{LabelSelector: q.Get("labelSelector")}
+	if v := q.Get("parallelism"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid parallelism %q: must be a positive integer", v)
+		}
+		opts.Parallelism = n
+	}
+	if v := q.Get("wait"); v != "" {
+		wait, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid wait %q", v)
+		}
+		opts.Wait = wait
+	}
+	if v := q.Get("timeout"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return opts, fmt.Errorf("invalid timeout %q", v)
+		}
+		opts.Timeout = timeout
+	}
+	return opts, nil
+}
+
+// handleApplyProcesses applies a set of process manifests as the desired state of a namespace.
+func (s *Server) handleApplyProcesses(w http.ResponseWriter, r *http.Request) {
+	namespace := getNamespace(mux.Vars(r))
+
+	var req models.ApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i := range req.Processes {
+		req.Processes[i].Spec.Mounts = dedupMounts(req.Processes[i].Spec.Mounts)
+	}
+
+	results, err := s.manager.ApplyProcesses(namespace, req)
+	if err != nil {
+		if errors.Is(err, proc.ErrInvalidProcess) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
 // handleDeleteProcess handles deleting a managed process
 func (s *Server) handleDeleteProcess(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -146,6 +329,10 @@ func (s *Server) handleDeleteProcess(w http.ResponseWriter, r *http.Request) {
 
 	err := s.manager.DeleteProcess(namespace, name)
 	if err != nil {
+		if errors.Is(err, proc.ErrProcessInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -183,18 +370,56 @@ func (s *Server) handleEditProcess(w http.ResponseWriter, r *http.Request) {
 	// Deduplicate mounts before saving
 	updatedProcess.Spec.Mounts = dedupMounts(updatedProcess.Spec.Mounts)
 
-	// Update process
-	key := fmt.Sprintf("%s/%s", namespace, name)
-	s.manager.GetProcesses()[key] = &updatedProcess
+	if err := proc.ValidateProcess(&updatedProcess); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Save updated process info
-	if err := s.manager.SaveManagedProcesses(proc.ProcessesFilePath); err != nil {
-		fmt.Printf("Warning: failed to save managed processes: %v\n", err)
+	// Update process
+	if err := s.manager.UpdateProcess(updatedProcess); err != nil {
+		if errors.Is(err, proc.ErrInvalidProcess) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Process updated successfully"})
 }
 
+// handlePreflightProcess checks whether a process definition can be started on
+// this host without changing anything. The definition in the request body is
+// checked if there is one, otherwise the managed process of that name. The
+// response is 200 with the findings even when the check does not pass.
+func (s *Server) handlePreflightProcess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := getNamespace(vars)
+	name := vars["name"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var process models.ManagedProcess
+	if len(bytes.TrimSpace(body)) == 0 {
+		process, err = s.manager.GetProcessStatus(namespace, name)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+	} else if err := json.Unmarshal(body, &process); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	process.Metadata.Namespace = namespace
+	process.Metadata.Name = name
+	process.Spec.Mounts = dedupMounts(process.Spec.Mounts)
+
+	writeJSON(w, http.StatusOK, s.manager.Preflight(process))
+}
+
 // handleGetSystemResources handles system resource monitoring.
 func (s *Server) handleGetSystemResources(w http.ResponseWriter, r *http.Request) {
 	data, err := s.exporter.GatherJSON()
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+	"github.com/gorilla/mux"
+)
+
+// handleRollingRestart starts a rolling restart of the processes in a
+// namespace matching labelSelector. The rollout runs in the background; the
+// response is 202 with its initial state.
+func (s *Server) handleRollingRestart(w http.ResponseWriter, r *http.Request) {
+	namespace := getNamespace(mux.Vars(r))
+
+	opts, err := parseRolloutOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rollout, err := s.manager.StartRollout(namespace, opts)
+	if err != nil {
+		writeRolloutError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, rollout)
+}
+
+// parseRolloutOptions reads the labelSelector, maxUnavailable, pauseBetween,
+// minReady and timeout query parameters.
+func parseRolloutOptions(r *http.Request) (models.RolloutOptions, error) {
+	q := r.URL.Query()
+	opts := models.RolloutOptions{LabelSelector: q.Get("labelSelector")}
+	if v := q.Get("maxUnavailable"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid maxUnavailable %q: must be a positive integer", v)
+		}
+		opts.MaxUnavailable = n
+	}
+	durations := []struct {
+		name     string
+		value    *time.Duration
+		positive bool
+	}{
+		{"pauseBetween", &opts.PauseBetween, false},
+		{"minReady", &opts.MinReady, true},
+		{"timeout", &opts.Timeout, true},
+	}
+	for _, d := range durations {
+		v := q.Get(d.name)
+		if v == "" {
+			continue
+		}
+		value, err := time.ParseDuration(v)
+		if err != nil || value < 0 || (d.positive && value == 0) {
+			return opts, fmt.Errorf("invalid %s %q", d.name, v)
+		}
+		*d.value = value
+	}
+	return opts, nil
+}
+
+// handleListRollouts returns the rollouts of a namespace, oldest first.
+func (s *Server) handleListRollouts(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.manager.ListRollouts(getNamespace(mux.Vars(r))))
+}
+
+// handleGetRollout returns the current state of a rollout.
+func (s *Server) handleGetRollout(w http.ResponseWriter, r *http.Request) {
+	rollout, err := s.rolloutInNamespace(r)
+	if err != nil {
+		writeRolloutError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rollout)
+}
+
+// handleCancelRollout cancels a running rollout and returns its final state.
+func (s *Server) handleCancelRollout(w http.ResponseWriter, r *http.Request) {
+	rollout, err := s.rolloutInNamespace(r)
+	if err == nil {
+		rollout, err = s.manager.CancelRollout(rollout.ID)
+	}
+	if err != nil {
+		writeRolloutError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rollout)
+}
+
+// handleResumeRollout continues a failed or cancelled rollout from its first
+// unfinished target.
+func (s *Server) handleResumeRollout(w http.ResponseWriter, r *http.Request) {
+	rollout, err := s.rolloutInNamespace(r)
+	if err == nil {
+		rollout, err = s.manager.ResumeRollout(rollout.ID)
+	}
+	if err != nil {
+		writeRolloutError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, rollout)
+}
+
+// rolloutInNamespace returns the rollout named by the id path variable if it
+// belongs to the namespace of the request.
+func (s *Server) rolloutInNamespace(r *http.Request) (models.Rollout, error) {
+	vars := mux.Vars(r)
+	rollout, err := s.manager.GetRollout(vars["id"])
+	if err != nil {
+		return rollout, err
+	}
+	if namespace := getNamespace(vars); rollout.Namespace != namespace {
+		return models.Rollout{}, fmt.Errorf("%w: %s in namespace %s", proc.ErrRolloutNotFound, vars["id"], namespace)
+	}
+	return rollout, nil
+}
+
+// writeRolloutError maps rollout errors to HTTP status codes.
+func writeRolloutError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, proc.ErrInvalidSelector):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, proc.ErrRolloutNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, proc.ErrRolloutConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+)
+
+func TestHandleRollingRestart(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	server := &Server{manager: manager}
+	router := server.Router()
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, path := range []string{
+		"/api/namespaces/test/processes:rollingRestart?maxUnavailable=0",
+		"/api/namespaces/test/processes:rollingRestart?minReady=0s",
+		"/api/namespaces/test/processes:rollingRestart?pauseBetween=soon",
+		"/api/namespaces/test/processes:rollingRestart?labelSelector=app+in+(web",
+	} {
+		if rr := do(http.MethodPost, path); rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	// A stopped process is skipped, so the rollout finishes immediately
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "web", Namespace: "test", Labels: map[string]string{"app": "web"}},
+		Spec:     models.Spec{Exec: models.Exec{Command: "sleep", Args: []string{"300"}}},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	rr := do(http.MethodPost, "/api/namespaces/test/processes:rollingRestart?labelSelector=app%3Dweb&pauseBetween=1s")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var rollout models.Rollout
+	if err := json.Unmarshal(rr.Body.Bytes(), &rollout); err != nil || len(rollout.Targets) != 1 || rollout.Options.PauseBetween.String() != "1s" {
+		t.Fatalf("unexpected rollout %s (%v)", rr.Body.String(), err)
+	}
+
+	if rr := do(http.MethodGet, "/api/namespaces/test/rollouts/"+rollout.ID); rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/api/namespaces/other/rollouts/"+rollout.ID); rr.Code != http.StatusNotFound {
+		t.Fatalf("rollout of another namespace: expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var list []models.Rollout
+	rr = do(http.MethodGet, "/api/namespaces/test/rollouts")
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("unexpected list response %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/api/namespaces/test/rollouts/rollout-404/cancel"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
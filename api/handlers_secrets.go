@@ -0,0 +1,87 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+	"github.com/gorilla/mux"
+)
+
+// handleListSecrets returns the names and timestamps of all secrets. Values
+// are never returned.
+func (s *Server) handleListSecrets(w http.ResponseWriter, r *http.Request) {
+	secrets, err := s.manager.ListSecrets()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if secrets == nil {
+		secrets = []models.Secret{}
+	}
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+// handleSetSecret creates or replaces a secret. The body is {"value": "..."}.
+func (s *Server) handleSetSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var secret models.Secret
+	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.manager.SetSecret(name, secret.Value)
+	if err != nil {
+		if errors.Is(err, proc.ErrInvalidSecret) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if created {
+		writeJSON(w, http.StatusCreated, map[string]string{"message": "Secret created successfully"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Secret updated successfully"})
+}
+
+// handleDeleteSecret deletes a secret that is not referenced by any process.
+func (s *Server) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	if err := s.manager.DeleteSecret(name); err != nil {
+		switch {
+		case errors.Is(err, proc.ErrSecretNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, proc.ErrSecretInUse):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Secret deleted successfully"})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/casuallc/vigil/proc"
+)
+
+func TestHandleSecrets(t *testing.T) {
+	store, err := proc.NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	defer store.Close()
+	manager := proc.NewManager()
+	manager.SetStore(store)
+	manager.SetEncryptionKey("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	server := &Server{manager: manager}
+	router := server.Router()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPut, "/api/secrets/token", `{"value":"hunter2"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPut, "/api/secrets/token", `{"value":"hunter3"}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200 on update, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Values are never returned
+	rr := do(http.MethodGet, "/api/secrets", "")
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "hunter") {
+		t.Fatalf("unexpected list response %d: %s", rr.Code, rr.Body.String())
+	}
+	var secrets []models.Secret
+	if err := json.Unmarshal(rr.Body.Bytes(), &secrets); err != nil || len(secrets) != 1 || secrets[0].Name != "token" {
+		t.Fatalf("expected secret token, got %s (%v)", rr.Body.String(), err)
+	}
+
+	// A referenced secret cannot be deleted
+	p := models.ManagedProcess{
+		Metadata: models.Metadata{Name: "app", Namespace: "test"},
+		Spec: models.Spec{
+			Exec: models.Exec{Command: "sleep"},
+			Env:  []models.EnvVar{{Name: "TOKEN", Value: "${secret:token}"}},
+		},
+	}
+	if err := manager.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if rr := do(http.MethodDelete, "/api/secrets/token", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := manager.DeleteProcess("test", "app"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+	if rr := do(http.MethodDelete, "/api/secrets/token", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/api/secrets/token", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -26,14 +26,41 @@ func (s *Server) Router() *mux.Router {
 
 	// Process management endpoints
 	r.HandleFunc("/api/processes/scan", s.handleScanProcesses).Methods("GET")
+	r.HandleFunc("/api/processes/events/watch", s.handleWatchEvents).Methods("GET")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/add", s.handleAddProcess).Methods("POST")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/start", s.handleStartProcess).Methods("POST")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/stop", s.handleStopProcess).Methods("POST")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/restart", s.handleRestartProcess).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/scale", s.handleScaleProcess).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/preflight", s.handlePreflightProcess).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/events", s.handleListProcessEvents).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/runs", s.handleListJobRuns).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/crashes", s.handleListCrashReports).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/crashes/{id}", s.handleGetCrashReport).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/crashes/{id}/download", s.handleDownloadCrashReport).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/crashes/{id}/files/{file:.+}", s.handleGetCrashFile).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}/attach", s.handleAttachProcess).Methods("GET")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}", s.handleGetProcess).Methods("GET")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}", s.handleEditProcess).Methods("PUT")
 	r.HandleFunc("/api/namespaces/{namespace}/processes/{name}", s.handleDeleteProcess).Methods("DELETE")
 	r.HandleFunc("/api/namespaces/{namespace}/processes", s.handleListProcesses).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:start", s.handleStartNamespace).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:stop", s.handleStopNamespace).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:restart", s.handleRestartNamespace).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:delete", s.handleDeleteNamespace).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:apply", s.handleApplyProcesses).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/processes:rollingRestart", s.handleRollingRestart).Methods("POST")
+	r.HandleFunc("/api/processes:rollingRestart", s.handleRollingRestart).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/rollouts", s.handleListRollouts).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/rollouts/{id}", s.handleGetRollout).Methods("GET")
+	r.HandleFunc("/api/namespaces/{namespace}/rollouts/{id}/cancel", s.handleCancelRollout).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/rollouts/{id}/resume", s.handleResumeRollout).Methods("POST")
+	r.HandleFunc("/api/namespaces/{namespace}/events", s.handleListNamespaceEvents).Methods("GET")
+
+	// Secret endpoints (values are write-only)
+	r.HandleFunc("/api/secrets", s.handleListSecrets).Methods("GET")
+	r.HandleFunc("/api/secrets/{name}", s.handleSetSecret).Methods("PUT")
+	r.HandleFunc("/api/secrets/{name}", s.handleDeleteSecret).Methods("DELETE")
 
 	// Resource monitoring endpoints
 	r.HandleFunc("/api/resources/system", s.handleGetSystemResources).Methods("GET")
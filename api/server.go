@@ -99,6 +99,11 @@ func NewServerWithManager(config *config.Config, manager *proc.Manager, configPa
 	exp, err := exporter.NewNodeExporter()
 	if err != nil {
 		log.Printf("Warning: failed to initialize node exporter: %v", err)
+	} else {
+		exp.AddCollector(exporter.NewProcessCollector(manager, exporter.ProcessCollectorOptions{
+			Labels:       config.Exporter.ProcessLabels,
+			MaxProcesses: config.Exporter.MaxProcesses,
+		}))
 	}
 
 	// Use single SQLite database file for both VMs and users
@@ -198,6 +203,9 @@ func NewServerWithManager(config *config.Config, manager *proc.Manager, configPa
 	// Start the scheduler
 	server.scheduler.Start()
 
+	// Record actions triggered by watched file changes in the audit log
+	manager.SetWatchHook(server.auditWatch)
+
 	// Initialize the file-transfer agent sub-feature when enabled.
 	if config.Filetransfer.Enabled {
 		server.filetransfer = filetransfer.NewManager(filetransfer.Options{
@@ -409,6 +417,16 @@ func (s *Server) LoggingMiddleware(next http.Handler) http.Handler {
 			}
 		case strings.HasPrefix(path, "/api/processes"):
 			action = audit.ActionProcessManage
+		case strings.HasPrefix(path, "/api/secrets"):
+			resource = strings.TrimPrefix(strings.TrimPrefix(path, "/api/secrets"), "/")
+			switch r.Method {
+			case http.MethodGet:
+				action = audit.ActionSecretList
+			case http.MethodPut:
+				action = audit.ActionSecretSet
+			case http.MethodDelete:
+				action = audit.ActionSecretDelete
+			}
 		case strings.HasPrefix(path, "/api/resources"):
 			action = audit.ActionResourceMonitor
 		case strings.HasPrefix(path, "/api/config"):
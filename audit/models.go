@@ -49,10 +49,15 @@ const (
 	ActionPermissionRemove ActionType = "permission_remove"
 	ActionPermissionList   ActionType = "permission_list"
 	ActionProcessManage    ActionType = "process_manage"
+	ActionProcessAttach    ActionType = "process_attach"
+	ActionProcessWatch     ActionType = "process_watch"
 	ActionResourceMonitor  ActionType = "resource_monitor"
 	ActionConfigManage     ActionType = "config_manage"
 	ActionCommandExecute   ActionType = "command_exec"
 	ActionNetworkProbe     ActionType = "network_probe"
+	ActionSecretList       ActionType = "secret_list"
+	ActionSecretSet        ActionType = "secret_set"
+	ActionSecretDelete     ActionType = "secret_delete"
 
 	ActionDockerContainerList    ActionType = "docker_container_list"
 	ActionDockerContainerInspect ActionType = "docker_container_inspect"
@@ -0,0 +1,174 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+  "errors"
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+
+  "github.com/casuallc/vigil/models"
+  "github.com/spf13/cobra"
+  "gopkg.in/yaml.v3"
+)
+
+// setupApplyCommand 设置apply命令
+func (c *CLI) setupApplyCommand() *cobra.Command {
+  var applyNamespace string
+  var applyFile string
+  var dryRun bool
+  var prune bool
+
+  applyCmd := &cobra.Command{
+    Use:   "apply",
+    Short: "Apply process manifests",
+    Long: "Make the processes of a namespace match a set of ManagedProcess manifests. " +
+      "Missing processes are created and changed ones updated; running processes are restarted only when fields that affect execution changed. " +
+      "With --prune, processes in the namespace that are not in the manifests are deleted.",
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleApply(applyFile, applyNamespace, dryRun, prune)
+    },
+  }
+  applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "Manifest file or directory (*.yaml, *.yml, *.json)")
+  applyCmd.Flags().StringVarP(&applyNamespace, "namespace", "n", "default", "Process namespace")
+  applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show the diff, do not change anything")
+  applyCmd.Flags().BoolVar(&prune, "prune", false, "Delete processes in the namespace that are not in the manifests")
+  _ = applyCmd.MarkFlagRequired("file")
+
+  return applyCmd
+}
+
+// handleApply 读取清单并提交到服务端，输出每个进程的差异
+func (c *CLI) handleApply(path, namespace string, dryRun, prune bool) error {
+  processes, err := loadManifests(path)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+
+  results, err := c.client.ApplyProcesses(namespace, models.ApplyRequest{Processes: processes, DryRun: dryRun, Prune: prune})
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+
+  suffix := ""
+  if dryRun {
+    suffix = " (dry run)"
+  }
+  for _, result := range results {
+    if result.Error != "" {
+      fmt.Printf("ERROR  Process '%s' (ns=%s) %s: %s\n", result.Name, result.Namespace, result.Action, result.Error)
+      continue
+    }
+    line := fmt.Sprintf("%-10s %s", result.Action, result.Name)
+    if len(result.Changes) > 0 {
+      line += " (" + strings.Join(result.Changes, ", ") + ")"
+    }
+    if result.Restart {
+      line += ", restart"
+    }
+    if result.Pruned {
+      line += ", prune"
+    }
+    fmt.Println(line + suffix)
+  }
+  return nil
+}
+
+// loadManifests 从文件或目录读取进程定义。
+// 每个文件可以包含多个 YAML 文档（以 --- 分隔），每个文档是一个进程或进程列表；JSON 文件按 YAML 解析。
+func loadManifests(path string) ([]models.ManagedProcess, error) {
+  info, err := os.Stat(path)
+  if err != nil {
+    return nil, err
+  }
+  files := []string{path}
+  if info.IsDir() {
+    entries, err := os.ReadDir(path)
+    if err != nil {
+      return nil, err
+    }
+    files = files[:0]
+    for _, entry := range entries {
+      switch strings.ToLower(filepath.Ext(entry.Name())) {
+      case ".yaml", ".yml", ".json":
+        if !entry.IsDir() {
+          files = append(files, filepath.Join(path, entry.Name()))
+        }
+      }
+    }
+    sort.Strings(files)
+  }
+
+  var processes []models.ManagedProcess
+  for _, file := range files {
+    loaded, err := loadManifestFile(file)
+    if err != nil {
+      return nil, fmt.Errorf("%s: %w", file, err)
+    }
+    processes = append(processes, loaded...)
+  }
+  if len(processes) == 0 {
+    return nil, fmt.Errorf("no process manifests found in %s", path)
+  }
+  return processes, nil
+}
+
+func loadManifestFile(file string) ([]models.ManagedProcess, error) {
+  f, err := os.Open(file)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+
+  var processes []models.ManagedProcess
+  decoder := yaml.NewDecoder(f)
+  for {
+    var node yaml.Node
+    if err := decoder.Decode(&node); err != nil {
+      if errors.Is(err, io.EOF) {
+        return processes, nil
+      }
+      return nil, err
+    }
+    if len(node.Content) == 0 {
+      continue
+    }
+    if node.Content[0].Kind == yaml.SequenceNode {
+      var list []models.ManagedProcess
+      if err := node.Decode(&list); err != nil {
+        return nil, err
+      }
+      processes = append(processes, list...)
+      continue
+    }
+    var process models.ManagedProcess
+    if err := node.Decode(&process); err != nil {
+      return nil, err
+    }
+    if process.Metadata.Name == "" {
+      return nil, fmt.Errorf("manifest without metadata.name")
+    }
+    processes = append(processes, process)
+  }
+}
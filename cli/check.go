@@ -0,0 +1,117 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+  "fmt"
+
+  "github.com/casuallc/vigil/models"
+  "github.com/spf13/cobra"
+)
+
+// setupCheckCommand 设置check命令
+func (c *CLI) setupCheckCommand() *cobra.Command {
+  var checkNamespace string
+  var checkFile string
+
+  checkCmd := &cobra.Command{
+    Use:   "check [name]",
+    Short: "Check whether processes can be started",
+    Long: "Run a preflight check of process definitions on the server without changing anything: " +
+      "the same validation as create and edit, plus the executable, working and log directories, mounts, " +
+      "environment variables, referenced secrets and whether health-check ports are free. " +
+      "Checks the manifests given with -f, or the managed process [name].",
+    Args: cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      if (checkFile == "") == (len(args) == 0) {
+        return fmt.Errorf("either a process name or -f is required")
+      }
+      name := ""
+      if len(args) > 0 {
+        name = args[0]
+      }
+      return c.handleCheck(name, checkFile, checkNamespace)
+    },
+  }
+  checkCmd.Flags().StringVarP(&checkFile, "file", "f", "", "Manifest file or directory (*.yaml, *.yml, *.json)")
+  checkCmd.Flags().StringVarP(&checkNamespace, "namespace", "n", "default", "Process namespace")
+
+  return checkCmd
+}
+
+// handleCheck 预检清单中的进程或已纳管的进程，输出发现的问题
+func (c *CLI) handleCheck(name, path, namespace string) error {
+  var results []models.PreflightResult
+  if path == "" {
+    result, err := c.client.PreflightProcess(namespace, name, nil)
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+      return nil
+    }
+    results = append(results, result)
+  } else {
+    processes, err := loadManifests(path)
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+      return nil
+    }
+    for i := range processes {
+      result, err := c.client.PreflightProcess(namespace, processes[i].Metadata.Name, &processes[i])
+      if err != nil {
+        fmt.Println("ERROR ", err.Error())
+        return nil
+      }
+      results = append(results, result)
+    }
+  }
+
+  failed := 0
+  for _, result := range results {
+    printPreflightResult(result)
+    if !result.Passed {
+      failed++
+    }
+  }
+  if len(results) > 1 {
+    fmt.Printf("%d of %d processes passed\n", len(results)-failed, len(results))
+  }
+  return nil
+}
+
+// printPreflightResult 输出一个进程的预检结果
+func printPreflightResult(result models.PreflightResult) {
+  errs, warnings := 0, 0
+  for _, f := range result.Findings {
+    if f.Severity == models.FindingError {
+      errs++
+    } else {
+      warnings++
+    }
+  }
+  status := "passed"
+  if !result.Passed {
+    status = "failed"
+  }
+  fmt.Printf("%s (ns=%s): %s, %d error(s), %d warning(s)\n", result.Name, result.Namespace, status, errs, warnings)
+  for _, f := range result.Findings {
+    field := f.Field
+    if f.Instance != "" {
+      field += " [" + f.Instance + "]"
+    }
+    fmt.Printf("  %-8s %s: %s\n", f.Severity, field, f.Message)
+  }
+}
@@ -18,8 +18,11 @@ package cli
 
 import (
   "fmt"
+  "strconv"
+  "time"
 
   "github.com/casuallc/vigil/api"
+  "github.com/casuallc/vigil/models"
   "github.com/casuallc/vigil/version"
   "github.com/spf13/cobra"
 )
@@ -191,14 +194,23 @@ func (c *CLI) setupProcCommands() *cobra.Command {
   // 添加各个子命令
   procCmd.AddCommand(c.setupScanCommand())
   procCmd.AddCommand(c.setupCreateCommand())
+  procCmd.AddCommand(c.setupApplyCommand())
   procCmd.AddCommand(c.setupStartCommand())
   procCmd.AddCommand(c.setupStopCommand())
   procCmd.AddCommand(c.setupRestartCommand())
+  procCmd.AddCommand(c.setupRolloutCommands())
+  procCmd.AddCommand(c.setupScaleCommand())
   procCmd.AddCommand(c.setupDeleteCommand())
   procCmd.AddCommand(c.setupListCommand())
   procCmd.AddCommand(c.setupStatusCommand())
   procCmd.AddCommand(c.setupEditCommand())
+  procCmd.AddCommand(c.setupCheckCommand())
   procCmd.AddCommand(c.setupGetCommand())
+  procCmd.AddCommand(c.setupEventsCommand())
+  procCmd.AddCommand(c.setupRunsCommand())
+  procCmd.AddCommand(c.setupCrashesCommand())
+  procCmd.AddCommand(c.setupAttachCommand())
+  procCmd.AddCommand(c.setupSecretCommands())
 
   // 新增挂载命令组
   procCmd.AddCommand(c.setupMountCommands())
@@ -276,13 +288,19 @@ func (c *CLI) setupCreateCommand() *cobra.Command {
 // setupStartCommand 设置start命令
 func (c *CLI) setupStartCommand() *cobra.Command {
   var startNamespace string
+  var startAll bool
+  var bulk models.BulkOptions
 
   startCmd := &cobra.Command{
     Use:   "start [name]",
     Short: "Start process",
-    Long:  "Start a managed process. If no name is provided, an interactive selection will be shown.",
+    Long:  "Start a managed process. If no name is provided, an interactive selection will be shown. With --all or -l, the selected processes are started in dependency order.",
     Args:  cobra.MaximumNArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
+      if startAll || bulk.LabelSelector != "" {
+        return c.handleBulk(startNamespace, models.BulkStart, bulk)
+      }
+
       // 如果没有提供参数，使用交互式选择
       if len(args) == 0 {
         return c.handleStartInteractive(startNamespace)
@@ -292,6 +310,8 @@ func (c *CLI) setupStartCommand() *cobra.Command {
     },
   }
   startCmd.Flags().StringVarP(&startNamespace, "namespace", "n", "default", "Process namespace")
+  startCmd.Flags().BoolVar(&startAll, "all", false, "Start all processes in the namespace in dependency order")
+  addBulkFlags(startCmd, &bulk)
 
   return startCmd
 }
@@ -299,13 +319,19 @@ func (c *CLI) setupStartCommand() *cobra.Command {
 // setupStopCommand 设置stop命令
 func (c *CLI) setupStopCommand() *cobra.Command {
   var stopNamespace string
+  var stopAll bool
+  var bulk models.BulkOptions
 
   stopCmd := &cobra.Command{
     Use:   "stop [name]",
     Short: "Stop process",
-    Long:  "Stop a managed process. If no name is provided, an interactive selection will be shown.",
+    Long:  "Stop a managed process. If no name is provided, an interactive selection will be shown. With --all or -l, the selected processes are stopped in reverse dependency order.",
     Args:  cobra.MaximumNArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
+      if stopAll || bulk.LabelSelector != "" {
+        return c.handleBulk(stopNamespace, models.BulkStop, bulk)
+      }
+
       // 如果没有提供参数，使用交互式选择
       if len(args) == 0 {
         return c.handleStopInteractive(stopNamespace)
@@ -315,6 +341,8 @@ func (c *CLI) setupStopCommand() *cobra.Command {
     },
   }
   stopCmd.Flags().StringVarP(&stopNamespace, "namespace", "n", "default", "Process namespace")
+  stopCmd.Flags().BoolVar(&stopAll, "all", false, "Stop all processes in the namespace in reverse dependency order")
+  addBulkFlags(stopCmd, &bulk)
 
   return stopCmd
 }
@@ -322,13 +350,18 @@ func (c *CLI) setupStopCommand() *cobra.Command {
 // setupRestartCommand 设置restart命令
 func (c *CLI) setupRestartCommand() *cobra.Command {
   var restartNamespace string
+  var bulk models.BulkOptions
 
   restartCmd := &cobra.Command{
     Use:   "restart [name]",
     Short: "Restart process",
-    Long:  "Restart a managed process. If no name is provided, an interactive selection will be shown.",
+    Long:  "Restart a managed process. If no name is provided, an interactive selection will be shown. With -l, the selected processes are restarted in dependency order.",
     Args:  cobra.MaximumNArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
+      if bulk.LabelSelector != "" {
+        return c.handleBulk(restartNamespace, models.BulkRestart, bulk)
+      }
+
       // 如果没有提供参数，使用交互式选择
       if len(args) == 0 {
         return c.handleRestartInteractive(restartNamespace)
@@ -338,20 +371,56 @@ func (c *CLI) setupRestartCommand() *cobra.Command {
     },
   }
   restartCmd.Flags().StringVarP(&restartNamespace, "namespace", "n", "default", "Process namespace")
+  addBulkFlags(restartCmd, &bulk)
 
   return restartCmd
 }
 
+// addBulkFlags 添加按标签选择器批量操作的参数
+func addBulkFlags(cmd *cobra.Command, opts *models.BulkOptions) {
+  cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", "", "Operate on the processes matching the label selector (e.g. app=kafka,tier!=dev)")
+  cmd.Flags().IntVar(&opts.Parallelism, "parallelism", 1, "Number of processes to operate on at the same time")
+  cmd.Flags().BoolVar(&opts.Wait, "wait", false, "Wait until all selected processes reach the desired phase")
+  cmd.Flags().DurationVar(&opts.Timeout, "timeout", time.Minute, "How long to wait with --wait")
+}
+
+// setupScaleCommand 设置scale命令
+func (c *CLI) setupScaleCommand() *cobra.Command {
+  var scaleNamespace string
+
+  scaleCmd := &cobra.Command{
+    Use:   "scale [name] [replicas]",
+    Short: "Scale process instances",
+    Long:  "Change the number of instances of a process with replicas. New instances are started if the process is running; removed instances are stopped gracefully, highest index first.",
+    Args:  cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      replicas, err := strconv.Atoi(args[1])
+      if err != nil || replicas < 0 {
+        return fmt.Errorf("invalid replicas %q: must be a non-negative integer", args[1])
+      }
+      return c.handleScale(args[0], replicas, scaleNamespace)
+    },
+  }
+  scaleCmd.Flags().StringVarP(&scaleNamespace, "namespace", "n", "default", "Process namespace")
+
+  return scaleCmd
+}
+
 // setupDeleteCommand 设置delete命令
 func (c *CLI) setupDeleteCommand() *cobra.Command {
   var deleteNamespace string
+  var bulk models.BulkOptions
 
   deleteCmd := &cobra.Command{
     Use:   "delete [name]",
     Short: "Delete a managed process",
-    Long:  "Delete a process from the managed list. If the process is running, it will be stopped first. If no name is provided, an interactive selection will be shown.",
+    Long:  "Delete a process from the managed list. If the process is running, it will be stopped first. If no name is provided, an interactive selection will be shown. With -l, the selected processes are deleted in reverse dependency order.",
     Args:  cobra.MaximumNArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
+      if bulk.LabelSelector != "" {
+        return c.handleBulk(deleteNamespace, models.BulkDelete, bulk)
+      }
+
       // 如果没有提供参数，使用交互式选择
       if len(args) == 0 {
         return c.handleDeleteInteractive(deleteNamespace)
@@ -361,6 +430,8 @@ func (c *CLI) setupDeleteCommand() *cobra.Command {
     },
   }
   deleteCmd.Flags().StringVarP(&deleteNamespace, "namespace", "n", "default", "Process namespace")
+  deleteCmd.Flags().StringVarP(&bulk.LabelSelector, "selector", "l", "", "Delete the processes matching the label selector (e.g. app=kafka,tier!=dev)")
+  deleteCmd.Flags().IntVar(&bulk.Parallelism, "parallelism", 1, "Number of processes to operate on at the same time")
 
   return deleteCmd
 }
@@ -368,16 +439,18 @@ func (c *CLI) setupDeleteCommand() *cobra.Command {
 // setupListCommand 设置list命令
 func (c *CLI) setupListCommand() *cobra.Command {
   var listNamespace string
+  var listSelector string
 
   listCmd := &cobra.Command{
     Use:   "list",
     Short: "List processes",
-    Long:  "List all managed processes",
+    Long:  "List all managed processes, optionally filtered by a label selector",
     RunE: func(cmd *cobra.Command, args []string) error {
-      return c.handleList(listNamespace)
+      return c.handleList(listNamespace, listSelector)
     },
   }
   listCmd.Flags().StringVarP(&listNamespace, "namespace", "n", "default", "Process namespace")
+  listCmd.Flags().StringVarP(&listSelector, "selector", "l", "", "Label selector (e.g. app=kafka,tier!=dev or 'env in (prod,stage)')")
 
   return listCmd
 }
@@ -448,6 +521,74 @@ func (c *CLI) setupGetCommand() *cobra.Command {
   return getCmd
 }
 
+// setupEventsCommand 设置events命令
+func (c *CLI) setupEventsCommand() *cobra.Command {
+  var eventsNamespace string
+  var follow bool
+  var limit int
+
+  eventsCmd := &cobra.Command{
+    Use:   "events [name]",
+    Short: "Show process events",
+    Long:  "Show lifecycle events (started, exited, restarted, probe failures...) of a managed process, or of all processes in the namespace if no name is provided.",
+    Args:  cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      name := ""
+      if len(args) > 0 {
+        name = args[0]
+      }
+      return c.handleEvents(name, eventsNamespace, limit, follow)
+    },
+  }
+  eventsCmd.Flags().StringVarP(&eventsNamespace, "namespace", "n", "default", "Process namespace")
+  eventsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep watching for new events")
+  eventsCmd.Flags().IntVar(&limit, "limit", 50, "Number of recent events to show")
+
+  return eventsCmd
+}
+
+// setupRunsCommand 设置runs命令
+func (c *CLI) setupRunsCommand() *cobra.Command {
+  var runsNamespace string
+  var limit int
+  var showOutput bool
+
+  runsCmd := &cobra.Command{
+    Use:   "runs [name]",
+    Short: "Show job runs",
+    Long:  "Show the recorded runs of a job (kind: Job): exit code, duration and reason of each run, optionally with the captured output.",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRuns(args[0], runsNamespace, limit, showOutput)
+    },
+  }
+  runsCmd.Flags().StringVarP(&runsNamespace, "namespace", "n", "default", "Process namespace")
+  runsCmd.Flags().IntVar(&limit, "limit", 20, "Number of recent runs to show")
+  runsCmd.Flags().BoolVar(&showOutput, "output", false, "Also print the captured stdout and stderr of each run")
+
+  return runsCmd
+}
+
+// setupAttachCommand 设置attach命令
+func (c *CLI) setupAttachCommand() *cobra.Command {
+  var attachNamespace string
+  var stdin bool
+
+  attachCmd := &cobra.Command{
+    Use:   "attach [name]",
+    Short: "Attach to a process's output",
+    Long:  "Replay the recent output of a managed process and follow new output live. With --stdin, local input is written to the process's stdin (requires spec.stdin and administrator privileges).",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleAttach(args[0], attachNamespace, stdin)
+    },
+  }
+  attachCmd.Flags().StringVarP(&attachNamespace, "namespace", "n", "default", "Process namespace")
+  attachCmd.Flags().BoolVarP(&stdin, "stdin", "i", false, "Forward local stdin to the process")
+
+  return attachCmd
+}
+
 // setupResourceCommands 设置资源相关命令
 func (c *CLI) setupResourceCommands() *cobra.Command {
   resourceCmd := &cobra.Command{
@@ -0,0 +1,140 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+  "fmt"
+  "os"
+  "time"
+
+  "github.com/casuallc/vigil/models"
+  "github.com/spf13/cobra"
+)
+
+// setupCrashesCommand 设置crashes命令
+func (c *CLI) setupCrashesCommand() *cobra.Command {
+  var crashesNamespace string
+  var output string
+  var catFile string
+
+  crashesCmd := &cobra.Command{
+    Use:   "crashes [name] [id]",
+    Short: "Show crash reports of a process",
+    Long: "List the crash reports collected when a process with spec.on_crash exited abnormally, or show one report by [id]. " +
+      "With an [id], -o saves the tar.gz archive and --cat prints a single file of it (such as stderr.log or proc/maps); " +
+      "both require administrator privileges.",
+    Args: cobra.RangeArgs(1, 2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      if len(args) == 1 {
+        if output != "" || catFile != "" {
+          return fmt.Errorf("-o and --cat require a report id")
+        }
+        return c.handleListCrashes(args[0], crashesNamespace)
+      }
+      return c.handleCrash(args[0], args[1], crashesNamespace, output, catFile)
+    },
+  }
+  crashesCmd.Flags().StringVarP(&crashesNamespace, "namespace", "n", "default", "Process namespace")
+  crashesCmd.Flags().StringVarP(&output, "output", "o", "", "Save the report archive (tar.gz) to this file")
+  crashesCmd.Flags().StringVar(&catFile, "cat", "", "Print a file of the report, such as stderr.log")
+
+  return crashesCmd
+}
+
+// handleListCrashes 输出进程的崩溃报告列表
+func (c *CLI) handleListCrashes(name, namespace string) error {
+  reports, err := c.client.ListCrashReports(namespace, name)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if len(reports) == 0 {
+    fmt.Printf("No crash reports for '%s' in namespace '%s'\n", name, namespace)
+    return nil
+  }
+  fmt.Printf("%-19s  %-32s  %-8s  %-22s  %s\n", "TIME", "ID", "PID", "EXIT", "SIZE")
+  for _, report := range reports {
+    fmt.Printf("%-19s  %-32s  %-8d  %-22s  %d\n", report.Time.Local().Format("2006-01-02 15:04:05"), report.ID,
+      report.PID, describeCrashExit(report), report.Size)
+  }
+  return nil
+}
+
+// handleCrash 输出一个崩溃报告，或保存报告的归档、输出报告中的文件
+func (c *CLI) handleCrash(name, id, namespace, output, catFile string) error {
+  if catFile != "" {
+    if err := c.client.ReadCrashFile(namespace, name, id, catFile, os.Stdout); err != nil {
+      fmt.Println("ERROR ", err.Error())
+    }
+    return nil
+  }
+  if output != "" {
+    f, err := os.Create(output)
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+      return nil
+    }
+    err = c.client.DownloadCrashReport(namespace, name, id, f)
+    if closeErr := f.Close(); err == nil {
+      err = closeErr
+    }
+    if err != nil {
+      os.Remove(output)
+      fmt.Println("ERROR ", err.Error())
+      return nil
+    }
+    fmt.Printf("Crash report %s saved to %s\n", id, output)
+    return nil
+  }
+
+  report, err := c.client.GetCrashReport(namespace, name, id)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  fmt.Printf("ID:       %s\n", report.ID)
+  fmt.Printf("Process:  %s/%s\n", report.Namespace, report.Name)
+  if report.Instance != "" {
+    fmt.Printf("Instance: %s\n", report.Instance)
+  }
+  fmt.Printf("PID:      %d\n", report.PID)
+  fmt.Printf("Exit:     %s\n", describeCrashExit(report))
+  fmt.Printf("Started:  %s\n", report.StartTime.Local().Format("2006-01-02 15:04:05"))
+  fmt.Printf("Crashed:  %s (after %s)\n", report.Time.Local().Format("2006-01-02 15:04:05"),
+    report.Time.Sub(report.StartTime).Round(time.Millisecond))
+  fmt.Printf("Size:     %d bytes\n", report.Size)
+  fmt.Println("Files:")
+  for _, f := range report.Files {
+    fmt.Printf("  %-40s %d\n", f.Name, f.Size)
+  }
+  for _, core := range report.SkippedCores {
+    fmt.Printf("  %-40s skipped (larger than max_core_mb)\n", "core/"+core)
+  }
+  return nil
+}
+
+// describeCrashExit 返回崩溃的退出码或信号
+func describeCrashExit(report models.CrashReport) string {
+  detail := fmt.Sprintf("exit code %d", report.ExitCode)
+  if report.Signal != 0 {
+    detail = fmt.Sprintf("signal %d", report.Signal)
+  }
+  if report.Reason != "" {
+    detail += fmt.Sprintf(" (%s)", report.Reason)
+  }
+  return detail
+}
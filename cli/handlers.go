@@ -17,15 +17,20 @@ limitations under the License.
 package cli
 
 import (
+  "context"
   "fmt"
   "github.com/casuallc/vigil/config"
   "github.com/casuallc/vigil/models"
   "os"
   "os/exec"
+  "os/signal"
   "path/filepath"
+  "strings"
+  "syscall"
   "time"
 
   "github.com/casuallc/vigil/common"
+  "github.com/gorilla/websocket"
 
   "gopkg.in/yaml.v3" // 导入yaml包用于YAML格式输出
 )
@@ -244,6 +249,208 @@ func (c *CLI) handleStart(name string, namespace string) error {
   return nil
 }
 
+// handleBulk 对匹配标签选择器的进程执行批量操作并逐个输出结果
+func (c *CLI) handleBulk(namespace string, action models.BulkAction, opts models.BulkOptions) error {
+  results, err := c.client.BulkOperation(namespace, action, opts)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if len(results) == 0 {
+    fmt.Printf("No processes matched in namespace '%s'\n", namespace)
+    return nil
+  }
+  printOperationResults(results, bulkActionDone[action])
+  return nil
+}
+
+// bulkActionDone 是批量操作成功时输出的动词
+var bulkActionDone = map[models.BulkAction]string{
+  models.BulkStart:   "started",
+  models.BulkStop:    "stopped",
+  models.BulkRestart: "restarted",
+  models.BulkDelete:  "deleted",
+}
+
+// printOperationResults 逐个输出批量操作的结果
+func printOperationResults(results []models.ProcessOperationResult, action string) {
+  for _, result := range results {
+    if result.Error != "" {
+      fmt.Printf("ERROR  Process '%s' (ns=%s): %s\n", result.Name, result.Namespace, result.Error)
+    } else {
+      phase := ""
+      if result.Phase != "" {
+        phase = ", phase=" + string(result.Phase)
+      }
+      fmt.Printf("Process '%s' %s (ns=%s%s)\n", result.Name, action, result.Namespace, phase)
+    }
+  }
+}
+
+// handleEvents 输出进程事件，follow 时持续输出新事件直到 Ctrl+C
+func (c *CLI) handleEvents(name, namespace string, limit int, follow bool) error {
+  events, err := c.client.ListProcessEvents(namespace, name, limit)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  var lastID int64
+  for _, ev := range events {
+    printProcessEvent(ev)
+    lastID = ev.ID
+  }
+  if !follow {
+    return nil
+  }
+
+  sigCh := make(chan os.Signal, 1)
+  signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+  defer signal.Stop(sigCh)
+
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+
+  errCh := make(chan error, 1)
+  go func() {
+    // 从已输出的最后一条事件之后继续，避免遗漏
+    errCh <- c.client.WatchProcessEvents(ctx, namespace, name, lastID, printProcessEvent)
+  }()
+
+  select {
+  case <-sigCh:
+    cancel()
+    return nil
+  case err := <-errCh:
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+    }
+    return nil
+  }
+}
+
+// printProcessEvent 输出一条进程事件
+func printProcessEvent(ev models.ProcessEvent) {
+  detail := ev.Message
+  if ev.Reason != "" {
+    detail = fmt.Sprintf("(%s) %s", ev.Reason, ev.Message)
+  }
+  fmt.Printf("%s  %-13s %s/%s  %s\n", ev.Timestamp.Local().Format("2006-01-02 15:04:05"), ev.Type, ev.Namespace, ev.Name, detail)
+}
+
+// handleRuns 输出 Job 的运行记录
+func (c *CLI) handleRuns(name, namespace string, limit int, showOutput bool) error {
+  runs, err := c.client.ListJobRuns(namespace, name, limit)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if len(runs) == 0 {
+    fmt.Printf("No runs recorded for '%s' in namespace '%s'\n", name, namespace)
+    return nil
+  }
+  for _, run := range runs {
+    result := "Succeeded"
+    if !run.Succeeded {
+      result = "Failed"
+    }
+    detail := fmt.Sprintf("exit code %d", run.ExitCode)
+    if run.Signal != 0 {
+      detail = fmt.Sprintf("signal %d", run.Signal)
+    }
+    if run.Reason != "" {
+      detail += fmt.Sprintf(" (%s)", run.Reason)
+    }
+    instance := ""
+    if run.Instance != "" {
+      instance = run.Instance + " "
+    }
+    fmt.Printf("%s  %s#%d  %-9s %-8s %s\n", run.StartTime.Local().Format("2006-01-02 15:04:05"), instance, run.Attempt,
+      result, run.Duration.Round(time.Millisecond), detail)
+    if showOutput {
+      printRunOutput("stdout", run.Stdout)
+      printRunOutput("stderr", run.Stderr)
+    }
+  }
+  return nil
+}
+
+// printRunOutput 缩进输出一次运行捕获的输出
+func printRunOutput(stream, output string) {
+  if output == "" {
+    return
+  }
+  fmt.Printf("    --- %s ---\n", stream)
+  for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+    fmt.Printf("    %s\n", line)
+  }
+}
+
+// handleAttach 跟随进程的输出直到 Ctrl+C，stdin 时把本地的标准输入转发给进程
+func (c *CLI) handleAttach(name, namespace string, stdin bool) error {
+  conn, err := c.client.AttachProcess(namespace, name, stdin)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  defer conn.Close()
+
+  sigCh := make(chan os.Signal, 1)
+  signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+  defer signal.Stop(sigCh)
+
+  errCh := make(chan error, 1)
+  go func() {
+    for {
+      messageType, message, err := conn.ReadMessage()
+      if err != nil {
+        if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+          err = nil
+        }
+        errCh <- err
+        return
+      }
+      // 文本消息是服务端的提示，二进制消息的第一个字节是输出流
+      switch {
+      case messageType == websocket.TextMessage:
+        fmt.Fprintln(os.Stderr, string(message))
+      case len(message) > 0 && message[0] == models.AttachStreamStderr:
+        os.Stderr.Write(message[1:])
+      case len(message) > 0:
+        os.Stdout.Write(message[1:])
+      }
+    }
+  }()
+
+  if stdin {
+    go func() {
+      buf := make([]byte, 4096)
+      for {
+        n, err := os.Stdin.Read(buf)
+        if n > 0 {
+          if conn.WriteMessage(websocket.BinaryMessage, buf[:n]) != nil {
+            return
+          }
+        }
+        if err != nil {
+          return
+        }
+      }
+    }()
+  }
+
+  select {
+  case <-sigCh:
+    conn.WriteControl(websocket.CloseMessage,
+      websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+    return nil
+  case err := <-errCh:
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+    }
+    return nil
+  }
+}
+
 // handleStartInteractive 处理交互式选择要启动的进程
 func (c *CLI) handleStartInteractive(namespace string) error {
   selectedProcess, err := c.selectProcessInteractively(namespace, "select proc to start")
@@ -282,6 +489,17 @@ func (c *CLI) handleRestart(name string, namespace string) error {
   return nil
 }
 
+// handleScale 调整进程的实例数
+func (c *CLI) handleScale(name string, replicas int, namespace string) error {
+  process, err := c.client.ScaleProcess(namespace, name, replicas)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  fmt.Printf("Process '%s' scaled to %d replicas (ns=%s)\n", name, len(process.Status.Instances), namespace)
+  return nil
+}
+
 // handleRestartInteractive 处理交互式选择要重启的进程
 func (c *CLI) handleRestartInteractive(namespace string) error {
   selectedProcess, err := c.selectProcessInteractively(namespace, "select proc to restart")
@@ -292,8 +510,8 @@ func (c *CLI) handleRestartInteractive(namespace string) error {
   return c.handleRestart(selectedProcess.Metadata.Name, namespace)
 }
 
-func (c *CLI) handleList(namespace string) error {
-  processes, err := c.client.ListProcesses(namespace)
+func (c *CLI) handleList(namespace, selector string) error {
+  processes, err := c.client.ListProcesses(namespace, selector)
   if err != nil {
     fmt.Println("ERROR ", err.Error())
     return nil
@@ -317,6 +535,9 @@ func (c *CLI) handleStatus(name string, namespace string) error {
   fmt.Printf("Process status for '%s' in namespace '%s':\n", name, namespace)
   fmt.Printf("  Status: %s\n", process.Status.Phase)
   fmt.Printf("  PID: %d\n", process.Status.PID)
+  if process.Status.StatusText != "" {
+    fmt.Printf("  Status Text: %s\n", process.Status.StatusText)
+  }
   fmt.Printf("  Command: %s\n", process.Spec.Exec.Command)
   fmt.Printf("  Working Directory: %s\n", process.Spec.WorkingDir)
   if process.Status.StartTime == nil {
@@ -325,8 +546,70 @@ func (c *CLI) handleStatus(name string, namespace string) error {
     fmt.Printf("  Start Time: %s\n", process.Status.StartTime.Format("2006-01-02 15:04:05"))
   }
   fmt.Printf("  Restart Count: %d\n", process.Status.RestartCount)
+  if process.Status.BackoffCount > 0 {
+    fmt.Printf("  Backoff Count: %d\n", process.Status.BackoffCount)
+  }
+  if process.Status.NextRetryTime != nil {
+    fmt.Printf("  Next Retry Time: %s (in %s)\n", process.Status.NextRetryTime.Format("2006-01-02 15:04:05"),
+      time.Until(*process.Status.NextRetryTime).Round(time.Second))
+  }
   if process.Status.LastTerminationInfo != nil {
     fmt.Printf("  Last Exit Code: %d\n", process.Status.LastTerminationInfo.ExitCode)
+    if process.Status.LastTerminationInfo.Reason != "" {
+      fmt.Printf("  Last Termination Reason: %s\n", process.Status.LastTerminationInfo.Reason)
+    }
+    if process.Status.LastTerminationInfo.Message != "" {
+      fmt.Printf("  Last Termination Message: %s\n", process.Status.LastTerminationInfo.Message)
+    }
+    if process.Status.LastTerminationInfo.StopSignal != "" {
+      fmt.Printf("  Last Stop Signal: %s (escalated: %t)\n", process.Status.LastTerminationInfo.StopSignal, process.Status.LastTerminationInfo.Escalated)
+    }
+  }
+  if process.Kind == models.KindJob {
+    job := models.JobStatus{}
+    if process.Status.Job != nil {
+      job = *process.Status.Job
+    }
+    fmt.Printf("  Kind: %s\n", process.Kind)
+    if process.Spec.Job != nil && process.Spec.Job.Schedule != "" {
+      fmt.Printf("  Schedule: %s (active runs: %d)\n", process.Spec.Job.Schedule, job.Active)
+      if job.LastScheduleTime != nil {
+        fmt.Printf("  Last Schedule Time: %s\n", job.LastScheduleTime.Format("2006-01-02 15:04:05"))
+      }
+      if job.NextScheduleTime != nil {
+        fmt.Printf("  Next Schedule Time: %s\n", job.NextScheduleTime.Format("2006-01-02 15:04:05"))
+      }
+      for _, inst := range process.Status.Instances {
+        fmt.Printf("    %s: %s, PID: %d\n", inst.Name, inst.Phase, inst.PID)
+      }
+    } else {
+      fmt.Printf("  Succeeded: %d, Failed: %d\n", job.Succeeded, job.Failed)
+      if job.CompletionTime != nil {
+        fmt.Printf("  Completion Time: %s\n", job.CompletionTime.Format("2006-01-02 15:04:05"))
+      }
+    }
+  }
+  if process.Spec.Replicas != nil {
+    fmt.Printf("  Replicas: %d\n", *process.Spec.Replicas)
+    for _, inst := range process.Status.Instances {
+      fmt.Printf("    %s: %s, PID: %d, Restarts: %d\n", inst.Name, inst.Phase, inst.PID, inst.RestartCount)
+    }
+  }
+  if len(process.Status.Conditions) > 0 {
+    fmt.Printf("  Conditions:\n")
+    for _, cond := range process.Status.Conditions {
+      fmt.Printf("    %s=%s", cond.Type, cond.Status)
+      if cond.Reason != "" {
+        fmt.Printf(" (%s)", cond.Reason)
+      }
+      if !cond.LastTransitionTime.IsZero() {
+        fmt.Printf(" since %s", cond.LastTransitionTime.Format("2006-01-02 15:04:05"))
+      }
+      if cond.Message != "" {
+        fmt.Printf(": %s", cond.Message)
+      }
+      fmt.Println()
+    }
   }
 
   return nil
@@ -360,6 +643,13 @@ func (c *CLI) handleGet(name string, format string, namespace string) error {
     fmt.Printf("  Start Time: %s\n", process.Status.StartTime.Format("2006-01-02 15:04:05"))
     fmt.Printf("  Restart Policy: %s\n", process.Spec.RestartPolicy)
     fmt.Printf("  Restart Count: %d\n", process.Status.RestartCount)
+    if process.Status.BackoffCount > 0 {
+      fmt.Printf("  Backoff Count: %d\n", process.Status.BackoffCount)
+    }
+    if process.Status.NextRetryTime != nil {
+      fmt.Printf("  Next Retry Time: %s (in %s)\n", process.Status.NextRetryTime.Format("2006-01-02 15:04:05"),
+        time.Until(*process.Status.NextRetryTime).Round(time.Second))
+    }
     fmt.Printf("  Restart Interval: %s\n", process.Spec.RestartInterval)
     fmt.Printf("  Last Exit Code: %d\n", process.Status.LastTerminationInfo.ExitCode)
     if process.Spec.User != "" {
@@ -379,6 +669,12 @@ func (c *CLI) handleGet(name string, format string, namespace string) error {
         fmt.Printf("    %s=%s\n", env.Name, env.Value)
       }
     }
+    if len(process.Spec.EnvFrom) > 0 {
+      fmt.Println("  Environment Files:")
+      for _, src := range process.Spec.EnvFrom {
+        fmt.Printf("    %s (prefix: %q, optional: %t)\n", src.File, src.Prefix, src.Optional)
+      }
+    }
   }
 
   return nil
@@ -726,6 +1022,8 @@ func (c *CLI) handleGetConfig() error {
   fmt.Printf("  Log Level: %s\n", cfg.Log.Level)
   fmt.Printf("  Monitor Rate: %d seconds\n", cfg.Monitor.Rate)
   fmt.Printf("  PID File Path: %s\n", cfg.Process.PidFile)
+  fmt.Printf("  Process PID Dir: %s\n", cfg.Process.PidDir)
+  fmt.Printf("  Process Crash Dir: %s\n", cfg.Process.CrashDir)
   fmt.Printf("  Managed Apps Count: %d\n", len(cfg.ManagedApps))
 
   return nil
@@ -57,7 +57,7 @@ func centerText(text string, width int) string {
 // selectProcessInteractively 通用的交互式进程选择函数
 func (c *CLI) selectProcessInteractively(namespace string, label string) (models.ManagedProcess, error) {
   // 获取所有进程
-  processes, err := c.client.ListProcesses(namespace)
+  processes, err := c.client.ListProcesses(namespace, "")
   if err != nil {
     return models.ManagedProcess{}, err
   }
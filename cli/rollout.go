@@ -0,0 +1,314 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+  "fmt"
+  "os"
+  "os/signal"
+  "strings"
+  "syscall"
+  "time"
+
+  "github.com/casuallc/vigil/models"
+  "github.com/spf13/cobra"
+)
+
+// rolloutPollInterval 是跟随滚动重启进度时查询状态的间隔
+const rolloutPollInterval = time.Second
+
+// setupRolloutCommands 设置滚动重启相关子命令 (restart/status/list/cancel/resume)
+func (c *CLI) setupRolloutCommands() *cobra.Command {
+  rolloutCmd := &cobra.Command{
+    Use:   "rollout",
+    Short: "Rolling restart of a group of processes",
+    Long:  "Restart the processes matching a label selector one at a time (or max-unavailable at a time), waiting for each to be Running with a stable PID and passing its health check before restarting the next.",
+  }
+
+  rolloutCmd.AddCommand(c.setupRolloutRestartCommand())
+  rolloutCmd.AddCommand(c.setupRolloutStatusCommand())
+  rolloutCmd.AddCommand(c.setupRolloutListCommand())
+  rolloutCmd.AddCommand(c.setupRolloutCancelCommand())
+  rolloutCmd.AddCommand(c.setupRolloutResumeCommand())
+
+  return rolloutCmd
+}
+
+// setupRolloutRestartCommand 设置滚动重启命令
+func (c *CLI) setupRolloutRestartCommand() *cobra.Command {
+  var namespace string
+  var detach bool
+  var opts models.RolloutOptions
+
+  cmd := &cobra.Command{
+    Use:   "restart",
+    Short: "Start a rolling restart",
+    Long:  "Start a rolling restart of the processes matching the label selector in dependency order. Each instance of a process with replicas is restarted separately. The rollout stops at the first process that fails to become ready; it can then be resumed or inspected with 'rollout status'.",
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRolloutRestart(namespace, opts, detach)
+    },
+  }
+  cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Process namespace")
+  cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", "", "Restart the processes matching the label selector (e.g. app=gateway)")
+  cmd.Flags().IntVar(&opts.MaxUnavailable, "max-unavailable", 1, "Number of processes restarting (not yet ready) at the same time")
+  cmd.Flags().DurationVar(&opts.PauseBetween, "pause-between", 0, "Pause before restarting the next process")
+  cmd.Flags().DurationVar(&opts.MinReady, "min-ready", 10*time.Second, "How long a restarted process must stay Running with the same PID and pass its health check")
+  cmd.Flags().DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "How long to wait for each restarted process to become ready")
+  cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Print the rollout id and return without following its progress")
+
+  return cmd
+}
+
+// setupRolloutStatusCommand 设置滚动重启状态命令
+func (c *CLI) setupRolloutStatusCommand() *cobra.Command {
+  var namespace string
+  var follow bool
+
+  cmd := &cobra.Command{
+    Use:   "status <id>",
+    Short: "Show a rollout",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRolloutStatus(namespace, args[0], follow)
+    },
+  }
+  cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Process namespace")
+  cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow the progress until the rollout finishes")
+
+  return cmd
+}
+
+// setupRolloutListCommand 设置滚动重启列表命令
+func (c *CLI) setupRolloutListCommand() *cobra.Command {
+  var namespace string
+
+  cmd := &cobra.Command{
+    Use:   "list",
+    Short: "List rollouts",
+    Long:  "List the running and recent rollouts of a namespace. Rollouts are kept in memory and are lost when the server restarts.",
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRolloutList(namespace)
+    },
+  }
+  cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Process namespace")
+
+  return cmd
+}
+
+// setupRolloutCancelCommand 设置取消滚动重启命令
+func (c *CLI) setupRolloutCancelCommand() *cobra.Command {
+  var namespace string
+
+  cmd := &cobra.Command{
+    Use:   "cancel <id>",
+    Short: "Cancel a running rollout",
+    Long:  "Cancel a running rollout. Processes already restarted are not rolled back; a process still being verified is restarted again if the rollout is resumed.",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRolloutCancel(namespace, args[0])
+    },
+  }
+  cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Process namespace")
+
+  return cmd
+}
+
+// setupRolloutResumeCommand 设置恢复滚动重启命令
+func (c *CLI) setupRolloutResumeCommand() *cobra.Command {
+  var namespace string
+  var detach bool
+
+  cmd := &cobra.Command{
+    Use:   "resume <id>",
+    Short: "Resume a failed or cancelled rollout",
+    Long:  "Resume a failed or cancelled rollout from its first unfinished process. Processes already restarted by the rollout are not restarted again.",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleRolloutResume(namespace, args[0], detach)
+    },
+  }
+  cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Process namespace")
+  cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Return without following the progress")
+
+  return cmd
+}
+
+func (c *CLI) handleRolloutRestart(namespace string, opts models.RolloutOptions, detach bool) error {
+  rollout, err := c.client.RollingRestart(namespace, opts)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  fmt.Printf("Rollout %s started: %d targets in namespace '%s'\n", rollout.ID, len(rollout.Targets), namespace)
+  if detach {
+    return nil
+  }
+  return c.followRollout(namespace, rollout)
+}
+
+func (c *CLI) handleRolloutStatus(namespace, id string, follow bool) error {
+  rollout, err := c.client.GetRollout(namespace, id)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if follow && rollout.Phase == models.RolloutRunning {
+    return c.followRollout(namespace, rollout)
+  }
+  printRollout(rollout)
+  return nil
+}
+
+func (c *CLI) handleRolloutList(namespace string) error {
+  rollouts, err := c.client.ListRollouts(namespace)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if len(rollouts) == 0 {
+    fmt.Printf("No rollouts found in namespace '%s'\n", namespace)
+    return nil
+  }
+  fmt.Printf("%-14s %-10s %-8s %-30s %-20s %s\n", "ID", "PHASE", "DONE", "SELECTOR", "CREATED", "MESSAGE")
+  for _, r := range rollouts {
+    done := 0
+    for _, t := range r.Targets {
+      if t.Phase == models.RolloutTargetDone || t.Phase == models.RolloutTargetSkipped {
+        done++
+      }
+    }
+    selector := r.Options.LabelSelector
+    if selector == "" {
+      selector = "<all>"
+    }
+    fmt.Printf("%-14s %-10s %-8s %-30s %-20s %s\n", r.ID, r.Phase, fmt.Sprintf("%d/%d", done, len(r.Targets)), selector,
+      r.CreatedAt.Local().Format("2006-01-02 15:04:05"), r.Message)
+  }
+  return nil
+}
+
+func (c *CLI) handleRolloutCancel(namespace, id string) error {
+  rollout, err := c.client.CancelRollout(namespace, id)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  printRollout(rollout)
+  return nil
+}
+
+func (c *CLI) handleRolloutResume(namespace, id string, detach bool) error {
+  rollout, err := c.client.ResumeRollout(namespace, id)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  fmt.Printf("Rollout %s resumed\n", rollout.ID)
+  if detach {
+    return nil
+  }
+  return c.followRollout(namespace, rollout)
+}
+
+// followRollout 输出目标状态的变化直到滚动重启结束。Ctrl+C 只停止跟随，滚动重启在服务端继续
+func (c *CLI) followRollout(namespace string, rollout models.Rollout) error {
+  sigCh := make(chan os.Signal, 1)
+  signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+  defer signal.Stop(sigCh)
+
+  ticker := time.NewTicker(rolloutPollInterval)
+  defer ticker.Stop()
+
+  seen := map[string]models.RolloutTargetPhase{}
+  for {
+    for _, t := range rollout.Targets {
+      key := t.Name + "/" + t.Instance
+      if seen[key] == t.Phase {
+        continue
+      }
+      seen[key] = t.Phase
+      if t.Phase != models.RolloutTargetPending || t.Message != "" {
+        printRolloutTarget(t)
+      }
+    }
+    if rollout.Phase != models.RolloutRunning {
+      break
+    }
+
+    select {
+    case <-sigCh:
+      fmt.Printf("\nStopped following; rollout %s continues. Check it with 'proc rollout status %s -n %s'\n", rollout.ID, rollout.ID, namespace)
+      return nil
+    case <-ticker.C:
+    }
+    next, err := c.client.GetRollout(namespace, rollout.ID)
+    if err != nil {
+      fmt.Println("ERROR ", err.Error())
+      return nil
+    }
+    rollout = next
+  }
+
+  switch rollout.Phase {
+  case models.RolloutSucceeded:
+    fmt.Printf("Rollout %s succeeded\n", rollout.ID)
+  default:
+    fmt.Printf("Rollout %s %s: %s\n", rollout.ID, rollout.Phase, rollout.Message)
+    if rollout.Phase == models.RolloutFailed {
+      fmt.Printf("Resume it with 'proc rollout resume %s -n %s' once the problem is fixed\n", rollout.ID, namespace)
+    }
+  }
+  return nil
+}
+
+// printRollout 输出滚动重启及其所有目标
+func printRollout(rollout models.Rollout) {
+  fmt.Printf("Rollout:         %s\n", rollout.ID)
+  fmt.Printf("Namespace:       %s\n", rollout.Namespace)
+  fmt.Printf("Phase:           %s\n", rollout.Phase)
+  if rollout.Message != "" {
+    fmt.Printf("Message:         %s\n", rollout.Message)
+  }
+  if rollout.Options.LabelSelector != "" {
+    fmt.Printf("Selector:        %s\n", rollout.Options.LabelSelector)
+  }
+  fmt.Printf("Max Unavailable: %d\n", rollout.Options.MaxUnavailable)
+  fmt.Printf("Min Ready:       %s\n", rollout.Options.MinReady)
+  if rollout.Options.PauseBetween > 0 {
+    fmt.Printf("Pause Between:   %s\n", rollout.Options.PauseBetween)
+  }
+  fmt.Printf("Created:         %s\n", rollout.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+  if rollout.FinishedAt != nil {
+    fmt.Printf("Finished:        %s\n", rollout.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+  }
+  fmt.Println("Targets:")
+  for _, t := range rollout.Targets {
+    printRolloutTarget(t)
+  }
+}
+
+// printRolloutTarget 输出一个目标的状态
+func printRolloutTarget(t models.RolloutTarget) {
+  detail := ""
+  if t.PID > 0 {
+    detail = fmt.Sprintf("pid=%d", t.PID)
+  }
+  if t.Message != "" {
+    detail += " " + t.Message
+  }
+  fmt.Printf("  %-30s %-11s %s\n", t.DisplayName(), t.Phase, strings.TrimSpace(detail))
+}
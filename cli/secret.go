@@ -0,0 +1,158 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "strings"
+
+  "github.com/spf13/cobra"
+  "golang.org/x/term"
+)
+
+// setupSecretCommands 设置密钥相关子命令 (set/list/delete)
+func (c *CLI) setupSecretCommands() *cobra.Command {
+  secretCmd := &cobra.Command{
+    Use:   "secret",
+    Short: "Manage secrets referenced by process env",
+    Long:  "Manage server-side encrypted secrets. Processes reference them in env values as ${secret:name}; values are never returned by the server.",
+  }
+
+  secretCmd.AddCommand(c.setupSecretSetCommand())
+  secretCmd.AddCommand(c.setupSecretListCommand())
+  secretCmd.AddCommand(c.setupSecretDeleteCommand())
+
+  return secretCmd
+}
+
+// setupSecretSetCommand 设置密钥创建/更新命令
+func (c *CLI) setupSecretSetCommand() *cobra.Command {
+  var value string
+  var fromFile string
+
+  cmd := &cobra.Command{
+    Use:   "set <name>",
+    Short: "Create or update a secret",
+    Long:  "Create or update a secret. The value is read from --from-file, --value, or prompted for (read from stdin when not a terminal).",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleSecretSet(args[0], value, cmd.Flags().Changed("value"), fromFile)
+    },
+  }
+  cmd.Flags().StringVar(&value, "value", "", "Secret value (visible in shell history, prefer the prompt or --from-file)")
+  cmd.Flags().StringVar(&fromFile, "from-file", "", "Read the secret value from a file")
+
+  return cmd
+}
+
+// setupSecretListCommand 设置密钥列表命令
+func (c *CLI) setupSecretListCommand() *cobra.Command {
+  return &cobra.Command{
+    Use:   "list",
+    Short: "List secrets",
+    Long:  "List secret names and timestamps. Values are never shown.",
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleSecretList()
+    },
+  }
+}
+
+// setupSecretDeleteCommand 设置密钥删除命令
+func (c *CLI) setupSecretDeleteCommand() *cobra.Command {
+  return &cobra.Command{
+    Use:   "delete <name>",
+    Short: "Delete a secret",
+    Long:  "Delete a secret. Secrets still referenced by a process cannot be deleted.",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return c.handleSecretDelete(args[0])
+    },
+  }
+}
+
+// readSecretValue 按 --from-file、--value、交互输入（或标准输入）的顺序读取密钥值
+func readSecretValue(name, value string, hasValue bool, fromFile string) (string, error) {
+  switch {
+  case fromFile != "":
+    data, err := os.ReadFile(fromFile)
+    if err != nil {
+      return "", err
+    }
+    return strings.TrimRight(string(data), "\r\n"), nil
+  case hasValue:
+    return value, nil
+  case term.IsTerminal(int(os.Stdin.Fd())):
+    fmt.Printf("Value for secret %s: ", name)
+    v, err := readPassword()
+    fmt.Println()
+    return v, err
+  default:
+    data, err := io.ReadAll(os.Stdin)
+    if err != nil {
+      return "", err
+    }
+    return strings.TrimRight(string(data), "\r\n"), nil
+  }
+}
+
+func (c *CLI) handleSecretSet(name, value string, hasValue bool, fromFile string) error {
+  v, err := readSecretValue(name, value, hasValue, fromFile)
+  if err != nil {
+    fmt.Println("ERROR failed to read secret value:", err.Error())
+    return nil
+  }
+  created, err := c.client.SetSecret(name, v)
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if created {
+    fmt.Printf("Secret %s created\n", name)
+  } else {
+    fmt.Printf("Secret %s updated\n", name)
+  }
+  return nil
+}
+
+func (c *CLI) handleSecretList() error {
+  secrets, err := c.client.ListSecrets()
+  if err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  if len(secrets) == 0 {
+    fmt.Println("No secrets found.")
+    return nil
+  }
+  fmt.Printf("%-30s %-20s %-20s\n", "NAME", "CREATED", "UPDATED")
+  for _, s := range secrets {
+    fmt.Printf("%-30s %-20s %-20s\n", s.Name,
+      s.CreatedAt.Local().Format("2006-01-02 15:04:05"), s.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+  }
+  return nil
+}
+
+func (c *CLI) handleSecretDelete(name string) error {
+  if err := c.client.DeleteSecret(name); err != nil {
+    fmt.Println("ERROR ", err.Error())
+    return nil
+  }
+  fmt.Printf("Secret %s deleted\n", name)
+  return nil
+}
@@ -35,6 +35,9 @@ import (
 )
 
 func main() {
+	// Run as the init of an isolated process when re-executed by the process manager
+	proc.IsolationInit()
+
 	// Parse command line arguments
 	var (
 		configPath  string
@@ -68,7 +71,7 @@ func main() {
 	}
 
 	// Common initialization
-	processManager, processStore := initProcessManager()
+	processManager, processStore := initProcessManager(cfg)
 	server := api.NewServerWithManager(cfg, processManager, configPath)
 
 	// Check if running in upgrade mode (new process)
@@ -88,8 +91,22 @@ func main() {
 }
 
 // initProcessManager initializes the process manager and store
-func initProcessManager() (*proc.Manager, *proc.ProcessStore) {
+func initProcessManager(cfg *config.Config) (*proc.Manager, *proc.ProcessStore) {
 	processManager := proc.NewManager()
+	pidDir := "data/pids"
+	crashDir := "data/crashes"
+	if cfg != nil {
+		processManager.SetEventRetention(cfg.Process.EventRetention, cfg.Process.MaxEvents)
+		processManager.SetEncryptionKey(cfg.Security.EncryptionKey)
+		if cfg.Process.PidDir != "" {
+			pidDir = cfg.Process.PidDir
+		}
+		if cfg.Process.CrashDir != "" {
+			crashDir = cfg.Process.CrashDir
+		}
+	}
+	processManager.SetPIDDir(pidDir)
+	processManager.SetCrashDir(crashDir)
 	dbPath := "data/vigil.db"
 	processStore, err := proc.NewProcessStore(dbPath)
 	if err != nil {
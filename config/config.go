@@ -21,6 +21,7 @@ import (
   "encoding/base64"
   "log"
   "os"
+  "time"
 
   "github.com/casuallc/vigil/common"
 
@@ -34,6 +35,7 @@ type Config struct {
   Log          LogConfig          `yaml:"log"`
   Monitor      MonitorConfig      `yaml:"monitor"`
   Process      ProcConfig         `yaml:"process"`
+  Exporter     ExporterConfig     `yaml:"exporter"`
   Security     SecurityConfig     `yaml:"security"`
   HTTPS        HTTPSConfig        `yaml:"https"`
   Database     DatabaseConfig     `yaml:"database"`
@@ -60,6 +62,22 @@ type MonitorConfig struct {
 
 type ProcConfig struct {
   PidFile string `yaml:"pid_file"`
+  // PidDir 纳管进程 PID 文件的目录，vigil 重启后据此重关联仍在运行的进程，默认 data/pids
+  PidDir string `yaml:"pid_dir,omitempty"`
+  // CrashDir 崩溃报告（spec.on_crash）的目录，默认 data/crashes
+  CrashDir string `yaml:"crash_dir,omitempty"`
+  // EventRetention 进程事件的保留时间，默认 168h
+  EventRetention time.Duration `yaml:"event_retention,omitempty"`
+  // MaxEvents 每个进程保留的最大事件数，默认 1000
+  MaxEvents int `yaml:"max_events,omitempty"`
+}
+
+// ExporterConfig 配置 /metrics 中每个纳管进程的指标
+type ExporterConfig struct {
+  // ProcessLabels 作为指标标签导出的 metadata.labels 键（label_<key>），最多 8 个
+  ProcessLabels []string `yaml:"process_labels,omitempty"`
+  // MaxProcesses 每次抓取最多导出的进程（含副本实例）数，默认 1000
+  MaxProcesses int `yaml:"max_processes,omitempty"`
 }
 
 type SecurityConfig struct {
@@ -22,7 +22,9 @@ const namespace = "node"
 
 // NodeExporter holds the Prometheus registry and the collectors that feed it.
 type NodeExporter struct {
-	registry   *prometheus.Registry
+	registry *prometheus.Registry
+	// mu guards collectors, which AddCollector can extend after creation.
+	mu         sync.RWMutex
 	collectors map[string]Collector
 
 	scrapeDuration *prometheus.Desc
@@ -35,6 +37,15 @@ func (n *NodeExporter) Registry() *prometheus.Registry {
 	return n.registry
 }
 
+// AddCollector registers an additional collector, such as the per-process
+// collector that needs the process manager. A collector with the same name
+// is replaced.
+func (n *NodeExporter) AddCollector(c Collector) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.collectors[c.Name()] = c
+}
+
 // newNodeExporterWithCollectors builds a NodeExporter from an explicit
 // collector map. Used by tests; production code goes through NewNodeExporter
 // in the platform-specific file.
@@ -70,6 +81,8 @@ func (n *NodeExporter) Describe(ch chan<- *prometheus.Desc) {
 // single broken collector cannot fail the entire scrape.
 func (n *NodeExporter) Collect(ch chan<- prometheus.Metric) {
 	var wg sync.WaitGroup
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	for name, c := range n.collectors {
 		wg.Add(1)
 		go func(name string, c Collector) {
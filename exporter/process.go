@@ -0,0 +1,275 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// processNamespace is the metric prefix of the per-process collector.
+	// Managed processes are not node-level resources, so they do not use
+	// the node_ prefix.
+	processNamespace = "vigil"
+
+	// DefaultMaxProcesses is the default number of processes exported per
+	// scrape when ProcessCollectorOptions.MaxProcesses is not set.
+	DefaultMaxProcesses = 1000
+	// maxProcessLabels caps how many Metadata.Labels keys become metric
+	// labels.
+	maxProcessLabels = 8
+	// maxLabelValueLength truncates label values longer than this.
+	maxLabelValueLength = 128
+)
+
+// processPhases are the phases exported by vigil_process_phase, one series
+// per phase so that a process's phase can be selected with == 1.
+var processPhases = []models.Phase{
+	models.PhasePending,
+	models.PhaseRunning,
+	models.PhaseStopping,
+	models.PhaseStopped,
+	models.PhaseFailed,
+	models.PhaseCrashLoopBackOff,
+	models.PhaseSucceeded,
+	models.PhaseUnknown,
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ProcessLister lists the managed processes with the resource usage last
+// recorded by the process manager's sampler. proc.Manager implements it.
+type ProcessLister interface {
+	ListManagedProcesses(namespace string) ([]models.ManagedProcess, error)
+}
+
+// ProcessCollectorOptions controls the labels and series count of the
+// per-process collector.
+type ProcessCollectorOptions struct {
+	// Labels are the Metadata.Labels keys exported as label_<key>. Only the
+	// first 8 are used; processes without a label export it as "".
+	Labels []string
+	// MaxProcesses caps the number of processes (and replica instances)
+	// exported per scrape. Processes beyond the cap, in namespace/name
+	// order, are counted in vigil_process_dropped.
+	MaxProcesses int
+}
+
+// processCollector exports one set of metrics per managed process. It only
+// reads the ResourceStats cached by the sampler, so a scrape never walks
+// /proc itself.
+type processCollector struct {
+	lister       ProcessLister
+	labelKeys    []string
+	maxProcesses int
+
+	cpuSeconds *prometheus.Desc
+	rss        *prometheus.Desc
+	openFDs    *prometheus.Desc
+	maxFDs     *prometheus.Desc
+	threads    *prometheus.Desc
+	readBytes  *prometheus.Desc
+	writeBytes *prometheus.Desc
+	restarts   *prometheus.Desc
+	phase      *prometheus.Desc
+	ready      *prometheus.Desc
+	healthy    *prometheus.Desc
+	uptime     *prometheus.Desc
+	dropped    *prometheus.Desc
+}
+
+// NewProcessCollector returns a collector that exports the managed
+// processes listed by lister.
+func NewProcessCollector(lister ProcessLister, opts ProcessCollectorOptions) Collector {
+	labelKeys, labelNames := processLabelNames(opts.Labels)
+	variable := append([]string{"namespace", "name", "replica"}, labelNames...)
+	desc := func(name, help string, extra ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(processNamespace, "process", name),
+			help, append(append([]string(nil), variable...), extra...), nil,
+		)
+	}
+
+	maxProcesses := opts.MaxProcesses
+	if maxProcesses <= 0 {
+		maxProcesses = DefaultMaxProcesses
+	}
+	return &processCollector{
+		lister:       lister,
+		labelKeys:    labelKeys,
+		maxProcesses: maxProcesses,
+		cpuSeconds:   desc("cpu_seconds_total", "Total user and system CPU time spent in seconds."),
+		rss:          desc("resident_memory_bytes", "Resident memory size in bytes."),
+		openFDs:      desc("open_fds", "Number of open file descriptors."),
+		maxFDs:       desc("max_fds", "Maximum number of open file descriptors (RLIMIT_NOFILE)."),
+		threads:      desc("threads", "Number of OS threads."),
+		readBytes:    desc("io_read_bytes_total", "Bytes read from storage."),
+		writeBytes:   desc("io_write_bytes_total", "Bytes written to storage."),
+		restarts:     desc("restarts_total", "Number of automatic restarts."),
+		phase:        desc("phase", "Current phase of the process, 1 for the current phase.", "phase"),
+		ready:        desc("ready", "Whether the Ready condition is true."),
+		healthy:      desc("healthy", "Whether the last health check passed, only for processes with a health check."),
+		uptime:       desc("uptime_seconds", "Seconds since the running process started."),
+		dropped: prometheus.NewDesc(
+			prometheus.BuildFQName(processNamespace, "process", "dropped"),
+			"Number of processes not exported because of the max_processes limit.", nil, nil,
+		),
+	}
+}
+
+// processLabelNames returns the label keys to export and their metric label
+// names, skipping duplicates and keys beyond maxProcessLabels.
+func processLabelNames(keys []string) ([]string, []string) {
+	var labelKeys, labelNames []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		name := "label_" + invalidLabelChars.ReplaceAllString(key, "_")
+		if key == "" || seen[name] {
+			continue
+		}
+		if len(labelKeys) == maxProcessLabels {
+			log.Printf("exporter: only the first %d process labels are exported, ignoring %q", maxProcessLabels, key)
+			continue
+		}
+		seen[name] = true
+		labelKeys = append(labelKeys, key)
+		labelNames = append(labelNames, name)
+	}
+	return labelKeys, labelNames
+}
+
+func (c *processCollector) Name() string { return "process" }
+
+func (c *processCollector) Update(ch chan<- prometheus.Metric) error {
+	processes, err := c.lister.ListManagedProcesses("")
+	if err != nil {
+		return err
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		if processes[i].Metadata.Namespace != processes[j].Metadata.Namespace {
+			return processes[i].Metadata.Namespace < processes[j].Metadata.Namespace
+		}
+		return processes[i].Metadata.Name < processes[j].Metadata.Name
+	})
+
+	now := time.Now()
+	exported, dropped := 0, 0
+	for i := range processes {
+		mp := &processes[i]
+		labels := c.labelValues(mp)
+		// Replica instances are exported individually. Cron job runs are
+		// short-lived and uniquely named, so only the job itself is
+		// exported to keep the series count bounded.
+		if mp.Spec.Replicas != nil {
+			for j := range mp.Status.Instances {
+				if exported == c.maxProcesses {
+					dropped++
+					continue
+				}
+				inst := &mp.Status.Instances[j]
+				c.collectStatus(ch, &inst.Status, now, append([]string{mp.Metadata.Namespace, mp.Metadata.Name, inst.Name}, labels...))
+				exported++
+			}
+			continue
+		}
+		if exported == c.maxProcesses {
+			dropped++
+			continue
+		}
+		status := mp.Status
+		if mp.Spec.Job != nil && mp.Spec.Job.Schedule != "" {
+			status.ResourceStats = nil
+		}
+		c.collectStatus(ch, &status, now, append([]string{mp.Metadata.Namespace, mp.Metadata.Name, ""}, labels...))
+		exported++
+	}
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.GaugeValue, float64(dropped))
+	return nil
+}
+
+// labelValues returns the values of the exported Metadata.Labels keys.
+func (c *processCollector) labelValues(mp *models.ManagedProcess) []string {
+	values := make([]string, len(c.labelKeys))
+	for i, key := range c.labelKeys {
+		v := mp.Metadata.Labels[key]
+		if len(v) > maxLabelValueLength {
+			// Cut on a rune boundary: MustNewConstMetric panics on
+			// invalid UTF-8.
+			n := maxLabelValueLength
+			for n > 0 && !utf8.RuneStart(v[n]) {
+				n--
+			}
+			v = v[:n]
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// collectStatus emits the metrics of one process or replica instance.
+func (c *processCollector) collectStatus(ch chan<- prometheus.Metric, status *models.Status, now time.Time, labels []string) {
+	gauge := func(desc *prometheus.Desc, v float64, extra ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, append(labels, extra...)...)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labels...)
+	}
+
+	phase := status.Phase
+	if phase == "" {
+		phase = models.PhaseUnknown
+	}
+	for _, p := range processPhases {
+		gauge(c.phase, boolValue(p == phase), string(p))
+	}
+	counter(c.restarts, float64(status.RestartCount))
+	ready := status.GetCondition(models.ConditionTypeReady)
+	gauge(c.ready, boolValue(ready != nil && ready.Status == models.ConditionTrue))
+	if healthy := status.GetCondition(models.ConditionTypeHealthy); healthy != nil {
+		gauge(c.healthy, boolValue(healthy.Status == models.ConditionTrue))
+	}
+
+	if phase != models.PhaseRunning {
+		return
+	}
+	if status.StartTime != nil && !status.StartTime.IsZero() {
+		gauge(c.uptime, now.Sub(*status.StartTime).Seconds())
+	}
+	if stats := status.ResourceStats; stats != nil {
+		counter(c.cpuSeconds, stats.CPUTotalTime)
+		gauge(c.rss, float64(stats.MemoryRSS))
+		gauge(c.openFDs, float64(stats.OpenFDs))
+		gauge(c.maxFDs, float64(stats.FDLimit))
+		gauge(c.threads, float64(stats.ThreadCount))
+		counter(c.readBytes, float64(stats.IOReadBytes))
+		counter(c.writeBytes, float64(stats.IOWriteBytes))
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
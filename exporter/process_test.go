@@ -0,0 +1,164 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+type fakeLister []models.ManagedProcess
+
+func (f fakeLister) ListManagedProcesses(namespace string) ([]models.ManagedProcess, error) {
+	return append([]models.ManagedProcess(nil), f...), nil
+}
+
+// gatherProcessMetrics returns "name{label=value,...}" -> value for the
+// vigil_process_* series.
+func gatherProcessMetrics(t *testing.T, lister ProcessLister, opts ProcessCollectorOptions) map[string]float64 {
+	t.Helper()
+	n, err := newNodeExporterWithCollectors(map[string]Collector{})
+	if err != nil {
+		t.Fatalf("newNodeExporterWithCollectors: %v", err)
+	}
+	n.AddCollector(NewProcessCollector(lister, opts))
+	families, err := n.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	got := map[string]float64{}
+	for _, fam := range families {
+		if !strings.HasPrefix(fam.GetName(), "vigil_process_") {
+			continue
+		}
+		for _, m := range fam.GetMetric() {
+			var labels []string
+			for _, lp := range m.GetLabel() {
+				if lp.GetValue() != "" {
+					labels = append(labels, lp.GetName()+"="+lp.GetValue())
+				}
+			}
+			sort.Strings(labels)
+			key := fmt.Sprintf("%s{%s}", fam.GetName(), strings.Join(labels, ","))
+			switch {
+			case m.GetGauge() != nil:
+				got[key] = m.GetGauge().GetValue()
+			case m.GetCounter() != nil:
+				got[key] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	return got
+}
+
+func TestProcessCollector(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+	replicas := 2
+	web := models.ManagedProcess{
+		Metadata: models.Metadata{Namespace: "prod", Name: "web", Labels: map[string]string{"app": "shop", "team.name": "core", "commit": "abc"}},
+		Spec:     models.Spec{Replicas: &replicas},
+		Status: models.Status{Instances: []models.InstanceStatus{
+			{Index: 0, Name: "web-0", Status: models.Status{Phase: models.PhaseRunning, StartTime: &started, RestartCount: 2,
+				ResourceStats: &models.ResourceStats{CPUTotalTime: 1.5, MemoryRSS: 4096, OpenFDs: 12, FDLimit: 1024, ThreadCount: 3, IOReadBytes: 10, IOWriteBytes: 20}}},
+			{Index: 1, Name: "web-1", Status: models.Status{Phase: models.PhaseCrashLoopBackOff}},
+		}},
+	}
+	web.Status.Instances[0].Status.SetCondition(models.ConditionTypeReady, models.ConditionTrue, "", "")
+	web.Status.Instances[0].Status.SetCondition(models.ConditionTypeHealthy, models.ConditionTrue, "", "")
+	db := models.ManagedProcess{
+		Metadata: models.Metadata{Namespace: "prod", Name: "db"},
+		Status:   models.Status{Phase: models.PhaseStopped, ResourceStats: &models.ResourceStats{MemoryRSS: 1}},
+	}
+	backup := models.ManagedProcess{
+		Kind:     models.KindJob,
+		Metadata: models.Metadata{Namespace: "prod", Name: "backup"},
+		Spec:     models.Spec{Job: &models.JobSpec{Schedule: "@daily"}},
+		Status:   models.Status{Phase: models.PhaseRunning, ResourceStats: &models.ResourceStats{MemoryRSS: 1}},
+	}
+	lister := fakeLister{web, db, backup}
+
+	got := gatherProcessMetrics(t, lister, ProcessCollectorOptions{Labels: []string{"app", "team.name"}})
+	want := map[string]float64{
+		"vigil_process_cpu_seconds_total{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":            1.5,
+		"vigil_process_resident_memory_bytes{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":        4096,
+		"vigil_process_open_fds{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":                     12,
+		"vigil_process_max_fds{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":                      1024,
+		"vigil_process_threads{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":                      3,
+		"vigil_process_io_write_bytes_total{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":         20,
+		"vigil_process_restarts_total{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":               2,
+		"vigil_process_ready{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":                        1,
+		"vigil_process_healthy{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}":                      1,
+		"vigil_process_phase{label_app=shop,label_team_name=core,name=web,namespace=prod,phase=Running,replica=web-0}":          1,
+		"vigil_process_phase{label_app=shop,label_team_name=core,name=web,namespace=prod,phase=Running,replica=web-1}":          0,
+		"vigil_process_phase{label_app=shop,label_team_name=core,name=web,namespace=prod,phase=CrashLoopBackOff,replica=web-1}": 1,
+		"vigil_process_phase{name=db,namespace=prod,phase=Stopped}":                                                             1,
+		"vigil_process_ready{name=db,namespace=prod}":                                                                           0,
+		"vigil_process_phase{name=backup,namespace=prod,phase=Running}":                                                         1,
+		"vigil_process_dropped{}": 0,
+	}
+	for key, v := range want {
+		if g, ok := got[key]; !ok || g != v {
+			t.Errorf("%s = %v (present %t), want %v", key, g, ok, v)
+		}
+	}
+	if up := got["vigil_process_uptime_seconds{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-0}"]; up < 60 {
+		t.Errorf("uptime = %v, want at least 60s", up)
+	}
+	// Stopped processes and cron jobs export no resource metrics, processes
+	// without a health check export no healthy series, and unselected
+	// labels are not exported.
+	for key := range got {
+		for _, absent := range []string{"memory_bytes{name=db", "memory_bytes{name=backup", "healthy{name=db", "memory_bytes{label_app=shop,label_team_name=core,name=web,namespace=prod,replica=web-1}", "commit"} {
+			if strings.Contains(key, absent) {
+				t.Errorf("unexpected series %s", key)
+			}
+		}
+	}
+
+	// Processes beyond max_processes, in namespace/name order, are dropped.
+	got = gatherProcessMetrics(t, lister, ProcessCollectorOptions{MaxProcesses: 2})
+	if got["vigil_process_dropped{}"] != 2 {
+		t.Errorf("dropped = %v, want 2", got["vigil_process_dropped{}"])
+	}
+	for key := range got {
+		if strings.Contains(key, "name=web") {
+			t.Errorf("process beyond the limit was exported: %s", key)
+		}
+	}
+}
+
+func TestProcessCollectorLongLabel(t *testing.T) {
+	// 201 bytes; byte 128 is in the middle of an "é".
+	value := "x" + strings.Repeat("é", 100)
+	lister := fakeLister{{
+		Metadata: models.Metadata{Namespace: "prod", Name: "web", Labels: map[string]string{"owner": value}},
+		Status:   models.Status{Phase: models.PhaseRunning},
+	}}
+	got := gatherProcessMetrics(t, lister, ProcessCollectorOptions{Labels: []string{"owner"}})
+
+	want := "x" + strings.Repeat("é", 63)
+	key := "vigil_process_phase{label_owner=" + want + ",name=web,namespace=prod,phase=Running}"
+	if got[key] != 1 {
+		t.Errorf("%s missing, got %v", key, got)
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// attach 会话中服务端发送的二进制消息以输出流编号开头，其后是输出内容；
+// 文本消息是服务端的提示（如写入标准输入失败）
+const (
+  // AttachStreamStdout 表示标准输出
+  AttachStreamStdout byte = 1
+  // AttachStreamStderr 表示标准错误
+  AttachStreamStderr byte = 2
+)
+
+// AttachOutput 是 attach 会话收到的一段进程输出
+type AttachOutput struct {
+  // Stream 是 AttachStreamStdout 或 AttachStreamStderr
+  Stream byte
+  Data   []byte
+}
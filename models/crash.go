@@ -0,0 +1,64 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// CrashPolicy 配置进程异常退出（退出码非 0 或被信号杀死）时收集的崩溃报告。
+// 被 vigil 停止的进程和重关联的进程不收集
+type CrashPolicy struct {
+  // OutputKB 是报告中保留的 stdout 和 stderr 最后的内容大小（KB），默认 64
+  OutputKB int `json:"output_kb,omitempty" yaml:"output_kb,omitempty"`
+
+  // CoreDir 是 core dump 所在的目录，应与 /proc/sys/kernel/core_pattern 一致（如 /var/crash/core.%e.%p）。
+  // 文件名中包含进程 PID 且在本次运行期间生成的文件会被收集；为空时不收集 core dump
+  CoreDir string `json:"core_dir,omitempty" yaml:"core_dir,omitempty"`
+
+  // MaxCoreMB 是收集的 core dump 的大小上限（MB），超过时只在报告中记录文件名，默认 512
+  MaxCoreMB int `json:"max_core_mb,omitempty" yaml:"max_core_mb,omitempty"`
+
+  // HistoryLimit 是保留的崩溃报告数，默认 10
+  HistoryLimit int `json:"history_limit,omitempty" yaml:"history_limit,omitempty"`
+}
+
+// CrashFile 是崩溃报告中的一个文件
+type CrashFile struct {
+  Name string `json:"name" yaml:"name"`
+  Size int64  `json:"size" yaml:"size"`
+}
+
+// CrashReport 是一次异常退出的崩溃报告，内容打包为 tar.gz
+type CrashReport struct {
+  // ID 由退出时间和实例名组成，在进程内唯一
+  ID        string `json:"id" yaml:"id"`
+  Namespace string `json:"namespace" yaml:"namespace"`
+  Name      string `json:"name" yaml:"name"`
+  // Instance 是 Replicas 进程或定时 Job 中崩溃的实例名
+  Instance string `json:"instance,omitempty" yaml:"instance,omitempty"`
+  PID      int    `json:"pid" yaml:"pid"`
+  ExitCode int    `json:"exit_code" yaml:"exit_code"`
+  Signal   int    `json:"signal,omitempty" yaml:"signal,omitempty"`
+  // Reason 是退出原因，如 OOMKilled
+  Reason    string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+  StartTime time.Time `json:"start_time" yaml:"start_time"`
+  Time      time.Time `json:"time" yaml:"time"`
+  // Size 是 tar.gz 文件的大小
+  Size  int64       `json:"size,omitempty" yaml:"size,omitempty"`
+  Files []CrashFile `json:"files" yaml:"files"`
+  // SkippedCores 是超过 MaxCoreMB 而没有收集的 core dump
+  SkippedCores []string `json:"skipped_cores,omitempty" yaml:"skipped_cores,omitempty"`
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// EventType 是进程生命周期事件的类型
+type EventType string
+
+const (
+  // EventStarted 进程已启动
+  EventStarted EventType = "Started"
+  // EventExited 进程已退出，记录退出码和信号
+  EventExited EventType = "Exited"
+  // EventRestarted 进程被自动重启
+  EventRestarted EventType = "Restarted"
+  // EventProbeFailed 健康检查失败
+  EventProbeFailed EventType = "ProbeFailed"
+  // EventReAssociated 重新关联到已存在的进程
+  EventReAssociated EventType = "ReAssociated"
+  // EventMountFailed 启动前挂载目录失败
+  EventMountFailed EventType = "MountFailed"
+  // EventConfigChanged 进程定义被修改
+  EventConfigChanged EventType = "ConfigChanged"
+  // EventKilled 进程未在宽限期内退出，被强制杀死
+  EventKilled EventType = "Killed"
+  // EventScaled Replicas 进程的实例数被调整
+  EventScaled EventType = "Scaled"
+  // EventReady 进程通过 sd_notify 报告就绪（READY=1）
+  EventReady EventType = "Ready"
+  // EventNotifyTimeout 进程未在期限内报告就绪或看门狗心跳
+  EventNotifyTimeout EventType = "NotifyTimeout"
+  // EventCompleted Job 已成功完成
+  EventCompleted EventType = "Completed"
+  // EventJobFailed Job 超过重试次数或运行时间上限，已失败
+  EventJobFailed EventType = "JobFailed"
+  // EventScheduled 定时任务按时间表开始了一次运行，或因 ConcurrencyPolicy 跳过
+  EventScheduled EventType = "Scheduled"
+  // EventWatchdogTriggered 看门狗规则触发，reason 为规则名
+  EventWatchdogTriggered EventType = "WatchdogTriggered"
+  // EventFileChanged 监视的文件内容变化并触发了动作，reason 为动作
+  EventFileChanged EventType = "FileChanged"
+  // EventCrashReported 进程异常退出后生成了崩溃报告，reason 为报告 ID
+  EventCrashReported EventType = "CrashReported"
+)
+
+// ProcessEvent 是一条进程生命周期事件
+type ProcessEvent struct {
+  // ID 单调递增，可用于增量查询和断线续传
+  ID        int64     `json:"id" yaml:"id"`
+  Namespace string    `json:"namespace" yaml:"namespace"`
+  Name      string    `json:"name" yaml:"name"`
+  Type      EventType `json:"type" yaml:"type"`
+  Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+  PID       int       `json:"pid,omitempty" yaml:"pid,omitempty"`
+  // ExitCode 和 Signal 只在 Exited 事件中设置
+  ExitCode *int   `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+  Signal   int    `json:"signal,omitempty" yaml:"signal,omitempty"`
+  Reason   string `json:"reason,omitempty" yaml:"reason,omitempty"`
+  Message  string `json:"message,omitempty" yaml:"message,omitempty"`
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// ProcessKind 是纳管进程的类型
+type ProcessKind string
+
+const (
+  // KindService 是长期运行的服务（默认）
+  KindService ProcessKind = "Service"
+  // KindJob 是运行到完成的任务
+  KindJob ProcessKind = "Job"
+)
+
+// ConcurrencyPolicy 控制定时任务上一次运行尚未结束时如何处理新的运行
+type ConcurrencyPolicy string
+
+const (
+  // ConcurrencyAllow 允许多次运行同时进行（默认）
+  ConcurrencyAllow ConcurrencyPolicy = "Allow"
+  // ConcurrencyForbid 上一次运行未结束时跳过本次运行
+  ConcurrencyForbid ConcurrencyPolicy = "Forbid"
+  // ConcurrencyReplace 停止上一次运行，再开始本次运行
+  ConcurrencyReplace ConcurrencyPolicy = "Replace"
+)
+
+// JobSpec 是 Kind 为 Job 的进程的配置。Job 复用进程的运行时（env、user、mounts、log 等），
+// 进程成功退出 Completions 次后完成；RestartPolicy 对 Job 无效，失败后按 BackoffLimit 重试
+type JobSpec struct {
+  // Completions 是需要成功完成的次数，按顺序运行，默认 1
+  Completions int `json:"completions,omitempty" yaml:"completions,omitempty"`
+
+  // BackoffLimit 是失败重试的次数上限，超过后 Job 失败，默认 6
+  BackoffLimit *int `json:"backoff_limit,omitempty" yaml:"backoff_limit,omitempty"`
+
+  // ActiveDeadline 是一次 Job 从开始到完成的最长时间（包括重试），超时后停止并置为失败，0 表示不限制
+  ActiveDeadline time.Duration `json:"active_deadline,omitempty" yaml:"active_deadline,omitempty"`
+
+  // Schedule 是 cron 表达式（分 时 日 月 周，或 @hourly、@daily、@every 10m 等），设置后按时间表创建 Job 运行
+  Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+  // ConcurrencyPolicy 控制上一次定时运行未结束时的行为：Allow（默认）、Forbid、Replace
+  ConcurrencyPolicy ConcurrencyPolicy `json:"concurrency_policy,omitempty" yaml:"concurrency_policy,omitempty"`
+
+  // Suspend 暂停定时调度，已经开始的运行不受影响
+  Suspend bool `json:"suspend,omitempty" yaml:"suspend,omitempty"`
+
+  // HistoryLimit 是保留的运行记录条数，默认 100
+  HistoryLimit int `json:"history_limit,omitempty" yaml:"history_limit,omitempty"`
+}
+
+// JobStatus 是 Job 的运行状态
+type JobStatus struct {
+  // Succeeded 和 Failed 是本次 Job 成功和失败的运行次数
+  Succeeded int `json:"succeeded,omitempty" yaml:"succeeded,omitempty"`
+  Failed    int `json:"failed,omitempty" yaml:"failed,omitempty"`
+
+  // Active 是定时任务正在进行的 Job 数
+  Active int `json:"active,omitempty" yaml:"active,omitempty"`
+
+  // StartTime 是本次 Job 的开始时间，CompletionTime 是成功完成的时间
+  StartTime      *time.Time `json:"start_time,omitempty" yaml:"start_time,omitempty"`
+  CompletionTime *time.Time `json:"completion_time,omitempty" yaml:"completion_time,omitempty"`
+
+  // LastScheduleTime 和 NextScheduleTime 是定时任务上一次和下一次调度的时间
+  LastScheduleTime *time.Time `json:"last_schedule_time,omitempty" yaml:"last_schedule_time,omitempty"`
+  NextScheduleTime *time.Time `json:"next_schedule_time,omitempty" yaml:"next_schedule_time,omitempty"`
+}
+
+// JobRun 是 Job 的一次运行记录
+type JobRun struct {
+  ID        int64  `json:"id" yaml:"id"`
+  Namespace string `json:"namespace" yaml:"namespace"`
+  // Name 是 Job 名；定时任务的运行由实例执行，Instance 是实例名
+  Name     string `json:"name" yaml:"name"`
+  Instance string `json:"instance,omitempty" yaml:"instance,omitempty"`
+  // Attempt 是本次 Job 中的第几次运行，从 1 开始
+  Attempt int `json:"attempt" yaml:"attempt"`
+  PID     int `json:"pid,omitempty" yaml:"pid,omitempty"`
+
+  StartTime  time.Time     `json:"start_time" yaml:"start_time"`
+  FinishTime time.Time     `json:"finish_time" yaml:"finish_time"`
+  Duration   time.Duration `json:"duration" yaml:"duration"`
+
+  ExitCode  int    `json:"exit_code" yaml:"exit_code"`
+  Signal    int    `json:"signal,omitempty" yaml:"signal,omitempty"`
+  Succeeded bool   `json:"succeeded" yaml:"succeeded"`
+  Reason    string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+  // Stdout 和 Stderr 是本次运行最后输出的内容
+  Stdout string `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+  Stderr string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// FindingSeverity 是预检发现的问题的严重程度
+type FindingSeverity string
+
+const (
+  // FindingError 启动时一定会失败的问题
+  FindingError FindingSeverity = "error"
+  // FindingWarning 可能导致进程行为不符合预期的问题
+  FindingWarning FindingSeverity = "warning"
+)
+
+// PreflightFinding 是预检发现的一个问题
+type PreflightFinding struct {
+  Severity FindingSeverity `json:"severity" yaml:"severity"`
+  // Field 是问题所在的字段，如 spec.exec.command、spec.mounts[0].source
+  Field string `json:"field" yaml:"field"`
+  // Instance 是 Replicas 进程中出现问题的实例名
+  Instance string `json:"instance,omitempty" yaml:"instance,omitempty"`
+  Message  string `json:"message" yaml:"message"`
+}
+
+// PreflightResult 是对进程定义的预检结果，预检只检查不做任何修改
+type PreflightResult struct {
+  Namespace string `json:"namespace" yaml:"namespace"`
+  Name      string `json:"name" yaml:"name"`
+  // Passed 表示没有 error 级别的问题
+  Passed   bool               `json:"passed" yaml:"passed"`
+  Findings []PreflightFinding `json:"findings" yaml:"findings"`
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package models
 
 import (
+  "encoding/json"
   "github.com/casuallc/vigil/common"
   "github.com/casuallc/vigil/config"
+  "gopkg.in/yaml.v3"
   "time"
 )
 
 // ManagedProcess 是对一个进程的完整声明式描述，包含 Spec（期望状态）和 Status（实际状态）
 type ManagedProcess struct {
+  // Kind 是进程类型：Service（默认，长期运行）或 Job（运行到完成）
+  Kind ProcessKind `json:"kind,omitempty" yaml:"kind,omitempty"`
+
   // Metadata 包含进程的元信息，如名称、标签、注解等
   Metadata Metadata `json:"metadata" yaml:"metadata"`
 
@@ -67,36 +72,159 @@ type Spec struct {
   // 目录挂载配置（仅在 Linux 下有效，类似 docker volume）
   Mounts []Mount `json:"mounts,omitempty" yaml:"mounts,omitempty"`
 
+  // Isolation 在独立的 Linux 命名空间中运行进程（可选）
+  Isolation *Isolation `json:"isolation,omitempty" yaml:"isolation,omitempty"`
+
   // User 和 UserGroup 指定运行用户
   User      string `json:"user,omitempty" yaml:"user,omitempty"`
   UserGroup string `json:"user_group,omitempty" yaml:"user_group,omitempty"`
 
-  // Env 是环境变量列表
+  // Env 是环境变量列表，Value 可以使用 ${secret:name} 引用密钥
   Env []EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
 
+  // EnvFrom 从 dotenv 文件加载环境变量，按顺序加载，Env 中的同名变量优先
+  EnvFrom []EnvFromSource `json:"env_from,omitempty" yaml:"env_from,omitempty"`
+
   // Log 配置日志输出
   Log LogConfig `json:"log,omitempty" yaml:"log,omitempty"`
 
+  // Stdin 为进程保持一个打开的标准输入管道（可选），attach 会话可以向其写入
+  Stdin bool `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+
   // RestartPolicy 控制重启行为
   RestartPolicy RestartPolicy `json:"restart_policy,omitempty" yaml:"restart_policy,omitempty"`
 
-  // RestartInterval 是重启间隔（例如 5s）
+  // RestartInterval 是重启间隔（例如 5s），也是崩溃重启指数退避的初始间隔
   RestartInterval time.Duration `json:"restart_interval,omitempty" yaml:"restart_interval,omitempty"`
 
+  // RestartBackoff 控制崩溃重启的指数退避和重启次数上限（可选）
+  RestartBackoff *RestartBackoff `json:"restart_backoff,omitempty" yaml:"restart_backoff,omitempty"`
+
   // Lifecycle 钩子（可选）
   Lifecycle *Lifecycle `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
 
   // HealthCheck 健康检查配置（可选）
   HealthCheck *HealthCheck `json:"health_check,omitempty" yaml:"health_check,omitempty"`
 
+  // Notify 启用 systemd 的 sd_notify 协议（可选，仅 Unix），进程通过 NOTIFY_SOCKET 报告就绪、状态和看门狗
+  Notify *NotifyConfig `json:"notify,omitempty" yaml:"notify,omitempty"`
+
+  // Watchdog 是基于资源采样的看门狗规则（可选），条件成立时记录事件、执行命令、发送信号或重启进程
+  Watchdog []WatchdogRule `json:"watchdog,omitempty" yaml:"watchdog,omitempty"`
+
+  // Watch 监视配置文件（可选），文件内容变化后重启进程、发送信号或执行命令
+  Watch *WatchConfig `json:"watch,omitempty" yaml:"watch,omitempty"`
+
+  // OnCrash 在进程异常退出时收集崩溃报告（可选）：最后的输出、资源使用、/proc 信息和 core dump
+  OnCrash *CrashPolicy `json:"on_crash,omitempty" yaml:"on_crash,omitempty"`
+
   // AppConfig 是 Vigil 特有的应用配置
   Config config.AppConfig `json:"config,omitempty" yaml:"config,omitempty"`
 
   // ProcessMatcher 用于直接匹配进程ID的脚本，脚本应输出匹配到的进程ID
   ProcessMatcher *CommandConfig `json:"process_matcher,omitempty" yaml:"process_matcher,omitempty"`
 
-  // Resources 资源限制（预留，可后续实现）
+  // Resources 资源请求与限制（Linux 下通过 cgroup v2 生效）
   Resources *ResourceRequirements `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+  // DependsOn 是同一 namespace 下需要先启动的进程
+  DependsOn []Dependency `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+
+  // Replicas 按同一定义运行多个实例（可选），实例名为 <name>-<index>，index 从 0 开始。
+  // Exec.Args、Env 的值、WorkingDir 和 Log 的目录与文件名中可以使用 {{.Index}} 和 {{.Name}}（实例名）
+  Replicas *int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+
+  // Job 是 Kind 为 Job 时的配置
+  Job *JobSpec `json:"job,omitempty" yaml:"job,omitempty"`
+}
+
+// Isolation 是进程的命名空间隔离配置（仅 Linux）。
+// 启用后进程在新的 mount、PID、UTS、IPC 命名空间中运行，Mounts 只在进程自己的 mount 命名空间内生效。
+type Isolation struct {
+  // Network 为进程创建独立的网络命名空间，其中只有 lo
+  Network bool `json:"network,omitempty" yaml:"network,omitempty"`
+
+  // Hostname 是进程 UTS 命名空间中的主机名，为空时沿用宿主机主机名
+  Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+
+  // NoNewPrivs 禁止进程通过 setuid 程序或文件 capability 获得新权限
+  NoNewPrivs bool `json:"no_new_privs,omitempty" yaml:"no_new_privs,omitempty"`
+
+  // DropCapabilities 是从 capability 边界集合中移除的能力（如 CAP_NET_RAW），ALL 表示全部移除
+  DropCapabilities []string `json:"drop_capabilities,omitempty" yaml:"drop_capabilities,omitempty"`
+
+  // ReadOnlyRootfs 将根文件系统以只读方式挂载，Mounts 中的可写挂载不受影响
+  ReadOnlyRootfs bool `json:"read_only_rootfs,omitempty" yaml:"read_only_rootfs,omitempty"`
+}
+
+// NotifyConfig 是 sd_notify 协议的配置。
+// 启用后进程保持 Pending 直到发送 READY=1，STATUS= 的内容记录在 Status.StatusText，
+// MAINPID= 更新跟踪的 PID（适用于 fork 后由子进程提供服务的守护进程）
+type NotifyConfig struct {
+  // ReadyTimeout 是等待 READY=1 的时间，超时视为启动失败，默认 90s
+  ReadyTimeout time.Duration `json:"ready_timeout,omitempty" yaml:"ready_timeout,omitempty"`
+
+  // WatchdogTimeout 大于 0 时要求进程在该时间内发送 WATCHDOG=1，超时则重启进程。
+  // 该值通过 WATCHDOG_USEC 传给进程，进程也可以发送 WATCHDOG_USEC= 修改
+  WatchdogTimeout time.Duration `json:"watchdog_timeout,omitempty" yaml:"watchdog_timeout,omitempty"`
+}
+
+// DependencyCondition 是依赖进程需要满足的条件
+type DependencyCondition string
+
+const (
+  // DependencyConditionStarted 依赖进程处于 Running 即可
+  DependencyConditionStarted DependencyCondition = "started"
+  // DependencyConditionHealthy 依赖进程需要 Ready（配置了健康检查时即健康检查通过）
+  DependencyConditionHealthy DependencyCondition = "healthy"
+)
+
+// Dependency 描述对同一 namespace 下另一个进程的依赖
+type Dependency struct {
+  // Name 是被依赖进程的名称
+  Name string `json:"name" yaml:"name"`
+
+  // Condition 是启动依赖方前依赖进程需要满足的条件，默认 started
+  Condition DependencyCondition `json:"condition,omitempty" yaml:"condition,omitempty"`
+
+  // Timeout 是等待条件满足的超时时间，默认 2m
+  Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// UnmarshalJSON 支持直接写进程名的简写形式
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+  var name string
+  if err := json.Unmarshal(data, &name); err == nil {
+    *d = Dependency{Name: name}
+    return nil
+  }
+  type plain Dependency
+  return json.Unmarshal(data, (*plain)(d))
+}
+
+// UnmarshalYAML 支持直接写进程名的简写形式
+func (d *Dependency) UnmarshalYAML(value *yaml.Node) error {
+  if value.Kind == yaml.ScalarNode {
+    *d = Dependency{Name: value.Value}
+    return nil
+  }
+  type plain Dependency
+  return value.Decode((*plain)(d))
+}
+
+// RestartBackoff 定义崩溃重启的退避策略
+type RestartBackoff struct {
+  // MaxInterval 是退避间隔的上限，默认 5m
+  MaxInterval time.Duration `json:"max_interval,omitempty" yaml:"max_interval,omitempty"`
+
+  // MaxRestarts 是 Window 内允许的最大自动重启次数，超过后不再重启，0 表示不限制
+  MaxRestarts int `json:"max_restarts,omitempty" yaml:"max_restarts,omitempty"`
+
+  // Window 是统计 MaxRestarts 的时间窗口，默认 10m
+  Window time.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+
+  // ResetAfter 是进程稳定运行多久后重置退避，默认 10m
+  ResetAfter time.Duration `json:"reset_after,omitempty" yaml:"reset_after,omitempty"`
 }
 
 // Exec 定义如何执行进程
@@ -109,14 +237,46 @@ type Exec struct {
 
   // StopCommand
   StopCommand *CommandConfig `json:"stop_command" yaml:"stop_command"`
+
+  // StopSignal 是停止进程时发送的信号（如 SIGTERM、SIGINT、SIGQUIT），默认 SIGTERM
+  StopSignal string `json:"stop_signal,omitempty" yaml:"stop_signal,omitempty"`
+
+  // StopGracePeriod 是发送 StopSignal 后等待退出的时间，超时后发送 SIGKILL，默认 10s
+  StopGracePeriod time.Duration `json:"stop_grace_period,omitempty" yaml:"stop_grace_period,omitempty"`
+
+  // KillMode 决定停止时向哪些进程发送信号，默认 control-group
+  KillMode KillMode `json:"kill_mode,omitempty" yaml:"kill_mode,omitempty"`
 }
 
+// KillMode 决定停止进程时信号的发送范围，语义与 systemd 的 KillMode 相同。
+// 每个进程在独立的会话（进程组）中启动，进程组和 cgroup 内的进程都属于“进程组”。
+type KillMode string
+
+const (
+  // KillModeControlGroup StopSignal 和 SIGKILL 都发送给进程组内的所有进程
+  KillModeControlGroup KillMode = "control-group"
+  // KillModeProcess StopSignal 和 SIGKILL 只发送给主进程
+  KillModeProcess KillMode = "process"
+  // KillModeMixed StopSignal 只发送给主进程，SIGKILL 发送给进程组内的所有进程
+  KillModeMixed KillMode = "mixed"
+)
+
 // EnvVar 表示一个环境变量
 type EnvVar struct {
   Name  string `json:"name" yaml:"name"`
   Value string `json:"value" yaml:"value"`
 }
 
+// EnvFromSource 是环境变量来源（dotenv 文件）
+type EnvFromSource struct {
+  // File 是 dotenv 文件路径（KEY=VALUE，支持 # 注释和引号），相对路径基于工作目录
+  File string `json:"file" yaml:"file"`
+  // Prefix 添加到文件中每个变量名之前
+  Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+  // Optional 为 true 时文件不存在不报错
+  Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+}
+
 // Mount 定义目录挂载映射（Linux 绑定挂载、tmpfs、命名卷）
 type Mount struct {
   // Type: bind|tmpfs|named，默认 bind
@@ -153,9 +313,27 @@ type LogConfig struct {
   // Dir 是日志目录
   Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
 
-  // Stdout 和 Stderr 可分别重定向（可选）
+  // Stdout 和 Stderr 可分别重定向（可选），相对路径基于 Dir
   StdoutFile string `json:"stdout_file,omitempty" yaml:"stdout_file,omitempty"`
   StderrFile string `json:"stderr_file,omitempty" yaml:"stderr_file,omitempty"`
+
+  // MaxSize 是单个日志文件的最大大小（例如 100Mi），超过后轮转，为空表示不按大小轮转
+  MaxSize string `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+
+  // RotateInterval 是按时间轮转的间隔（例如 24h），为 0 表示不按时间轮转
+  RotateInterval time.Duration `json:"rotate_interval,omitempty" yaml:"rotate_interval,omitempty"`
+
+  // MaxFiles 是保留的轮转文件数量，0 表示不限制
+  MaxFiles int `json:"max_files,omitempty" yaml:"max_files,omitempty"`
+
+  // MaxAge 是轮转文件的最长保留时间，0 表示不限制
+  MaxAge time.Duration `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+
+  // Compress 是否使用 gzip 压缩轮转文件
+  Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+
+  // Timestamps 是否在每行日志前添加时间戳
+  Timestamps bool `json:"timestamps,omitempty" yaml:"timestamps,omitempty"`
 }
 
 // Lifecycle 定义进程生命周期钩子
@@ -209,7 +387,7 @@ type CommandConfig struct {
   Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
-// ResourceRequirements 资源请求与限制（预留）
+// ResourceRequirements 资源请求与限制，键见 ResourceCPU 等常量
 type ResourceRequirements struct {
   // Requests 是期望的资源
   Requests ResourceList `json:"requests,omitempty" yaml:"requests,omitempty"`
@@ -221,6 +399,23 @@ type ResourceRequirements struct {
 // ResourceList 是资源名到数量的映射（如 "cpu": "500m", "memory": "128Mi"）
 type ResourceList map[string]string
 
+// 资源名称（Linux 下通过 cgroup v2 生效）
+const (
+  // ResourceCPU CPU 核数（如 "500m"、"2"）；limits 对应 cpu.max，requests 对应 cpu.weight
+  ResourceCPU = "cpu"
+  // ResourceMemory 内存字节数（如 "512Mi"）；limits 对应 memory.max，requests 对应 memory.low
+  ResourceMemory = "memory"
+  // ResourceMemoryHigh 内存软限制，仅 limits 有效，对应 memory.high
+  ResourceMemoryHigh = "memory_high"
+  // ResourcePids 最大进程/线程数，仅 limits 有效，对应 pids.max
+  ResourcePids = "pids"
+  // 工作目录所在块设备的 IO 限制，仅 limits 有效，对应 io.max
+  ResourceIOReadBPS   = "io_read_bps"
+  ResourceIOWriteBPS  = "io_write_bps"
+  ResourceIOReadIOPS  = "io_read_iops"
+  ResourceIOWriteIOPS = "io_write_iops"
+)
+
 // Status 表示进程的当前运行状态（由系统维护）
 type Status struct {
   // Phase 是高层次状态（Running, Failed, Succeeded, Unknown）
@@ -238,11 +433,38 @@ type Status struct {
   // LastTerminationInfo 包含上次退出的信息
   LastTerminationInfo *TerminationInfo `json:"last_termination_info,omitempty" yaml:"last_termination_info,omitempty"`
 
-  // RestartCount 是重启次数
+  // StatusText 是进程通过 sd_notify 的 STATUS= 报告的状态描述
+  StatusText string `json:"status_text,omitempty" yaml:"status_text,omitempty"`
+
+  // RestartCount 是自动重启次数，手动启动不计入
   RestartCount int32 `json:"restart_count,omitempty" yaml:"restart_count,omitempty"`
 
+  // BackoffCount 是连续崩溃重启次数，决定下次重启的退避时间，稳定运行后清零
+  BackoffCount int32 `json:"backoff_count,omitempty" yaml:"backoff_count,omitempty"`
+
+  // NextRetryTime 是 CrashLoopBackOff 状态下次自动重启的时间
+  NextRetryTime *time.Time `json:"next_retry_time,omitempty" yaml:"next_retry_time,omitempty"`
+
   // ResourceStats 是资源使用统计
   ResourceStats *ResourceStats `json:"resource_stats,omitempty" yaml:"resource_stats,omitempty"`
+
+  // Instances 是设置了 Replicas 的进程各实例的状态，按 Index 排序，此时 Phase 和 Ready 条件由实例状态汇总得出；
+  // 对于定时 Job 是正在进行和最近结束的运行
+  Instances []InstanceStatus `json:"instances,omitempty" yaml:"instances,omitempty"`
+
+  // Job 是 Kind 为 Job 的进程的运行状态
+  Job *JobStatus `json:"job,omitempty" yaml:"job,omitempty"`
+}
+
+// InstanceStatus 是 Replicas 进程中单个实例的状态
+type InstanceStatus struct {
+  // Index 是实例序号
+  Index int `json:"index" yaml:"index"`
+
+  // Name 是实例名（<name>-<index>）
+  Name string `json:"name" yaml:"name"`
+
+  Status `yaml:",inline"`
 }
 
 // Phase 高层次状态
@@ -255,6 +477,10 @@ const (
   PhaseStopping Phase = "Stopping"
   PhaseStopped  Phase = "Stopped"
   PhaseUnknown  Phase = "Unknown"
+  // PhaseCrashLoopBackOff 进程崩溃后正在等待退避重启
+  PhaseCrashLoopBackOff Phase = "CrashLoopBackOff"
+  // PhaseSucceeded 表示 Job 已成功完成
+  PhaseSucceeded Phase = "Succeeded"
 )
 
 // Condition 表示一个状态条件
@@ -283,12 +509,58 @@ const (
   ConditionUnknown ConditionStatus = "Unknown"
 )
 
+// 条件类型
+const (
+  // ConditionTypeReady 表示进程已启动且（如配置了健康检查）探针已通过
+  ConditionTypeReady = "Ready"
+  // ConditionTypeHealthy 表示健康检查探针的最近结论
+  ConditionTypeHealthy = "Healthy"
+  // ConditionTypeResourcesEnforced 表示 Spec.Resources 是否已通过 cgroup 生效
+  ConditionTypeResourcesEnforced = "ResourcesEnforced"
+)
+
+// GetCondition 返回指定类型的条件，不存在时返回 nil
+func (s *Status) GetCondition(condType string) *Condition {
+  for i := range s.Conditions {
+    if s.Conditions[i].Type == condType {
+      return &s.Conditions[i]
+    }
+  }
+  return nil
+}
+
+// SetCondition 设置指定类型的条件，仅在 Status 发生变化时更新 LastTransitionTime
+func (s *Status) SetCondition(condType string, status ConditionStatus, reason, message string) {
+  if c := s.GetCondition(condType); c != nil {
+    if c.Status != status {
+      c.LastTransitionTime = time.Now()
+    }
+    c.Status = status
+    c.Reason = reason
+    c.Message = message
+    return
+  }
+  s.Conditions = append(s.Conditions, Condition{
+    Type:               condType,
+    Status:             status,
+    LastTransitionTime: time.Now(),
+    Reason:             reason,
+    Message:            message,
+  })
+}
+
 // TerminationInfo 记录进程退出信息
 type TerminationInfo struct {
   ExitCode   int       `json:"exit_code" yaml:"exit_code"`
   Signal     int       `json:"signal,omitempty" yaml:"signal,omitempty"`
   FinishedAt time.Time `json:"finished_at" yaml:"finished_at"`
-  Message    string    `json:"message,omitempty" yaml:"message,omitempty"`
+  // Reason 是退出原因的简短描述（如 OOMKilled）
+  Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+  Message string `json:"message,omitempty" yaml:"message,omitempty"`
+  // StopSignal 是 vigil 停止进程时发送的信号，进程自行退出时为空
+  StopSignal string `json:"stop_signal,omitempty" yaml:"stop_signal,omitempty"`
+  // Escalated 表示进程未在宽限期内退出，被 SIGKILL 强制终止
+  Escalated bool `json:"escalated,omitempty" yaml:"escalated,omitempty"`
 }
 
 // ResourceStats 表示资源使用情况
@@ -345,6 +617,8 @@ type ResourceStats struct {
   IOReadTimeMS  uint64 `json:"io_read_time_ms,omitempty" yaml:"io_read_time_ms,omitempty"`
   IOWriteTimeMS uint64 `json:"io_write_time_ms,omitempty" yaml:"io_write_time_ms,omitempty"`
   OpenFDs       int32  `json:"open_fds,omitempty" yaml:"open_fds,omitempty"`
+  // FDLimit 是进程可打开的文件描述符上限（RLIMIT_NOFILE 软限制）
+  FDLimit uint64 `json:"fd_limit,omitempty" yaml:"fd_limit,omitempty"`
 
   // 进程状态与调度
   ProcessStatus          string `json:"process_status,omitempty" yaml:"process_status,omitempty"`
@@ -470,3 +744,80 @@ const (
   RestartPolicyNever     RestartPolicy = "Never"
   RestartPolicyOnSuccess RestartPolicy = "OnSuccess"
 )
+
+// ProcessOperationResult 是批量操作中单个进程的结果
+type ProcessOperationResult struct {
+  Namespace string `json:"namespace" yaml:"namespace"`
+  Name      string `json:"name" yaml:"name"`
+  // Phase 是操作（以及等待）结束时进程的状态，进程已删除时为空
+  Phase Phase `json:"phase,omitempty" yaml:"phase,omitempty"`
+  // Error 为空表示操作成功
+  Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// BulkAction 是批量操作的类型
+type BulkAction string
+
+const (
+  BulkStart   BulkAction = "start"
+  BulkStop    BulkAction = "stop"
+  BulkRestart BulkAction = "restart"
+  BulkDelete  BulkAction = "delete"
+)
+
+// BulkOptions 是批量操作的选项
+type BulkOptions struct {
+  // LabelSelector 是 Kubernetes 风格的标签选择器（如 "app=kafka,tier!=dev"、"env in (prod,stage)"），
+  // 为空时选择 namespace 下的所有进程
+  LabelSelector string `json:"label_selector,omitempty" yaml:"label_selector,omitempty"`
+  // Parallelism 是同时操作的进程数上限，默认 1；存在依赖关系的进程仍然按依赖顺序操作
+  Parallelism int `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+  // Wait 等待所有目标进程到达期望状态：start 和 restart 为 Running（Job 为 Succeeded），stop 为停止
+  Wait bool `json:"wait,omitempty" yaml:"wait,omitempty"`
+  // Timeout 是等待的超时时间，默认 1m
+  Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// ApplyRequest 是声明式 apply 的请求：用一组进程定义描述 namespace 的期望状态
+type ApplyRequest struct {
+  Processes []ManagedProcess `json:"processes" yaml:"processes"`
+
+  // DryRun 只计算差异，不做任何修改
+  DryRun bool `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+
+  // Prune 删除 namespace 中不在 Processes 里的进程
+  Prune bool `json:"prune,omitempty" yaml:"prune,omitempty"`
+}
+
+// ApplyAction 是 apply 对单个进程计算出的差异
+type ApplyAction string
+
+const (
+  // ApplyCreated 进程不存在，将被创建
+  ApplyCreated ApplyAction = "created"
+  // ApplyChanged 进程定义有变化，将被更新
+  ApplyChanged ApplyAction = "changed"
+  // ApplyUnchanged 进程定义没有变化
+  ApplyUnchanged ApplyAction = "unchanged"
+  // ApplyOrphaned 进程不在清单中，Prune 时被删除
+  ApplyOrphaned ApplyAction = "orphaned"
+)
+
+// ApplyResult 是 apply 中单个进程的结果
+type ApplyResult struct {
+  Namespace string      `json:"namespace" yaml:"namespace"`
+  Name      string      `json:"name" yaml:"name"`
+  Action    ApplyAction `json:"action" yaml:"action"`
+
+  // Changes 是有变化的字段（如 spec.exec.args、metadata.labels）
+  Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"`
+
+  // Restart 表示影响执行的字段有变化且进程正在运行，进程被（DryRun 时将被）重启
+  Restart bool `json:"restart,omitempty" yaml:"restart,omitempty"`
+
+  // Pruned 表示孤立的进程被（DryRun 时将被）删除
+  Pruned bool `json:"pruned,omitempty" yaml:"pruned,omitempty"`
+
+  // Error 为空表示操作成功
+  Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// RolloutPhase 是滚动重启的状态
+type RolloutPhase string
+
+const (
+  // RolloutRunning 滚动重启正在进行
+  RolloutRunning RolloutPhase = "Running"
+  // RolloutSucceeded 所有目标都已重启并通过检查
+  RolloutSucceeded RolloutPhase = "Succeeded"
+  // RolloutFailed 某个目标重启失败或未通过检查，之后的目标没有重启
+  RolloutFailed RolloutPhase = "Failed"
+  // RolloutCancelled 滚动重启被用户取消
+  RolloutCancelled RolloutPhase = "Cancelled"
+)
+
+// RolloutTargetPhase 是滚动重启中单个进程（或 Replicas 实例）的状态
+type RolloutTargetPhase string
+
+const (
+  // RolloutTargetPending 等待重启
+  RolloutTargetPending RolloutTargetPhase = "Pending"
+  // RolloutTargetRestarting 正在重启
+  RolloutTargetRestarting RolloutTargetPhase = "Restarting"
+  // RolloutTargetVerifying 已重启，等待进程稳定运行并通过健康检查
+  RolloutTargetVerifying RolloutTargetPhase = "Verifying"
+  // RolloutTargetDone 已重启并通过检查
+  RolloutTargetDone RolloutTargetPhase = "Done"
+  // RolloutTargetSkipped 进程未运行或已被删除，没有重启
+  RolloutTargetSkipped RolloutTargetPhase = "Skipped"
+  // RolloutTargetFailed 重启失败或未通过检查
+  RolloutTargetFailed RolloutTargetPhase = "Failed"
+)
+
+// RolloutOptions 是滚动重启的选项
+type RolloutOptions struct {
+  // LabelSelector 是选择进程的标签选择器，为空时选择 namespace 下的所有进程
+  LabelSelector string `json:"label_selector,omitempty" yaml:"label_selector,omitempty"`
+  // MaxUnavailable 是同时重启（尚未通过检查）的目标数上限，默认 1
+  MaxUnavailable int `json:"max_unavailable,omitempty" yaml:"max_unavailable,omitempty"`
+  // PauseBetween 是开始重启下一个目标前的等待时间
+  PauseBetween time.Duration `json:"pause_between,omitempty" yaml:"pause_between,omitempty"`
+  // MinReady 是目标重启后需要保持 Running、PID 不变并通过健康检查的时长，默认 10s
+  MinReady time.Duration `json:"min_ready,omitempty" yaml:"min_ready,omitempty"`
+  // Timeout 是单个目标从重启到通过检查的超时时间，默认 5m
+  Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// RolloutTarget 是滚动重启的一个目标：普通进程，或 Replicas 进程的一个实例
+type RolloutTarget struct {
+  // Name 是进程名，Instance 是实例名（如 web-0），普通进程为空
+  Name     string             `json:"name" yaml:"name"`
+  Instance string             `json:"instance,omitempty" yaml:"instance,omitempty"`
+  Phase    RolloutTargetPhase `json:"phase" yaml:"phase"`
+  // PID 是重启后的进程号
+  PID     int    `json:"pid,omitempty" yaml:"pid,omitempty"`
+  Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+  StartedAt  *time.Time `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+  FinishedAt *time.Time `json:"finished_at,omitempty" yaml:"finished_at,omitempty"`
+}
+
+// Rollout 是一次滚动重启。目标按依赖顺序逐个（最多 MaxUnavailable 个同时）重启，
+// 上一个目标通过检查后才重启下一个，第一个失败的目标会中止滚动重启
+type Rollout struct {
+  ID        string         `json:"id" yaml:"id"`
+  Namespace string         `json:"namespace" yaml:"namespace"`
+  Options   RolloutOptions `json:"options" yaml:"options"`
+  Phase     RolloutPhase   `json:"phase" yaml:"phase"`
+  // Message 是失败或取消的原因
+  Message string          `json:"message,omitempty" yaml:"message,omitempty"`
+  Targets []RolloutTarget `json:"targets" yaml:"targets"`
+
+  CreatedAt  time.Time  `json:"created_at" yaml:"created_at"`
+  FinishedAt *time.Time `json:"finished_at,omitempty" yaml:"finished_at,omitempty"`
+}
+
+// DisplayName 返回目标的显示名：实例名，普通进程为进程名
+func (t *RolloutTarget) DisplayName() string {
+  if t.Instance != "" {
+    return t.Instance
+  }
+  return t.Name
+}
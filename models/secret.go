@@ -0,0 +1,28 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// Secret 是服务端保存的密钥，进程通过 ${secret:name} 引用。
+// Value 只在写入时使用，查询接口不会返回。
+type Secret struct {
+  Name      string    `json:"name" yaml:"name"`
+  Value     string    `json:"value,omitempty" yaml:"value,omitempty"`
+  CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+  UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
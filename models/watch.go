@@ -0,0 +1,70 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// WatchAction 是监视的文件变化后执行的动作
+type WatchAction string
+
+const (
+  // WatchActionRestart 重启进程（默认）
+  WatchActionRestart WatchAction = "restart"
+  // WatchActionSignal 向进程发送 Signal，用于支持重新加载配置的进程（如 SIGHUP）
+  WatchActionSignal WatchAction = "signal"
+  // WatchActionCommand 执行 Command
+  WatchActionCommand WatchAction = "command"
+)
+
+// WatchConfig 监视进程使用的配置文件，文件内容变化后重启进程、发送信号或执行命令
+type WatchConfig struct {
+  // Paths 是监视的文件，相对路径基于 WorkingDir；文件名中可以使用通配符（如 conf/*.yaml），目录部分不能使用
+  Paths []string `json:"paths" yaml:"paths"`
+
+  // Debounce 是最后一次变化后等待的时间，期间的多次修改只触发一次动作，默认 2s
+  Debounce time.Duration `json:"debounce,omitempty" yaml:"debounce,omitempty"`
+
+  // Action 是文件变化后的动作：restart（默认）、signal、command
+  Action WatchAction `json:"action,omitempty" yaml:"action,omitempty"`
+
+  // Signal 是 Action 为 signal 时发送的信号（如 SIGHUP、USR1）
+  Signal string `json:"signal,omitempty" yaml:"signal,omitempty"`
+
+  // Command 是 Action 为 command 时执行的命令，使用进程的环境变量和运行用户
+  Command *CommandConfig `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+// FileChange 是一个被监视文件的内容变化
+type FileChange struct {
+  // Path 是文件的绝对路径
+  Path string `json:"path" yaml:"path"`
+  // SHA256 是变化后文件内容的 SHA-256，文件被删除时为空
+  SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+  // Removed 表示文件被删除
+  Removed bool `json:"removed,omitempty" yaml:"removed,omitempty"`
+}
+
+// WatchTrigger 是一次由文件变化触发的动作，用于审计
+type WatchTrigger struct {
+  Namespace string       `json:"namespace" yaml:"namespace"`
+  Name      string       `json:"name" yaml:"name"`
+  PID       int          `json:"pid,omitempty" yaml:"pid,omitempty"`
+  Action    WatchAction  `json:"action" yaml:"action"`
+  Changes   []FileChange `json:"changes" yaml:"changes"`
+  // Error 是执行动作失败的原因
+  Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// WatchdogAction 是看门狗规则触发后执行的动作
+type WatchdogAction string
+
+const (
+  // WatchdogActionEvent 只记录 WatchdogTriggered 事件（默认）
+  WatchdogActionEvent WatchdogAction = "event"
+  // WatchdogActionCommand 执行 Command
+  WatchdogActionCommand WatchdogAction = "command"
+  // WatchdogActionSignal 向进程发送 Signal
+  WatchdogActionSignal WatchdogAction = "signal"
+  // WatchdogActionRestart 重启进程
+  WatchdogActionRestart WatchdogAction = "restart"
+)
+
+// WatchdogRule 是基于资源采样的看门狗规则。Rule 是 expr 表达式，可以引用 memory_rss、cpu_usage、
+// open_fds、fd_max 等变量，数值可以带 Ki/Mi/Gi 或 K/M/G 后缀；末尾的 "for <duration>" 表示条件需要持续成立的时间，
+// 例如 "memory_rss > 2Gi for 2m"、"open_fds > 0.9*fd_max"
+type WatchdogRule struct {
+  // Name 是规则名，记录在事件的 reason 中，默认为 Rule
+  Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+  // Rule 是触发条件
+  Rule string `json:"rule" yaml:"rule"`
+
+  // Action 是触发后的动作：event（默认）、command、signal、restart，任何动作都会记录事件
+  Action WatchdogAction `json:"action,omitempty" yaml:"action,omitempty"`
+
+  // Command 是 Action 为 command 时执行的命令，使用进程的环境变量和运行用户
+  Command *CommandConfig `json:"command,omitempty" yaml:"command,omitempty"`
+
+  // Signal 是 Action 为 signal 时发送的信号（如 SIGUSR1、HUP）
+  Signal string `json:"signal,omitempty" yaml:"signal,omitempty"`
+
+  // Cooldown 是两次触发之间的最短间隔，条件持续成立时按该间隔重复触发，默认 5m
+  Cooldown time.Duration `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+}
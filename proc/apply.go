@@ -0,0 +1,233 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// executionFields 是影响运行中进程的字段，变化后需要重启进程才能生效。
+// 其他字段（重启策略、健康检查、钩子、依赖等）在 vigil 中直接生效或在下次启动时使用。
+var executionFields = map[string]bool{
+	"spec.exec.command": true,
+	"spec.exec.args":    true,
+	"spec.working_dir":  true,
+	"spec.mounts":       true,
+	"spec.isolation":    true,
+	"spec.user":         true,
+	"spec.user_group":   true,
+	"spec.env":          true,
+	"spec.env_from":     true,
+	"spec.log":          true,
+	"spec.stdin":        true,
+	"spec.resources":    true,
+	"spec.notify":       true,
+	"spec.config":       true,
+}
+
+// nestedFields 是逐个比较子字段的结构体字段，便于区分是否影响执行
+var nestedFields = map[string]bool{
+	"spec.exec": true,
+}
+
+// ApplyProcesses 以 req.Processes 作为 namespace 的期望状态：创建不存在的进程，更新有变化的进程，
+// 只在影响执行的字段变化时重启运行中的进程；req.Prune 时删除不在清单中的进程。
+// 所有定义先通过校验才会做修改，req.DryRun 时只返回差异。
+func (m *Manager) ApplyProcesses(namespace string, req models.ApplyRequest) ([]models.ApplyResult, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	seen := make(map[string]bool, len(req.Processes))
+	processes := make([]models.ManagedProcess, 0, len(req.Processes))
+	for _, p := range req.Processes {
+		if p.Metadata.Namespace == "" {
+			p.Metadata.Namespace = namespace
+		}
+		if p.Metadata.Namespace != namespace {
+			return nil, fmt.Errorf("%w: process %s is in namespace %s, not %s", ErrInvalidProcess, p.Metadata.Name, p.Metadata.Namespace, namespace)
+		}
+		if err := ValidateProcess(&p); err != nil {
+			return nil, fmt.Errorf("process %s: %w", p.Metadata.Name, err)
+		}
+		if seen[p.Metadata.Name] {
+			return nil, fmt.Errorf("%w: duplicate process %s", ErrInvalidProcess, p.Metadata.Name)
+		}
+		seen[p.Metadata.Name] = true
+		p.Status = models.Status{}
+		processes = append(processes, p)
+	}
+	// 被依赖的进程先创建和更新
+	ordered, err := sortByDependencies(processes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+
+	results := make([]models.ApplyResult, 0, len(ordered))
+	for _, p := range ordered {
+		result := models.ApplyResult{Namespace: namespace, Name: p.Metadata.Name, Action: models.ApplyCreated}
+		if e, exists := m.getEntry(namespace, p.Metadata.Name); exists {
+			current := e.snapshot()
+			result.Changes = processChanges(&current, &p)
+			result.Action = models.ApplyUnchanged
+			if len(result.Changes) > 0 {
+				result.Action = models.ApplyChanged
+				result.Restart = needsRestart(result.Changes) && isActivePhase(current.Status.Phase)
+			}
+		}
+		results = append(results, result)
+	}
+
+	var orphans []models.ManagedProcess
+	list, _ := m.ListManagedProcesses(namespace)
+	for _, p := range list {
+		if !seen[p.Metadata.Name] {
+			orphans = append(orphans, p)
+			results = append(results, models.ApplyResult{
+				Namespace: namespace,
+				Name:      p.Metadata.Name,
+				Action:    models.ApplyOrphaned,
+				Pruned:    req.Prune,
+			})
+		}
+	}
+	if req.DryRun {
+		return results, nil
+	}
+
+	for i, p := range ordered {
+		result := &results[i]
+		var err error
+		switch result.Action {
+		case models.ApplyCreated:
+			err = m.CreateProcess(p)
+		case models.ApplyChanged:
+			err = m.UpdateProcess(p)
+			if err == nil && result.Restart {
+				err = m.RestartProcess(namespace, p.Metadata.Name)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+			result.Restart = false
+		}
+	}
+
+	if req.Prune {
+		m.pruneOrphans(orphans, results[len(ordered):])
+	}
+	return results, nil
+}
+
+// pruneOrphans 按依赖的逆序删除孤立的进程，依赖方先于被依赖的进程删除
+func (m *Manager) pruneOrphans(orphans []models.ManagedProcess, results []models.ApplyResult) {
+	index := make(map[string]*models.ApplyResult, len(results))
+	for i := range results {
+		index[results[i].Name] = &results[i]
+	}
+	ordered, err := sortByDependencies(orphans)
+	if err != nil {
+		ordered = orphans
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		result := index[ordered[i].Metadata.Name]
+		if err := m.DeleteProcess(result.Namespace, result.Name); err != nil {
+			result.Error = err.Error()
+			result.Pruned = false
+		}
+	}
+}
+
+// processChanges 比较当前定义和期望定义，返回有变化的字段
+func processChanges(current, desired *models.ManagedProcess) []string {
+	var changes []string
+	if processKind(current) != processKind(desired) {
+		changes = append(changes, "kind")
+	}
+	if !valuesEqual(reflect.ValueOf(current.Metadata.Labels), reflect.ValueOf(desired.Metadata.Labels)) {
+		changes = append(changes, "metadata.labels")
+	}
+	if !valuesEqual(reflect.ValueOf(current.Metadata.Annotations), reflect.ValueOf(desired.Metadata.Annotations)) {
+		changes = append(changes, "metadata.annotations")
+	}
+	return append(changes, structChanges("spec", reflect.ValueOf(current.Spec), reflect.ValueOf(desired.Spec))...)
+}
+
+// processKind 返回进程类型，未设置时为 Service
+func processKind(mp *models.ManagedProcess) models.ProcessKind {
+	if mp.Kind == "" {
+		return models.KindService
+	}
+	return mp.Kind
+}
+
+// structChanges 逐字段比较结构体，字段名使用 json 标签
+func structChanges(prefix string, current, desired reflect.Value) []string {
+	var changes []string
+	for i := 0; i < current.NumField(); i++ {
+		name, _, _ := strings.Cut(current.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + "." + name
+		if nestedFields[path] {
+			changes = append(changes, structChanges(path, current.Field(i), desired.Field(i))...)
+			continue
+		}
+		if !valuesEqual(current.Field(i), desired.Field(i)) {
+			changes = append(changes, path)
+		}
+	}
+	return changes
+}
+
+// valuesEqual 按序列化结果比较两个值，nil 与空集合视为相同
+func valuesEqual(a, b reflect.Value) bool {
+	if isEmptyValue(a) && isEmptyValue(b) {
+		return true
+	}
+	x, errX := json.Marshal(a.Interface())
+	y, errY := json.Marshal(b.Interface())
+	if errX != nil || errY != nil {
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+	return bytes.Equal(x, y)
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// needsRestart 变化的字段中是否有影响执行的字段
+func needsRestart(changes []string) bool {
+	for _, c := range changes {
+		if executionFields[c] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// applySummary 把 apply 结果格式化为 name=action[+restart][+pruned]，便于比较
+func applySummary(results []models.ApplyResult) string {
+	var s string
+	for _, r := range results {
+		s += fmt.Sprintf("%s=%s", r.Name, r.Action)
+		if r.Restart {
+			s += "+restart"
+		}
+		if r.Pruned {
+			s += "+pruned"
+		}
+		if r.Error != "" {
+			s += "!" + r.Error
+		}
+		s += " "
+	}
+	return s
+}
+
+func TestProcessChanges(t *testing.T) {
+	current := testProcess("/tmp", "web")
+	desired := testProcess("/tmp", "web")
+	current.Spec.Exec.Args = nil
+	desired.Spec.Exec.Args = []string{}
+	current.Status.Phase = models.PhaseRunning
+	if changes := processChanges(&current, &desired); len(changes) != 0 {
+		t.Fatalf("changes for nil vs empty args = %v, want none", changes)
+	}
+
+	desired.Spec.Exec.StopSignal = "SIGINT"
+	desired.Spec.RestartPolicy = models.RestartPolicyAlways
+	desired.Metadata.Labels = map[string]string{"tier": "web"}
+	changes := processChanges(&current, &desired)
+	if fmt.Sprint(changes) != "[metadata.labels spec.exec.stop_signal spec.restart_policy]" || needsRestart(changes) {
+		t.Fatalf("changes = %v (restart %t), want labels, stop_signal and restart_policy without restart", changes, needsRestart(changes))
+	}
+
+	desired.Spec.Exec.Args = []string{"600"}
+	desired.Spec.Env = []models.EnvVar{{Name: "A", Value: "1"}}
+	if changes := processChanges(&current, &desired); !needsRestart(changes) {
+		t.Fatalf("changes = %v, want restart", changes)
+	}
+
+	// 应用配置变化与 UpdateProcessConfig 一样需要重启
+	desired = current
+	desired.Spec.Config.Env = map[string]string{"MODE": "prod"}
+	if changes := processChanges(&current, &desired); fmt.Sprint(changes) != "[spec.config]" || !needsRestart(changes) {
+		t.Fatalf("changes = %v (restart %t), want spec.config with restart", changes, needsRestart(changes))
+	}
+}
+
+func TestManagerApply(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	db := testProcess(dir, "db")
+	web := testProcess(dir, "web")
+	web.Spec.DependsOn = []models.Dependency{{Name: "db"}}
+	// 清单中的 namespace 可以省略
+	web.Metadata.Namespace = ""
+
+	old := testProcess(dir, "old")
+	if err := m.CreateProcess(old); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+
+	results, err := m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{web, db}, DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("ApplyProcesses dry run: %v", err)
+	}
+	if got, want := applySummary(results), "db=created web=created old=orphaned+pruned "; got != want {
+		t.Fatalf("dry run = %q, want %q", got, want)
+	}
+	if list, _ := m.ListManagedProcesses("test"); len(list) != 1 {
+		t.Fatalf("dry run changed processes: %d managed", len(list))
+	}
+
+	results, err = m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{web, db}})
+	if err != nil {
+		t.Fatalf("ApplyProcesses: %v", err)
+	}
+	if got, want := applySummary(results), "db=created web=created old=orphaned "; got != want {
+		t.Fatalf("apply = %q, want %q", got, want)
+	}
+	for _, name := range []string{"db", "web"} {
+		if err := m.StartProcess("test", name); err != nil {
+			t.Fatalf("StartProcess %s: %v", name, err)
+		}
+	}
+	status, _ := m.GetProcessStatus("test", "web")
+	pid := status.Status.PID
+
+	// 只修改不影响执行的字段时不重启
+	web.Spec.RestartPolicy = models.RestartPolicyOnFailure
+	web.Spec.RestartInterval = 3 * time.Second
+	results, err = m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{db, web}})
+	if err != nil {
+		t.Fatalf("ApplyProcesses: %v", err)
+	}
+	if got, want := applySummary(results), "db=unchanged web=changed old=orphaned "; got != want {
+		t.Fatalf("apply = %q, want %q", got, want)
+	}
+	if fmt.Sprint(results[1].Changes) != "[spec.restart_policy spec.restart_interval]" {
+		t.Fatalf("changes = %v", results[1].Changes)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if status.Status.PID != pid || status.Spec.RestartPolicy != models.RestartPolicyOnFailure {
+		t.Fatalf("web after non-execution change: pid %d (was %d), policy %s", status.Status.PID, pid, status.Spec.RestartPolicy)
+	}
+
+	// 修改参数后重启运行中的进程，并清理孤立的进程
+	web.Spec.Exec.Args = []string{"301"}
+	results, err = m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{db, web}, Prune: true})
+	if err != nil {
+		t.Fatalf("ApplyProcesses: %v", err)
+	}
+	if got, want := applySummary(results), "db=unchanged web=changed+restart old=orphaned+pruned "; got != want {
+		t.Fatalf("apply = %q, want %q", got, want)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID == pid {
+		t.Fatalf("web after execution change: %s pid %d (was %d), want restarted", status.Status.Phase, status.Status.PID, pid)
+	}
+	if _, err := m.GetProcessStatus("test", "old"); err == nil {
+		t.Fatalf("orphaned process was not pruned")
+	}
+
+	// 修改应用配置同样重启进程
+	pid = status.Status.PID
+	web.Spec.Config.Env = map[string]string{"MODE": "prod"}
+	results, err = m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{db, web}})
+	if err != nil {
+		t.Fatalf("ApplyProcesses: %v", err)
+	}
+	if got, want := applySummary(results), "db=unchanged web=changed+restart "; got != want {
+		t.Fatalf("apply = %q, want %q", got, want)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID == pid || status.Spec.Config.Env["MODE"] != "prod" {
+		t.Fatalf("web after config change: %s pid %d (was %d), config %+v, want restarted", status.Status.Phase, status.Status.PID, pid, status.Spec.Config)
+	}
+
+	// 被清单中的进程依赖的孤立进程不能删除
+	results, err = m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{web}, Prune: true})
+	if err != nil {
+		t.Fatalf("ApplyProcesses: %v", err)
+	}
+	if len(results) != 2 || results[1].Name != "db" || results[1].Pruned || !errors.Is(m.DeleteProcess("test", "db"), ErrProcessInUse) {
+		t.Fatalf("pruning a dependency = %q, want an error", applySummary(results))
+	}
+
+	// 任何一个定义无效时不做修改
+	bad := testProcess(dir, "bad")
+	bad.Metadata.Namespace = "other"
+	if _, err := m.ApplyProcesses("test", models.ApplyRequest{Processes: []models.ManagedProcess{testProcess(dir, "new"), bad}}); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("ApplyProcesses with foreign namespace = %v, want ErrInvalidProcess", err)
+	}
+	if _, err := m.GetProcessStatus("test", "new"); err == nil {
+		t.Fatalf("invalid apply created a process")
+	}
+
+	for _, name := range []string{"web", "db"} {
+		_ = m.StopProcess("test", name)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// attachBufferSize 是每个进程在内存中保留的最近输出的大小，新的 attach 会话先回放这些输出
+	attachBufferSize = 64 * 1024
+	// attachWatchBuffer 是每个 attach 会话的输出通道容量，跟不上的会话丢弃输出
+	attachWatchBuffer = 256
+)
+
+// ErrStdinBusy 表示进程的标准输入已被另一个 attach 会话占用
+var ErrStdinBusy = errors.New("stdin is held by another attach session")
+
+// attachHub 保留进程最近的输出（跨重启）并分发给 attach 会话。
+// 同一时间只有一个会话可以写入标准输入
+type attachHub struct {
+	mu     sync.Mutex
+	buffer []models.AttachOutput
+	size   int
+	subs   map[*AttachSession]struct{}
+	// closed 在进程被删除后设置，之后不能再 attach
+	closed bool
+	// stdin 是当前运行实例的标准输入管道（Spec.Stdin），stdinOwner 是占用标准输入的会话
+	stdin      *os.File
+	stdinOwner *AttachSession
+}
+
+func newAttachHub() *attachHub {
+	return &attachHub{subs: make(map[*AttachSession]struct{})}
+}
+
+// attachWriter 把一路输出写入 attachHub
+type attachWriter struct {
+	hub    *attachHub
+	stream byte
+}
+
+func (w attachWriter) Write(p []byte) (int, error) {
+	w.hub.publish(models.AttachOutput{Stream: w.stream, Data: append([]byte(nil), p...)})
+	return len(p), nil
+}
+
+// publish 把输出加入缓冲区并发送给所有会话
+func (h *attachHub) publish(out models.AttachOutput) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buffer = append(h.buffer, out)
+	h.size += len(out.Data)
+	for h.size > attachBufferSize && len(h.buffer) > 1 {
+		h.size -= len(h.buffer[0].Data)
+		h.buffer[0] = models.AttachOutput{}
+		h.buffer = h.buffer[1:]
+	}
+	for s := range h.subs {
+		select {
+		case s.output <- out:
+		default:
+		}
+	}
+}
+
+// setStdin 设置当前运行实例的标准输入管道，进程退出时设置为 nil
+func (h *attachHub) setStdin(f *os.File) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stdin = f
+}
+
+// attach 创建会话，stdin 为 true 时会话占用标准输入
+func (h *attachHub) attach(stdin bool) (*AttachSession, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, fmt.Errorf("process is not managed")
+	}
+	if stdin && h.stdinOwner != nil {
+		return nil, ErrStdinBusy
+	}
+	s := &AttachSession{
+		Backlog: append([]models.AttachOutput(nil), h.buffer...),
+		hub:     h,
+		output:  make(chan models.AttachOutput, attachWatchBuffer),
+		stdin:   stdin,
+	}
+	h.subs[s] = struct{}{}
+	if stdin {
+		h.stdinOwner = s
+	}
+	return s, nil
+}
+
+// close 在进程被删除时结束所有会话
+func (h *attachHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.output)
+	}
+	h.stdinOwner = nil
+}
+
+// AttachSession 是一个 attach 会话：Backlog 是会话开始时缓冲的最近输出，Output 跟随之后的输出
+type AttachSession struct {
+	Backlog []models.AttachOutput
+
+	hub    *attachHub
+	output chan models.AttachOutput
+	stdin  bool
+	once   sync.Once
+}
+
+// Output 返回之后的输出，会话关闭或进程被删除时关闭
+func (s *AttachSession) Output() <-chan models.AttachOutput {
+	return s.output
+}
+
+// Write 写入进程的标准输入，只有占用了标准输入的会话可以写入
+func (s *AttachSession) Write(p []byte) (int, error) {
+	if !s.stdin {
+		return 0, fmt.Errorf("session is not attached to stdin")
+	}
+	h := s.hub
+	h.mu.Lock()
+	stdin, owner := h.stdin, h.stdinOwner == s
+	h.mu.Unlock()
+	if !owner {
+		return 0, fmt.Errorf("session is closed")
+	}
+	if stdin == nil {
+		return 0, fmt.Errorf("process is not running")
+	}
+	// 在锁外写入，子进程不读取时不阻塞其他会话；进程退出时管道关闭，写入返回错误
+	return stdin.Write(p)
+}
+
+// Close 结束会话并释放标准输入
+func (s *AttachSession) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[s]; ok {
+			delete(h.subs, s)
+			close(s.output)
+		}
+		if h.stdinOwner == s {
+			h.stdinOwner = nil
+		}
+	})
+}
+
+// AttachProcess 开始一个 attach 会话，回放进程最近的输出并跟随之后的输出，进程重启后继续跟随。
+// stdin 为 true 时会话独占进程的标准输入，进程需要设置 Spec.Stdin
+func (m *Manager) AttachProcess(namespace, name string, stdin bool) (*AttachSession, error) {
+	e, exists := m.getEntry(namespace, name)
+	if !exists {
+		return nil, fmt.Errorf("process %s/%s is not managed", namespace, name)
+	}
+	mp := e.snapshot()
+	if mp.Spec.Replicas != nil || isCronJob(&mp) {
+		return nil, fmt.Errorf("%w: attach is not supported for processes with replicas or a schedule", ErrInvalidProcess)
+	}
+	if stdin && !mp.Spec.Stdin {
+		return nil, fmt.Errorf("%w: process %s does not keep stdin open (spec.stdin)", ErrInvalidProcess, e.key)
+	}
+	return e.attach.attach(stdin)
+}
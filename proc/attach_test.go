@@ -0,0 +1,140 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// readAttachOutput 读取会话的输出直到包含 want
+func readAttachOutput(t *testing.T, s *AttachSession, stream byte, want string) {
+	t.Helper()
+	var got strings.Builder
+	timeout := time.After(5 * time.Second)
+	for !strings.Contains(got.String(), want) {
+		select {
+		case out, ok := <-s.Output():
+			if !ok {
+				t.Fatalf("session closed, got %q, want %q", got.String(), want)
+			}
+			if out.Stream == stream {
+				got.Write(out.Data)
+			}
+		case <-timeout:
+			t.Fatalf("got %q, want %q", got.String(), want)
+		}
+	}
+}
+
+func TestManagerAttach(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "repl")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `echo ready; echo warn >&2; while read line; do echo "got $line"; done`}}
+	p.Spec.Stdin = true
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "repl"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	writer, err := m.AttachProcess("test", "repl", true)
+	if err != nil {
+		t.Fatalf("AttachProcess: %v", err)
+	}
+	defer writer.Close()
+	// 只有一个会话可以占用标准输入，只读会话不受限制
+	if _, err := m.AttachProcess("test", "repl", true); !errors.Is(err, ErrStdinBusy) {
+		t.Fatalf("second stdin attach: %v, want ErrStdinBusy", err)
+	}
+	viewer, err := m.AttachProcess("test", "repl", false)
+	if err != nil {
+		t.Fatalf("AttachProcess: %v", err)
+	}
+	if _, err := viewer.Write([]byte("x\n")); err == nil {
+		t.Fatalf("a read-only session wrote to stdin")
+	}
+
+	if _, err := writer.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	readAttachOutput(t, viewer, models.AttachStreamStdout, "got hello\n")
+	readAttachOutput(t, writer, models.AttachStreamStdout, "got hello\n")
+
+	// 新会话先回放缓冲的输出
+	late, err := m.AttachProcess("test", "repl", false)
+	if err != nil {
+		t.Fatalf("AttachProcess: %v", err)
+	}
+	var stdout, stderr string
+	for _, out := range late.Backlog {
+		if out.Stream == models.AttachStreamStderr {
+			stderr += string(out.Data)
+		} else {
+			stdout += string(out.Data)
+		}
+	}
+	if !strings.HasPrefix(stdout, "ready\n") || !strings.Contains(stdout, "got hello\n") || stderr != "warn\n" {
+		t.Fatalf("backlog stdout=%q stderr=%q", stdout, stderr)
+	}
+
+	// 关闭会话后释放标准输入
+	writer.Close()
+	again, err := m.AttachProcess("test", "repl", true)
+	if err != nil {
+		t.Fatalf("stdin attach after close: %v", err)
+	}
+
+	// 进程删除后会话结束
+	if err := m.DeleteProcess("test", "repl"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+	for _, s := range []*AttachSession{viewer, late, again} {
+		select {
+		case <-drain(s.Output()):
+		case <-time.After(5 * time.Second):
+			t.Fatalf("session was not closed after the process was deleted")
+		}
+	}
+
+	// 未设置 spec.stdin 的进程只能只读 attach
+	plain := testProcess(dir, "plain")
+	if err := m.CreateProcess(plain); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if _, err := m.AttachProcess("test", "plain", true); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("stdin attach without spec.stdin: %v, want ErrInvalidProcess", err)
+	}
+}
+
+// drain 读取通道直到关闭
+func drain(ch <-chan models.AttachOutput) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultBulkWaitTimeout 是批量操作等待进程到达期望状态的默认超时时间
+	defaultBulkWaitTimeout = time.Minute
+	// bulkWaitPollInterval 是等待期间检查进程状态的间隔
+	bulkWaitPollInterval = 100 * time.Millisecond
+)
+
+// BulkOperation 对 namespace 下匹配 opts.LabelSelector 的进程执行批量操作，返回每个进程的结果。
+// start 和 restart 按依赖顺序执行，依赖失败时跳过依赖方；stop 和 delete 按依赖的逆序执行。
+// 最多 opts.Parallelism 个进程同时操作，存在依赖关系的进程仍然依次操作
+func (m *Manager) BulkOperation(namespace string, action models.BulkAction, opts models.BulkOptions) ([]models.ProcessOperationResult, error) {
+	processes, err := m.SelectProcesses(namespace, opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := sortByDependencies(processes)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.ProcessOperationResult
+	switch action {
+	case models.BulkStart:
+		results = m.runBulk(ordered, opts.Parallelism, false, m.startWithDependencies)
+	case models.BulkRestart:
+		results = m.runBulk(ordered, opts.Parallelism, false, func(mp models.ManagedProcess) error {
+			return m.RestartProcess(mp.Metadata.Namespace, mp.Metadata.Name)
+		})
+	case models.BulkStop:
+		results = m.runBulk(ordered, opts.Parallelism, true, func(mp models.ManagedProcess) error {
+			if e, exists := m.getEntry(mp.Metadata.Namespace, mp.Metadata.Name); exists && isActivePhase(e.snapshot().Status.Phase) {
+				return m.StopProcess(mp.Metadata.Namespace, mp.Metadata.Name)
+			}
+			return nil
+		})
+	case models.BulkDelete:
+		results = m.runBulk(ordered, opts.Parallelism, true, func(mp models.ManagedProcess) error {
+			return m.DeleteProcess(mp.Metadata.Namespace, mp.Metadata.Name)
+		})
+	default:
+		return nil, fmt.Errorf("unsupported bulk action %q", action)
+	}
+
+	if opts.Wait && action != models.BulkDelete {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = defaultBulkWaitTimeout
+		}
+		m.waitForBulk(action, results, time.Now().Add(timeout))
+	}
+	for i := range results {
+		if e, exists := m.getEntry(results[i].Namespace, results[i].Name); exists {
+			results[i].Phase = e.snapshot().Status.Phase
+		}
+	}
+	return results, nil
+}
+
+// startWithDependencies 等待依赖满足条件后启动进程，已运行的进程视为启动成功
+func (m *Manager) startWithDependencies(mp models.ManagedProcess) error {
+	e, exists := m.getEntry(mp.Metadata.Namespace, mp.Metadata.Name)
+	if !exists {
+		return fmt.Errorf("process %s/%s is not managed", mp.Metadata.Namespace, mp.Metadata.Name)
+	}
+	if e.isRunning() {
+		return nil
+	}
+	if err := m.waitForDependencies(mp); err != nil {
+		return err
+	}
+	return m.StartProcess(mp.Metadata.Namespace, mp.Metadata.Name)
+}
+
+// runBulk 对按依赖排序的进程执行 op，最多 parallelism 个同时进行，结果按 processes 的顺序（reverse 时为逆序）返回。
+// reverse 为 false 时进程在集合内的依赖完成后执行，依赖失败时跳过；为 true 时在集合内依赖它的进程完成后执行
+func (m *Manager) runBulk(processes []models.ManagedProcess, parallelism int, reverse bool, op func(mp models.ManagedProcess) error) []models.ProcessOperationResult {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	n := len(processes)
+	index := make(map[string]int, n)
+	for i, mp := range processes {
+		index[mp.Metadata.Name] = i
+	}
+	// before[i] 是需要在 i 之前完成的进程
+	before := make([][]int, n)
+	for i, mp := range processes {
+		for _, dep := range mp.Spec.DependsOn {
+			j, ok := index[dep.Name]
+			if !ok {
+				continue
+			}
+			if reverse {
+				before[j] = append(before[j], i)
+			} else {
+				before[i] = append(before[i], j)
+			}
+		}
+	}
+
+	results := make([]models.ProcessOperationResult, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, parallelism)
+	run := func(i int) {
+		defer close(done[i])
+		mp := processes[i]
+		results[i] = models.ProcessOperationResult{Namespace: mp.Metadata.Namespace, Name: mp.Metadata.Name}
+		for _, j := range before[i] {
+			<-done[j]
+			if !reverse && results[j].Error != "" {
+				results[i].Error = fmt.Sprintf("dependency %s failed", processes[j].Metadata.Name)
+				return
+			}
+		}
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		if err := op(mp); err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+	var wg sync.WaitGroup
+	for k := 0; k < n; k++ {
+		i := k
+		if reverse {
+			i = n - 1 - k
+		}
+		// parallelism 为 1 时严格按顺序依次操作
+		if parallelism == 1 {
+			run(i)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(i)
+		}()
+	}
+	wg.Wait()
+
+	if reverse {
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+	return results
+}
+
+// waitForBulk 等待操作成功的进程到达期望状态，超时或进入失败状态时记录到结果中
+func (m *Manager) waitForBulk(action models.BulkAction, results []models.ProcessOperationResult, deadline time.Time) {
+	for {
+		pending := 0
+		for i := range results {
+			r := &results[i]
+			if r.Error != "" {
+				continue
+			}
+			e, exists := m.getEntry(r.Namespace, r.Name)
+			if !exists {
+				r.Error = "process was deleted"
+				continue
+			}
+			mp := e.snapshot()
+			reached, err := bulkPhaseReached(action, &mp)
+			if err != nil {
+				r.Error = err.Error()
+				continue
+			}
+			if !reached {
+				if time.Now().After(deadline) {
+					r.Error = fmt.Sprintf("timed out waiting for the process to be %s (phase %s)", bulkTarget(action, &mp), phaseOrUnknown(mp.Status.Phase))
+					continue
+				}
+				pending++
+			}
+		}
+		if pending == 0 {
+			return
+		}
+		time.Sleep(bulkWaitPollInterval)
+	}
+}
+
+// bulkTarget 返回批量操作的期望状态
+func bulkTarget(action models.BulkAction, mp *models.ManagedProcess) string {
+	switch {
+	case action == models.BulkStop:
+		return "stopped"
+	case isJob(mp) && !isCronJob(mp):
+		return string(models.PhaseSucceeded)
+	}
+	return string(models.PhaseRunning)
+}
+
+// bulkPhaseReached 进程是否到达批量操作的期望状态，进入失败状态时返回错误
+func bulkPhaseReached(action models.BulkAction, mp *models.ManagedProcess) (bool, error) {
+	phase := mp.Status.Phase
+	if action == models.BulkStop {
+		return !isActivePhase(phase), nil
+	}
+	switch phase {
+	case models.PhaseFailed, models.PhaseCrashLoopBackOff:
+		return false, fmt.Errorf("process entered phase %s", phase)
+	}
+	if isJob(mp) && !isCronJob(mp) {
+		return phase == models.PhaseSucceeded, nil
+	}
+	return phase == models.PhaseRunning, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// resultNames 返回批量操作结果中的进程名，失败的进程带上错误
+func resultNames(results []models.ProcessOperationResult) string {
+	var names []string
+	for _, r := range results {
+		if r.Error != "" {
+			names = append(names, fmt.Sprintf("%s(%s)", r.Name, r.Error))
+		} else {
+			names = append(names, r.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func TestManagerBulkOperation(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	labeled := func(p models.ManagedProcess, labels map[string]string) models.ManagedProcess {
+		p.Metadata.Labels = labels
+		return p
+	}
+	for _, p := range []models.ManagedProcess{
+		labeled(withDeps(testProcess(dir, "kafka-0"), "zookeeper"), map[string]string{"app": "kafka", "env": "prod"}),
+		labeled(withDeps(testProcess(dir, "kafka-1"), "zookeeper"), map[string]string{"app": "kafka", "env": "stage"}),
+		labeled(testProcess(dir, "zookeeper"), map[string]string{"app": "zookeeper", "env": "prod"}),
+		labeled(testProcess(dir, "web"), map[string]string{"app": "web", "env": "dev"}),
+	} {
+		if err := m.CreateProcess(p); err != nil {
+			t.Fatalf("CreateProcess %s: %v", p.Metadata.Name, err)
+		}
+	}
+
+	selected, err := m.SelectProcesses("test", "env in (prod,stage)")
+	if err != nil || len(selected) != 3 {
+		t.Fatalf("SelectProcesses = %d processes, %v, want 3", len(selected), err)
+	}
+	if _, err := m.BulkOperation("test", models.BulkStart, models.BulkOptions{LabelSelector: "env in (prod"}); !errors.Is(err, ErrInvalidSelector) {
+		t.Fatalf("BulkOperation with an invalid selector = %v, want ErrInvalidSelector", err)
+	}
+
+	// 依赖先启动，无依赖关系的进程并行启动，等待全部 Running
+	results, err := m.BulkOperation("test", models.BulkStart, models.BulkOptions{LabelSelector: "env!=dev", Parallelism: 4, Wait: true})
+	if err != nil {
+		t.Fatalf("BulkOperation start: %v", err)
+	}
+	if got, want := resultNames(results), "zookeeper,kafka-0,kafka-1"; got != want {
+		t.Fatalf("start results = %s, want %s", got, want)
+	}
+	for _, r := range results {
+		if r.Phase != models.PhaseRunning {
+			t.Fatalf("%s phase = %s after --wait, want Running", r.Name, r.Phase)
+		}
+	}
+	if status, _ := m.GetProcessStatus("test", "web"); status.Status.Phase == models.PhaseRunning {
+		t.Fatalf("web was started but does not match the selector")
+	}
+
+	// 依赖方先于被依赖的进程停止
+	results, err = m.BulkOperation("test", models.BulkStop, models.BulkOptions{LabelSelector: "app in (kafka,zookeeper)", Parallelism: 2, Wait: true})
+	if err != nil {
+		t.Fatalf("BulkOperation stop: %v", err)
+	}
+	if got, want := resultNames(results), "kafka-1,kafka-0,zookeeper"; got != want {
+		t.Fatalf("stop results = %s, want %s", got, want)
+	}
+	for _, r := range results {
+		if r.Phase != models.PhaseStopped {
+			t.Fatalf("%s phase = %s after stop, want Stopped", r.Name, r.Phase)
+		}
+	}
+
+	// 依赖启动失败时跳过依赖方；--wait 报告进入失败状态的进程
+	bad := labeled(testProcess(dir, "zookeeper"), map[string]string{"app": "zookeeper", "env": "prod"})
+	bad.Spec.Exec = models.Exec{Command: "/nonexistent/zookeeper"}
+	if err := m.UpdateProcess(bad); err != nil {
+		t.Fatalf("UpdateProcess: %v", err)
+	}
+	results, _ = m.BulkOperation("test", models.BulkStart, models.BulkOptions{LabelSelector: "env=prod"})
+	if len(results) != 2 || results[0].Error == "" || results[1].Error != "dependency zookeeper failed" {
+		t.Fatalf("start results = %s, want zookeeper to fail and kafka-0 to be skipped", resultNames(results))
+	}
+
+	// 未报告 READY=1 的进程保持 Pending，等待超时
+	slow := labeled(testProcess(dir, "slow"), map[string]string{"app": "slow"})
+	slow.Spec.Notify = &models.NotifyConfig{}
+	if err := m.CreateProcess(slow); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	results, _ = m.BulkOperation("test", models.BulkStart, models.BulkOptions{LabelSelector: "app=slow", Wait: true, Timeout: 300 * time.Millisecond})
+	if len(results) != 1 || !strings.Contains(results[0].Error, "timed out") || results[0].Phase != models.PhasePending {
+		t.Fatalf("start results = %+v, want a wait timeout in phase Pending", results)
+	}
+
+	// 按选择器删除
+	results, err = m.BulkOperation("test", models.BulkDelete, models.BulkOptions{LabelSelector: "app in (kafka,zookeeper)"})
+	if err != nil {
+		t.Fatalf("BulkOperation delete: %v", err)
+	}
+	if got, want := resultNames(results), "kafka-1,kafka-0,zookeeper"; got != want {
+		t.Fatalf("delete results = %s, want %s", got, want)
+	}
+	remaining, _ := m.ListManagedProcesses("test")
+	if len(remaining) != 2 {
+		t.Fatalf("%d processes remain after delete, want 2", len(remaining))
+	}
+}
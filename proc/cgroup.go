@@ -0,0 +1,189 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// CgroupRoot 是 vigil 管理的 cgroup v2 层级，每个进程在其下的 <namespace>/<name> 拥有独立的子 cgroup
+var CgroupRoot = "/sys/fs/cgroup/vigil"
+
+// processCgroupPath 返回进程的 cgroup 路径，按命名空间分级以免不同命名空间的名称拼接后冲突
+func processCgroupPath(mp *models.ManagedProcess) string {
+	return filepath.Join(CgroupRoot, mp.Metadata.Namespace, mp.Metadata.Name)
+}
+
+// errCgroupUnsupported 当前平台不支持 cgroup v2
+var errCgroupUnsupported = errors.New("cgroup v2 is not supported on this platform")
+
+// cgroupLimits 是从 Spec.Resources 解析出的 cgroup 设置，未设置的字段为 0
+type cgroupLimits struct {
+	// CPU 限制与请求（毫核）
+	CPULimitMilli   int64
+	CPURequestMilli int64
+	// 内存限制（字节）
+	MemoryMax  int64
+	MemoryHigh int64
+	MemoryLow  int64
+	// 最大进程数
+	PidsMax int64
+	// IO 限制（字节/秒、次/秒）
+	IOReadBPS   int64
+	IOWriteBPS  int64
+	IOReadIOPS  int64
+	IOWriteIOPS int64
+}
+
+// cpuMaxPeriod 是 cpu.max 的调度周期（微秒）
+const cpuMaxPeriod = 100000
+
+// cgroupFile 是一个待写入的 cgroup 控制文件及其内容
+type cgroupFile struct {
+	name  string
+	value string
+}
+
+// controlFiles 返回 cpu/memory/pids 控制文件的内容，未设置的项不写入（io.max 依赖块设备，单独处理）
+func (l *cgroupLimits) controlFiles() []cgroupFile {
+	var files []cgroupFile
+	if l.CPULimitMilli > 0 {
+		quota := l.CPULimitMilli * cpuMaxPeriod / 1000
+		if quota < 1000 {
+			quota = 1000
+		}
+		files = append(files, cgroupFile{"cpu.max", fmt.Sprintf("%d %d", quota, cpuMaxPeriod)})
+	}
+	if l.CPURequestMilli > 0 {
+		// 与 Kubernetes 一致：毫核 -> cpu.shares -> cpu.weight
+		shares := l.CPURequestMilli * 1024 / 1000
+		if shares < 2 {
+			shares = 2
+		}
+		weight := 1 + (shares-2)*9999/262142
+		files = append(files, cgroupFile{"cpu.weight", strconv.FormatInt(weight, 10)})
+	}
+	for _, f := range []struct {
+		name  string
+		value int64
+	}{
+		{"memory.max", l.MemoryMax},
+		{"memory.high", l.MemoryHigh},
+		{"memory.low", l.MemoryLow},
+		{"pids.max", l.PidsMax},
+	} {
+		if f.value > 0 {
+			files = append(files, cgroupFile{f.name, strconv.FormatInt(f.value, 10)})
+		}
+	}
+	return files
+}
+
+// hasIOLimits 是否配置了任一 IO 限制
+func (l *cgroupLimits) hasIOLimits() bool {
+	return l.IOReadBPS > 0 || l.IOWriteBPS > 0 || l.IOReadIOPS > 0 || l.IOWriteIOPS > 0
+}
+
+// parseCgroupLimits 解析 Spec.Resources，未配置任何资源时返回 nil
+func parseCgroupLimits(res *models.ResourceRequirements) (*cgroupLimits, error) {
+	if res == nil || (len(res.Limits) == 0 && len(res.Requests) == 0) {
+		return nil, nil
+	}
+
+	limits := &cgroupLimits{}
+	for _, name := range sortedResourceNames(res.Limits) {
+		value := res.Limits[name]
+		var err error
+		switch name {
+		case models.ResourceCPU:
+			limits.CPULimitMilli, err = ParseCPUQuantity(value)
+		case models.ResourceMemory:
+			limits.MemoryMax, err = ParseQuantity(value)
+		case models.ResourceMemoryHigh:
+			limits.MemoryHigh, err = ParseQuantity(value)
+		case models.ResourcePids:
+			limits.PidsMax, err = ParseQuantity(value)
+		case models.ResourceIOReadBPS:
+			limits.IOReadBPS, err = ParseQuantity(value)
+		case models.ResourceIOWriteBPS:
+			limits.IOWriteBPS, err = ParseQuantity(value)
+		case models.ResourceIOReadIOPS:
+			limits.IOReadIOPS, err = ParseQuantity(value)
+		case models.ResourceIOWriteIOPS:
+			limits.IOWriteIOPS, err = ParseQuantity(value)
+		default:
+			err = fmt.Errorf("unsupported resource limit")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("limits.%s: %v", name, err)
+		}
+	}
+
+	for _, name := range sortedResourceNames(res.Requests) {
+		value := res.Requests[name]
+		var err error
+		switch name {
+		case models.ResourceCPU:
+			limits.CPURequestMilli, err = ParseCPUQuantity(value)
+		case models.ResourceMemory:
+			limits.MemoryLow, err = ParseQuantity(value)
+		default:
+			err = fmt.Errorf("unsupported resource request")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("requests.%s: %v", name, err)
+		}
+	}
+
+	return limits, nil
+}
+
+// sortedResourceNames 返回排序后的资源名，保证错误信息稳定
+func sortedResourceNames(list models.ResourceList) []string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setupCgroup 按 Spec.Resources 为进程创建 cgroup 并让 cmd 在其中启动。
+// 未配置资源时返回 nil, nil；返回错误表示 cgroup 不可用，调用方应记录警告条件并继续以无限制方式启动。
+func setupCgroup(mp *models.ManagedProcess, cmd *exec.Cmd) (*processCgroup, error) {
+	limits, err := parseCgroupLimits(mp.Spec.Resources)
+	if err != nil || limits == nil {
+		return nil, err
+	}
+
+	cg, err := newProcessCgroup(mp, limits)
+	if err != nil {
+		return nil, err
+	}
+	if err := cg.attach(cmd); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build linux
+
+package proc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"golang.org/x/sys/unix"
+)
+
+// cgroupFSRoot 是 cgroup v2 统一层级的挂载点
+const cgroupFSRoot = "/sys/fs/cgroup"
+
+// cgroupControllers 是需要在 vigil 层级启用的控制器
+var cgroupControllers = []string{"cpu", "memory", "pids", "io"}
+
+// processCgroup 表示一个进程独占的 cgroup
+type processCgroup struct {
+	path string
+	// fd 在 cmd.Start 期间保持打开，用于 clone3 直接把子进程放入 cgroup
+	fd *os.File
+
+	// 上次 CPU 采样，用于计算 CPU 使用率
+	mu          sync.Mutex
+	lastCPUUsec uint64
+	lastSample  time.Time
+}
+
+// newProcessCgroup 在 CgroupRoot 下创建进程的 cgroup 并写入资源限制
+func newProcessCgroup(mp *models.ManagedProcess, limits *cgroupLimits) (*processCgroup, error) {
+	if _, err := os.Stat(filepath.Join(cgroupFSRoot, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 is not mounted at %s", cgroupFSRoot)
+	}
+
+	cg := &processCgroup{path: processCgroupPath(mp)}
+	// 命名空间目录只作为中间层级，不放进程，删除进程时保留
+	nsDir := filepath.Dir(cg.path)
+	if err := os.MkdirAll(nsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %v", nsDir, err)
+	}
+	// 逐级启用控制器，单个控制器不可用时由写入限制时报告
+	for _, dir := range []string{filepath.Dir(CgroupRoot), CgroupRoot, nsDir} {
+		for _, c := range cgroupControllers {
+			_ = os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+"+c), 0644)
+		}
+	}
+
+	// 清理上次运行残留的空 cgroup
+	if err := os.Remove(cg.path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale cgroup %s: %v", cg.path, err)
+	}
+	if err := os.Mkdir(cg.path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %v", err)
+	}
+
+	if err := cg.applyLimits(mp, limits); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// applyLimits 写入 cpu/memory/pids/io 控制文件
+func (cg *processCgroup) applyLimits(mp *models.ManagedProcess, limits *cgroupLimits) error {
+	for _, f := range limits.controlFiles() {
+		if err := cg.write(f.name, f.value); err != nil {
+			return err
+		}
+	}
+	if limits.hasIOLimits() {
+		dev, err := blockDeviceFor(processWorkingDir(mp))
+		if err != nil {
+			return fmt.Errorf("failed to resolve block device for io.max: %v", err)
+		}
+		var parts []string
+		for _, kv := range []struct {
+			key   string
+			value int64
+		}{
+			{"rbps", limits.IOReadBPS},
+			{"wbps", limits.IOWriteBPS},
+			{"riops", limits.IOReadIOPS},
+			{"wiops", limits.IOWriteIOPS},
+		} {
+			if kv.value > 0 {
+				parts = append(parts, fmt.Sprintf("%s=%d", kv.key, kv.value))
+			}
+		}
+		if err := cg.write("io.max", dev+" "+strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write 写入 cgroup 控制文件
+func (cg *processCgroup) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(cg.path, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to set %s=%q: %v", file, value, err)
+	}
+	return nil
+}
+
+// attach 让 cmd 启动时直接进入该 cgroup（clone3 CLONE_INTO_CGROUP）
+func (cg *processCgroup) attach(cmd *exec.Cmd) error {
+	fd, err := os.Open(cg.path)
+	if err != nil {
+		return fmt.Errorf("failed to open cgroup: %v", err)
+	}
+	cg.fd = fd
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(fd.Fd())
+	return nil
+}
+
+// closeFD 在进程启动后关闭 cgroup 目录句柄
+func (cg *processCgroup) closeFD() {
+	if cg.fd != nil {
+		cg.fd.Close()
+		cg.fd = nil
+	}
+}
+
+// remove 终止 cgroup 中残留的进程并删除 cgroup
+func (cg *processCgroup) remove() {
+	cg.closeFD()
+	if err := os.Remove(cg.path); err == nil || os.IsNotExist(err) {
+		return
+	}
+	// 仍有残留进程（如被遗留的子进程），使用 cgroup.kill 清理后重试
+	_ = os.WriteFile(filepath.Join(cg.path, "cgroup.kill"), []byte("1"), 0644)
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(cg.path); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// signal 向 cgroup 内的所有进程发送信号
+func (cg *processCgroup) signal(sig syscall.Signal) {
+	data, err := os.ReadFile(filepath.Join(cg.path, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(field); err == nil && pid > 0 {
+			_ = syscall.Kill(pid, sig)
+		}
+	}
+}
+
+// populated 返回 cgroup 内是否还有进程
+func (cg *processCgroup) populated() bool {
+	events, err := readKeyValueFile(filepath.Join(cg.path, "cgroup.events"))
+	if err != nil {
+		return false
+	}
+	return events["populated"] > 0
+}
+
+// contains 返回 pid 是否在该 cgroup 内，cg 为 nil 时返回 false
+func (cg *processCgroup) contains(pid int) bool {
+	if cg == nil {
+		return false
+	}
+	rel, err := filepath.Rel(cgroupFSRoot, cg.path)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "0::/"+rel {
+			return true
+		}
+	}
+	return false
+}
+
+// oomKilled 返回 cgroup 内是否发生过 OOM kill
+func (cg *processCgroup) oomKilled() bool {
+	events, err := readKeyValueFile(filepath.Join(cg.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	return events["oom_kill"] > 0
+}
+
+// fillStats 用 cgroup 统计覆盖 CPU、内存和 IO 使用量（包含子进程）
+func (cg *processCgroup) fillStats(stats *models.ResourceStats) {
+	if v, err := readUintFile(filepath.Join(cg.path, "memory.current")); err == nil {
+		stats.MemoryUsage = v
+	}
+
+	if cpu, err := readKeyValueFile(filepath.Join(cg.path, "cpu.stat")); err == nil {
+		usage := cpu["usage_usec"]
+		stats.CPUUserTime = float64(cpu["user_usec"]) / 1e6
+		stats.CPUSystemTime = float64(cpu["system_usec"]) / 1e6
+		stats.CPUTotalTime = float64(usage) / 1e6
+
+		cg.mu.Lock()
+		now := time.Now()
+		if !cg.lastSample.IsZero() && usage >= cg.lastCPUUsec {
+			elapsed := now.Sub(cg.lastSample).Microseconds()
+			if elapsed > 0 {
+				stats.CPUUsage = float64(usage-cg.lastCPUUsec) / float64(elapsed) * 100
+			}
+		}
+		cg.lastCPUUsec = usage
+		cg.lastSample = now
+		cg.mu.Unlock()
+	}
+
+	if data, err := os.ReadFile(filepath.Join(cg.path, "io.stat")); err == nil {
+		var rbytes, wbytes, rios, wios uint64
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, field := range strings.Fields(line) {
+				k, v, ok := strings.Cut(field, "=")
+				if !ok {
+					continue
+				}
+				n, _ := strconv.ParseUint(v, 10, 64)
+				switch k {
+				case "rbytes":
+					rbytes += n
+				case "wbytes":
+					wbytes += n
+				case "rios":
+					rios += n
+				case "wios":
+					wios += n
+				}
+			}
+		}
+		stats.IOReadBytes = rbytes
+		stats.IOWriteBytes = wbytes
+		stats.IOReadCount = rios
+		stats.IOWriteCount = wios
+		stats.DiskIO = rbytes + wbytes
+	}
+}
+
+// blockDeviceFor 返回路径所在磁盘的 "major:minor"，分区会解析为所属整盘
+func blockDeviceFor(path string) (string, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return "", err
+	}
+	dev := fmt.Sprintf("%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev))
+
+	sysPath := filepath.Join("/sys/dev/block", dev)
+	if _, err := os.Stat(sysPath); err != nil {
+		return "", fmt.Errorf("%s is not on a block device", path)
+	}
+	if _, err := os.Stat(filepath.Join(sysPath, "partition")); err == nil {
+		parent, err := os.ReadFile(filepath.Join(sysPath, "..", "dev"))
+		if err != nil {
+			return "", err
+		}
+		dev = strings.TrimSpace(string(parent))
+	}
+	return dev, nil
+}
+
+// readUintFile 读取只包含一个整数的 cgroup 文件
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValueFile 读取 "key value" 格式的 cgroup 文件（如 cpu.stat、memory.events）
+func readKeyValueFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			result[fields[0]] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errors.New("empty file")
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !linux
+
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// processCgroup 在非 Linux 平台上不可用，仅用于保持调用方无需构建标签
+type processCgroup struct {
+	path string
+}
+
+func newProcessCgroup(mp *models.ManagedProcess, limits *cgroupLimits) (*processCgroup, error) {
+	return nil, errCgroupUnsupported
+}
+
+func (cg *processCgroup) attach(cmd *exec.Cmd) error { return errCgroupUnsupported }
+
+func (cg *processCgroup) closeFD() {}
+
+func (cg *processCgroup) remove() {}
+
+func (cg *processCgroup) oomKilled() bool { return false }
+
+func (cg *processCgroup) signal(sig syscall.Signal) {}
+
+func (cg *processCgroup) populated() bool { return false }
+
+func (cg *processCgroup) fillStats(stats *models.ResourceStats) {}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestParseCgroupLimits(t *testing.T) {
+	for _, res := range []*models.ResourceRequirements{nil, {}} {
+		limits, err := parseCgroupLimits(res)
+		if err != nil || limits != nil {
+			t.Errorf("parseCgroupLimits(%+v) = %+v, %v, want nil, nil", res, limits, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		res  models.ResourceRequirements
+		want []cgroupFile
+	}{
+		{
+			name: "cpu limit",
+			res:  models.ResourceRequirements{Limits: models.ResourceList{"cpu": "500m"}},
+			want: []cgroupFile{{"cpu.max", "50000 100000"}},
+		},
+		{
+			name: "fractional cpu limit",
+			res:  models.ResourceRequirements{Limits: models.ResourceList{"cpu": "1.5"}},
+			want: []cgroupFile{{"cpu.max", "150000 100000"}},
+		},
+		{
+			name: "minimum cpu quota",
+			res:  models.ResourceRequirements{Limits: models.ResourceList{"cpu": "1m"}},
+			want: []cgroupFile{{"cpu.max", "1000 100000"}},
+		},
+		{
+			name: "cpu request",
+			res:  models.ResourceRequirements{Requests: models.ResourceList{"cpu": "1"}},
+			want: []cgroupFile{{"cpu.weight", "39"}},
+		},
+		{
+			name: "memory and pids",
+			res: models.ResourceRequirements{
+				Limits:   models.ResourceList{"memory": "512Mi", "memory_high": "400M", "pids": "100"},
+				Requests: models.ResourceList{"memory": "1Gi"},
+			},
+			want: []cgroupFile{
+				{"memory.max", "536870912"},
+				{"memory.high", "400000000"},
+				{"memory.low", "1073741824"},
+				{"pids.max", "100"},
+			},
+		},
+		{
+			name: "io only",
+			res:  models.ResourceRequirements{Limits: models.ResourceList{"io_read_bps": "10Mi"}},
+		},
+	}
+	for _, tt := range tests {
+		limits, err := parseCgroupLimits(&tt.res)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := limits.controlFiles(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: control files = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	limits, err := parseCgroupLimits(&models.ResourceRequirements{Limits: models.ResourceList{
+		"io_read_bps": "10Mi", "io_write_bps": "1M", "io_read_iops": "100", "io_write_iops": "50",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limits.hasIOLimits() || limits.IOReadBPS != 10<<20 || limits.IOWriteBPS != 1000000 ||
+		limits.IOReadIOPS != 100 || limits.IOWriteIOPS != 50 {
+		t.Errorf("io limits = %+v", limits)
+	}
+
+	errTests := []struct {
+		res  models.ResourceRequirements
+		want string
+	}{
+		{models.ResourceRequirements{Limits: models.ResourceList{"gpu": "1"}}, "limits.gpu: unsupported resource limit"},
+		{models.ResourceRequirements{Requests: models.ResourceList{"pids": "10"}}, "requests.pids: unsupported resource request"},
+		{models.ResourceRequirements{Limits: models.ResourceList{"memory": "-1Gi"}}, "limits.memory: invalid quantity"},
+		{models.ResourceRequirements{Limits: models.ResourceList{"cpu": "fast"}}, "limits.cpu: invalid cpu quantity"},
+		{models.ResourceRequirements{Requests: models.ResourceList{"cpu": "-1"}}, "requests.cpu: invalid cpu quantity"},
+	}
+	for _, tt := range errTests {
+		if _, err := parseCgroupLimits(&tt.res); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseCgroupLimits(%+v) error = %v, want %q", tt.res, err, tt.want)
+		}
+	}
+}
+
+func TestProcessCgroupPath(t *testing.T) {
+	path := func(ns, name string) string {
+		p := models.ManagedProcess{Metadata: models.Metadata{Namespace: ns, Name: name}}
+		return processCgroupPath(&p)
+	}
+	if got, want := path("prod", "web"), filepath.Join(CgroupRoot, "prod", "web"); got != want {
+		t.Errorf("cgroup path = %q, want %q", got, want)
+	}
+	if a, b := path("a_b", "c"), path("a", "b_c"); a == b {
+		t.Errorf("cgroup paths of a_b/c and a/b_c collide: %q", a)
+	}
+
+	// 会逃出 CgroupRoot 的名称在校验时被拒绝
+	for _, tt := range []struct{ ns, name string }{
+		{"default", ".."},
+		{"default", "../web"},
+		{"default", "a/b"},
+		{"..", "web"},
+		{"a/b", "web"},
+		{".", "web"},
+	} {
+		p := models.ManagedProcess{
+			Metadata: models.Metadata{Namespace: tt.ns, Name: tt.name},
+			Spec:     models.Spec{Exec: models.Exec{Command: "true"}},
+		}
+		if err := ValidateProcess(&p); !errors.Is(err, ErrInvalidProcess) || !strings.Contains(err.Error(), "path separators") {
+			t.Errorf("ValidateProcess(%s/%s) = %v, want path error", tt.ns, tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultCrashOutputKB 是崩溃报告中默认保留的每路输出大小（KB）
+	defaultCrashOutputKB = 64
+	// defaultCrashMaxCoreMB 是默认收集的 core dump 大小上限（MB）
+	defaultCrashMaxCoreMB = 512
+	// defaultCrashHistoryLimit 是每个进程默认保留的崩溃报告数
+	defaultCrashHistoryLimit = 10
+	// crashReportFile 是报告元数据在 tar.gz 中的文件名
+	crashReportFile = "report.json"
+	// crashIDLayout 是报告 ID 中的时间格式
+	crashIDLayout = "20060102-150405.000"
+)
+
+// ErrCrashReportNotFound 是崩溃报告或报告中的文件不存在时的错误
+var ErrCrashReportNotFound = errors.New("crash report not found")
+
+// crashProcFiles 是进程运行期间保存的 /proc/<pid> 文件，进程退出后就读不到了
+var crashProcFiles = []string{"status", "limits", "maps", "environ"}
+
+// procSnapshot 是运行中进程最近一次保存的 /proc 信息和资源使用
+type procSnapshot struct {
+	pid   int
+	files map[string][]byte
+	stats *models.ResourceStats
+}
+
+// crashFile 是写入崩溃报告的一个文件，内容在内存中（data）或来自磁盘上的文件（path）
+type crashFile struct {
+	name string
+	data []byte
+	path string
+	size int64
+}
+
+// SetCrashDir 设置崩溃报告的目录（process.crash_dir），为空时不收集崩溃报告
+func (m *Manager) SetCrashDir(dir string) {
+	m.crashDir = dir
+}
+
+// validateCrashPolicy 校验 Spec.OnCrash
+func validateCrashPolicy(mp *models.ManagedProcess) error {
+	p := mp.Spec.OnCrash
+	if p == nil {
+		return nil
+	}
+	if p.OutputKB < 0 || p.MaxCoreMB < 0 || p.HistoryLimit < 0 {
+		return fmt.Errorf("on_crash: output_kb, max_core_mb and history_limit must not be negative")
+	}
+	if p.CoreDir != "" && !filepath.IsAbs(p.CoreDir) {
+		return fmt.Errorf("on_crash: core_dir must be an absolute path")
+	}
+	return nil
+}
+
+// crashPolicy 返回填充了默认值的 Spec.OnCrash
+func crashPolicy(spec *models.Spec) models.CrashPolicy {
+	p := *spec.OnCrash
+	if p.OutputKB == 0 {
+		p.OutputKB = defaultCrashOutputKB
+	}
+	if p.MaxCoreMB == 0 {
+		p.MaxCoreMB = defaultCrashMaxCoreMB
+	}
+	if p.HistoryLimit == 0 {
+		p.HistoryLimit = defaultCrashHistoryLimit
+	}
+	return p
+}
+
+// outputTailLimit 返回每路输出在内存中保留的大小，崩溃报告需要更多输出时相应增大
+func outputTailLimit(spec *models.Spec) int {
+	if spec.OnCrash != nil {
+		if n := crashPolicy(spec).OutputKB * 1024; n > outputTailSize {
+			return n
+		}
+	}
+	return outputTailSize
+}
+
+// saveProcSnapshot 保存启用了 OnCrash 的运行中进程的 /proc 信息，stats 为空时沿用上一次的资源使用。
+// 在进程启动后和每次采样后调用；重关联的进程不收集崩溃报告
+func (e *processEntry) saveProcSnapshot(pid int, stats *models.ResourceStats) {
+	e.mu.RLock()
+	enabled := e.process.Spec.OnCrash != nil && !e.adopted && e.process.Status.PID == pid
+	isolated := e.process.Spec.Isolation != nil
+	e.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	snap := &procSnapshot{pid: pid, files: make(map[string][]byte), stats: stats}
+	dir := filepath.Join("/proc", strconv.Itoa(samplePID(isolated, pid)))
+	for _, name := range crashProcFiles {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			snap.files[name] = data
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.process.Status.PID != pid {
+		return
+	}
+	if snap.stats == nil && e.crashSnapshot != nil && e.crashSnapshot.pid == pid {
+		snap.stats = e.crashSnapshot.stats
+	}
+	e.crashSnapshot = snap
+}
+
+// shouldReportCrash 本次退出是否需要生成崩溃报告：启用了 OnCrash，进程不是被 vigil 停止的，
+// 并且以非 0 退出码退出或被信号杀死
+func (m *Manager) shouldReportCrash(mp *models.ManagedProcess, run *processRun, info *models.TerminationInfo, stop *stopResult) bool {
+	return m.crashDir != "" && mp.Spec.OnCrash != nil && run != nil && stop == nil &&
+		(info.ExitCode != 0 || info.Signal != 0)
+}
+
+// reportCrash 在后台收集并保存崩溃报告（仅在 reconcile 协程中调用）。
+// 每次运行的输出缓冲是独立的，进程随后被重启不影响报告的内容
+func (m *Manager) reportCrash(e *processEntry, mp *models.ManagedProcess, run *processRun, info *models.TerminationInfo, pid int, snap *procSnapshot) {
+	report := models.CrashReport{
+		Namespace: mp.Metadata.Namespace,
+		Name:      e.jobName(),
+		Instance:  e.jobInstance(),
+		PID:       pid,
+		ExitCode:  info.ExitCode,
+		Signal:    info.Signal,
+		Reason:    info.Reason,
+		StartTime: e.runStarted,
+		Time:      info.FinishedAt,
+	}
+	report.ID = report.Time.UTC().Format(crashIDLayout)
+	if report.Instance != "" {
+		report.ID += "-" + report.Instance
+	}
+	policy := crashPolicy(&mp.Spec)
+	secrets := secretEnvNames(&mp.Spec)
+
+	go func() {
+		if err := m.writeCrashReport(&report, policy, run.outputs, snap, secrets); err != nil {
+			log.Printf("Warning: failed to save crash report of process %s: %v", e.key, err)
+			return
+		}
+		m.recordEvent(models.ProcessEvent{
+			Namespace: mp.Metadata.Namespace,
+			Name:      mp.Metadata.Name,
+			Type:      models.EventCrashReported,
+			PID:       pid,
+			Reason:    report.ID,
+			Message:   fmt.Sprintf("crash report %s saved (%d files, %d bytes)", report.ID, len(report.Files), report.Size),
+		})
+		m.pruneCrashReports(report.Namespace, report.Name, policy.HistoryLimit)
+	}()
+}
+
+// writeCrashReport 把输出、资源使用、/proc 信息和 core dump 打包为 <crash_dir>/<namespace>/<name>/<id>.tar.gz，
+// 并在旁边写入报告元数据 <id>.json
+func (m *Manager) writeCrashReport(report *models.CrashReport, policy models.CrashPolicy, outputs []*processOutput, snap *procSnapshot, secrets map[string]bool) error {
+	var files []crashFile
+	for i, o := range outputs {
+		if !o.wait(jobOutputWait) {
+			log.Printf("Warning: output of process %s/%s is still open, recording partial output", report.Namespace, report.Name)
+		}
+		name := "stdout.log"
+		if i > 0 {
+			name = "stderr.log"
+		}
+		files = append(files, crashFile{name: name, data: []byte(o.tail.last(policy.OutputKB * 1024))})
+	}
+	if snap != nil {
+		if snap.stats != nil {
+			data, err := json.MarshalIndent(snap.stats, "", "  ")
+			if err != nil {
+				return err
+			}
+			files = append(files, crashFile{name: "resource_stats.json", data: data})
+		}
+		for _, name := range crashProcFiles {
+			data, ok := snap.files[name]
+			if !ok {
+				continue
+			}
+			if name == "environ" {
+				data = formatEnviron(data, secrets)
+			}
+			files = append(files, crashFile{name: "proc/" + name, data: data})
+		}
+	}
+	if policy.CoreDir != "" {
+		cores, skipped := findCoreDumps(policy.CoreDir, report.PID, report.StartTime, int64(policy.MaxCoreMB)<<20)
+		files = append(files, cores...)
+		report.SkippedCores = skipped
+	}
+	for i := range files {
+		if files[i].path == "" {
+			files[i].size = int64(len(files[i].data))
+		}
+		report.Files = append(report.Files, models.CrashFile{Name: files[i].name, Size: files[i].size})
+	}
+
+	dir := m.crashReportDir(report.Namespace, report.Name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, report.ID+".tar.gz")
+	if err := writeCrashArchive(path, report, files); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	report.Size = info.Size()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, report.ID+".json"), data, 0600)
+}
+
+// writeCrashArchive 写入 tar.gz，report.json 在最前面
+func writeCrashArchive(path string, report *models.CrashReport, files []crashFile) error {
+	meta, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		files = append([]crashFile{{name: crashReportFile, data: meta, size: int64(len(meta))}}, files...)
+		for _, file := range files {
+			if err := addCrashFile(tw, file, report.Time); err != nil {
+				return fmt.Errorf("%s: %v", file.name, err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	}()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// addCrashFile 向 tar 中写入一个文件
+func addCrashFile(tw *tar.Writer, file crashFile, modTime time.Time) error {
+	var r io.Reader = bytes.NewReader(file.data)
+	if file.path != "" {
+		f, err := os.Open(file.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = io.LimitReader(f, file.size)
+	}
+	hdr := &tar.Header{Name: file.name, Mode: 0600, Size: file.size, ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// writeFileAtomic 先写临时文件再改名，读取方不会看到写了一半的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// secretEnvNames 返回 Spec.Env 中引用了密钥的变量名，崩溃报告中不保存它们的值
+func secretEnvNames(spec *models.Spec) map[string]bool {
+	names := make(map[string]bool)
+	for _, env := range spec.Env {
+		if secretRefPattern.MatchString(env.Value) {
+			names[env.Name] = true
+		}
+	}
+	return names
+}
+
+// formatEnviron 把 /proc/<pid>/environ（以 NUL 分隔）转换为每行一个 NAME=VALUE，引用了密钥的变量值被隐藏
+func formatEnviron(data []byte, secrets map[string]bool) []byte {
+	var b bytes.Buffer
+	for _, kv := range strings.Split(string(data), "\x00") {
+		if kv == "" {
+			continue
+		}
+		if name, _, ok := strings.Cut(kv, "="); ok && secrets[name] {
+			kv = name + "=******"
+		}
+		b.WriteString(kv)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// findCoreDumps 查找 dir 中本次运行期间生成、文件名中包含 pid 的 core dump（对应 core_pattern 的 %p），
+// 超过 maxSize 的文件只返回文件名
+func findCoreDumps(dir string, pid int, since time.Time, maxSize int64) ([]crashFile, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Warning: failed to read core dump directory %s: %v", dir, err)
+		return nil, nil
+	}
+	// 文件时间来自内核的粗粒度时钟，可能略早于 since
+	since = since.Truncate(time.Second)
+	var cores []crashFile
+	var skipped []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !nameHasNumber(entry.Name(), pid) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		if info.Size() > maxSize {
+			skipped = append(skipped, entry.Name())
+			continue
+		}
+		cores = append(cores, crashFile{name: "core/" + entry.Name(), path: filepath.Join(dir, entry.Name()), size: info.Size()})
+	}
+	return cores, skipped
+}
+
+// nameHasNumber 文件名中是否有等于 n 的完整数字，如 core.app.1234 中的 1234
+func nameHasNumber(name string, n int) bool {
+	want := strconv.Itoa(n)
+	for _, field := range strings.FieldsFunc(name, func(r rune) bool { return r < '0' || r > '9' }) {
+		if field == want {
+			return true
+		}
+	}
+	return false
+}
+
+// crashReportDir 返回进程的崩溃报告目录
+func (m *Manager) crashReportDir(namespace, name string) string {
+	return filepath.Join(m.crashDir, namespace, name)
+}
+
+// pruneCrashReports 删除超过 limit 的最旧的崩溃报告
+func (m *Manager) pruneCrashReports(namespace, name string, limit int) {
+	reports, err := m.ListCrashReports(namespace, name)
+	if err != nil || len(reports) <= limit {
+		return
+	}
+	dir := m.crashReportDir(namespace, name)
+	for _, report := range reports[:len(reports)-limit] {
+		os.Remove(filepath.Join(dir, report.ID+".tar.gz"))
+		os.Remove(filepath.Join(dir, report.ID+".json"))
+	}
+}
+
+// ListCrashReports 按时间顺序返回进程（包括 Replicas 进程和定时 Job 的实例）的崩溃报告
+func (m *Manager) ListCrashReports(namespace, name string) ([]models.CrashReport, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	if m.crashDir == "" || !validCrashPathElem(namespace) || !validCrashPathElem(name) {
+		return nil, nil
+	}
+	dir := m.crashReportDir(namespace, name)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var reports []models.CrashReport
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		report, err := readCrashReport(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Time.Before(reports[j].Time) })
+	return reports, nil
+}
+
+// readCrashReport 读取报告元数据
+func readCrashReport(path string) (models.CrashReport, error) {
+	var report models.CrashReport
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("invalid crash report %s: %v", path, err)
+	}
+	return report, nil
+}
+
+// validCrashPathElem 名称能否安全地作为路径的一部分
+func validCrashPathElem(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// GetCrashReport 返回一个崩溃报告的元数据
+func (m *Manager) GetCrashReport(namespace, name, id string) (models.CrashReport, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	if m.crashDir == "" || !validCrashPathElem(namespace) || !validCrashPathElem(name) || !validCrashPathElem(id) {
+		return models.CrashReport{}, fmt.Errorf("%w: %s", ErrCrashReportNotFound, id)
+	}
+	report, err := readCrashReport(filepath.Join(m.crashReportDir(namespace, name), id+".json"))
+	if os.IsNotExist(err) {
+		return report, fmt.Errorf("%w: %s", ErrCrashReportNotFound, id)
+	}
+	return report, err
+}
+
+// OpenCrashReport 打开崩溃报告的 tar.gz，调用方负责关闭
+func (m *Manager) OpenCrashReport(namespace, name, id string) (models.CrashReport, *os.File, error) {
+	report, err := m.GetCrashReport(namespace, name, id)
+	if err != nil {
+		return report, nil, err
+	}
+	f, err := os.Open(filepath.Join(m.crashReportDir(report.Namespace, report.Name), report.ID+".tar.gz"))
+	if os.IsNotExist(err) {
+		return report, nil, fmt.Errorf("%w: %s", ErrCrashReportNotFound, id)
+	}
+	return report, f, err
+}
+
+// ReadCrashFile 把崩溃报告中的文件 file（如 stderr.log、proc/maps）写入 w
+func (m *Manager) ReadCrashFile(namespace, name, id, file string, w io.Writer) error {
+	_, f, err := m.OpenCrashReport(namespace, name, id)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%w: %s has no file %s", ErrCrashReportNotFound, id, file)
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Name == file {
+			_, err = io.Copy(w, tr)
+			return err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build linux
+
+package proc
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// enableCoreDump 把进程的 RLIMIT_CORE 软限制提高到硬限制，使崩溃时能够生成 core dump。
+// 在进程启动后设置，进程在此之前崩溃不会生成 core dump
+func enableCoreDump(pid int) error {
+	var limit unix.Rlimit
+	if err := unix.Prlimit(pid, unix.RLIMIT_CORE, nil, &limit); err != nil {
+		return err
+	}
+	if limit.Cur == limit.Max {
+		return nil
+	}
+	limit.Cur = limit.Max
+	return unix.Prlimit(pid, unix.RLIMIT_CORE, &limit, nil)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// waitForCrashReports 等待进程的崩溃报告达到 n 个
+func waitForCrashReports(t *testing.T, m *Manager, name string, n int) []models.CrashReport {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		reports, err := m.ListCrashReports("test", name)
+		if err != nil {
+			t.Fatalf("ListCrashReports: %v", err)
+		}
+		if len(reports) >= n {
+			return reports
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d crash reports of %s after 5s, want %d", len(reports), name, n)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestValidateCrashPolicy(t *testing.T) {
+	for _, policy := range []models.CrashPolicy{
+		{OutputKB: -1},
+		{HistoryLimit: -1},
+		{CoreDir: "cores"},
+	} {
+		p := testProcess(t.TempDir(), "app")
+		p.Spec.OnCrash = &policy
+		if err := ValidateProcess(&p); !errors.Is(err, ErrInvalidProcess) || !strings.Contains(err.Error(), "on_crash:") {
+			t.Errorf("%+v: ValidateProcess = %v, want ErrInvalidProcess", policy, err)
+		}
+	}
+}
+
+func TestFormatEnviron(t *testing.T) {
+	got := formatEnviron([]byte("A=1\x00TOKEN=s3cret\x00B=x=y\x00"), map[string]bool{"TOKEN": true})
+	if want := "A=1\nTOKEN=******\nB=x=y\n"; string(got) != want {
+		t.Fatalf("formatEnviron = %q, want %q", got, want)
+	}
+}
+
+func TestManagerCrashReport(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	m.SetCrashDir(filepath.Join(dir, "crashes"))
+	coreDir := filepath.Join(dir, "cores")
+	if err := os.Mkdir(coreDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProcess(dir, "app")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "sleep 0.5; echo out; echo err >&2; exit 3"}}
+	p.Spec.Env = []models.EnvVar{{Name: "CRASH_TEST", Value: "1"}}
+	p.Spec.OnCrash = &models.CrashPolicy{CoreDir: coreDir, HistoryLimit: 1}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "app")
+	pid := status.Status.PID
+	// core_pattern 为 core.%p 时内核写出的文件，其他 PID 的文件不收集
+	core := fmt.Sprintf("core.%d", pid)
+	if err := os.WriteFile(filepath.Join(coreDir, core), []byte("core"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(coreDir, fmt.Sprintf("core.%d1", pid)), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := waitForCrashReports(t, m, "app", 1)[0]
+	if report.PID != pid || report.ExitCode != 3 || report.Size == 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	files := make(map[string]bool)
+	for _, f := range report.Files {
+		files[f.Name] = true
+	}
+	want := []string{"stdout.log", "stderr.log", "core/" + core}
+	if runtime.GOOS == "linux" {
+		want = append(want, "proc/status", "proc/limits", "proc/maps", "proc/environ")
+	}
+	for _, name := range want {
+		if !files[name] {
+			t.Errorf("report has no %s: %+v", name, report.Files)
+		}
+	}
+	if len(report.Files) != len(want) {
+		t.Errorf("report files = %+v, want %v", report.Files, want)
+	}
+
+	read := func(file string) string {
+		t.Helper()
+		var b bytes.Buffer
+		if err := m.ReadCrashFile("test", "app", report.ID, file, &b); err != nil {
+			t.Fatalf("ReadCrashFile %s: %v", file, err)
+		}
+		return b.String()
+	}
+	if got := read("stderr.log"); got != "err\n" {
+		t.Fatalf("stderr.log = %q", got)
+	}
+	if got := read("core/" + core); got != "core" {
+		t.Fatalf("core = %q", got)
+	}
+	if runtime.GOOS == "linux" && !strings.Contains(read("proc/environ"), "CRASH_TEST=1\n") {
+		t.Fatal("proc/environ does not contain CRASH_TEST")
+	}
+	if err := m.ReadCrashFile("test", "app", report.ID, "missing", &bytes.Buffer{}); !errors.Is(err, ErrCrashReportNotFound) {
+		t.Fatalf("ReadCrashFile missing = %v, want ErrCrashReportNotFound", err)
+	}
+	if _, err := m.GetCrashReport("test", "app", "../app"); !errors.Is(err, ErrCrashReportNotFound) {
+		t.Fatalf("GetCrashReport ../app = %v, want ErrCrashReportNotFound", err)
+	}
+	events, _ := m.ListEvents("test", "app", 0, 0)
+	if last := events[len(events)-1]; last.Type != models.EventCrashReported || last.Reason != report.ID {
+		t.Fatalf("last event = %+v, want CrashReported", last)
+	}
+
+	// 超过 HistoryLimit 的旧报告被删除
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		reports, _ := m.ListCrashReports("test", "app")
+		if len(reports) == 1 && reports[0].ID != report.ID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("crash reports = %+v, want only a new one", reports)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 被用户停止的进程不生成报告
+	s := testProcess(dir, "stopped")
+	s.Spec.OnCrash = &models.CrashPolicy{}
+	if err := m.CreateProcess(s); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "stopped"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if err := m.StopProcess("test", "stopped"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if reports, _ := m.ListCrashReports("test", "stopped"); len(reports) != 0 {
+		t.Fatalf("crash reports of a stopped process: %+v", reports)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// runAsUser 是由 Spec.User / Spec.UserGroup 解析出的运行身份
+type runAsUser struct {
+	Username string
+	HomeDir  string
+	UID      uint32
+	GID      uint32
+	// Groups 是附加组（仅在用户存在于系统用户数据库时可解析）
+	Groups []uint32
+}
+
+// resolveRunAsUser 解析进程的运行用户，User 与 UserGroup 均为空时返回 nil（以 vigil 自身身份运行）。
+// User 和 UserGroup 支持名称或数字 ID；数字 ID 可以不存在于系统用户数据库中。
+func resolveRunAsUser(spec *models.Spec) (*runAsUser, error) {
+	if spec.User == "" && spec.UserGroup == "" {
+		return nil, nil
+	}
+
+	u := &runAsUser{}
+	if spec.User != "" {
+		sysUser, err := lookupUser(spec.User)
+		if err != nil {
+			return nil, err
+		}
+		if sysUser != nil {
+			uid, _ := strconv.ParseUint(sysUser.Uid, 10, 32)
+			gid, _ := strconv.ParseUint(sysUser.Gid, 10, 32)
+			u.Username = sysUser.Username
+			u.HomeDir = sysUser.HomeDir
+			u.UID = uint32(uid)
+			u.GID = uint32(gid)
+			if gids, err := sysUser.GroupIds(); err == nil {
+				for _, g := range gids {
+					if v, err := strconv.ParseUint(g, 10, 32); err == nil {
+						u.Groups = append(u.Groups, uint32(v))
+					}
+				}
+			}
+		} else {
+			// 仅有数字 UID，没有对应的用户记录
+			uid, _ := strconv.ParseUint(spec.User, 10, 32)
+			u.Username = spec.User
+			u.HomeDir = "/"
+			u.UID = uint32(uid)
+			u.GID = uint32(uid)
+		}
+	} else {
+		// 只指定了用户组：保持当前用户，仅切换组
+		u.UID = uint32(os.Getuid())
+		u.GID = uint32(os.Getgid())
+		if current, err := user.Current(); err == nil {
+			u.Username = current.Username
+			u.HomeDir = current.HomeDir
+		}
+	}
+
+	if spec.UserGroup != "" {
+		gid, err := lookupGroupID(spec.UserGroup)
+		if err != nil {
+			return nil, err
+		}
+		u.GID = gid
+	}
+	return u, nil
+}
+
+// lookupUser 按名称或数字 UID 查找用户；数字 UID 不存在时返回 nil, nil
+func lookupUser(name string) (*user.User, error) {
+	if u, err := user.Lookup(name); err == nil {
+		return u, nil
+	}
+	if _, err := strconv.ParseUint(name, 10, 32); err != nil {
+		return nil, fmt.Errorf("unknown user %q", name)
+	}
+	if u, err := user.LookupId(name); err == nil {
+		return u, nil
+	}
+	return nil, nil
+}
+
+// lookupGroupID 按名称或数字 GID 查找用户组
+func lookupGroupID(name string) (uint32, error) {
+	if g, err := user.LookupGroup(name); err == nil {
+		gid, err := strconv.ParseUint(g.Gid, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid gid %q for group %q", g.Gid, name)
+		}
+		return uint32(gid), nil
+	}
+	gid, err := strconv.ParseUint(name, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q", name)
+	}
+	return uint32(gid), nil
+}
+
+// env 返回目标用户的 HOME/USER/LOGNAME 环境变量
+func (u *runAsUser) env() []string {
+	if u == nil {
+		return nil
+	}
+	return []string{
+		"HOME=" + u.HomeDir,
+		"USER=" + u.Username,
+		"LOGNAME=" + u.Username,
+	}
+}
+
+// chown 把 vigil 创建的文件或目录交给目标用户
+func (u *runAsUser) chown(path string) {
+	if u == nil {
+		return
+	}
+	if err := os.Chown(path, int(u.UID), int(u.GID)); err != nil {
+		fmt.Printf("Warning: failed to chown %s to %d:%d: %v\n", path, u.UID, u.GID, err)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestResolveRunAsUser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix uid/gid")
+	}
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("current user: %v", err)
+	}
+	group, err := user.LookupGroupId(current.Gid)
+	if err != nil {
+		t.Skipf("current group: %v", err)
+	}
+	uid, _ := strconv.ParseUint(current.Uid, 10, 32)
+	gid, _ := strconv.ParseUint(current.Gid, 10, 32)
+
+	u, err := resolveRunAsUser(&models.Spec{})
+	if err != nil || u != nil {
+		t.Fatalf("empty spec = %+v, %v, want nil, nil", u, err)
+	}
+
+	tests := []struct {
+		name      string
+		spec      models.Spec
+		wantUser  string
+		wantHome  string
+		wantUID   uint32
+		wantGID   uint32
+		wantGroup bool
+	}{
+		{"user name", models.Spec{User: current.Username}, current.Username, current.HomeDir, uint32(uid), uint32(gid), true},
+		{"numeric uid of known user", models.Spec{User: current.Uid}, current.Username, current.HomeDir, uint32(uid), uint32(gid), true},
+		{"numeric uid without passwd entry", models.Spec{User: "4000000001"}, "4000000001", "/", 4000000001, 4000000001, false},
+		{"user and group name", models.Spec{User: current.Username, UserGroup: group.Name}, current.Username, current.HomeDir, uint32(uid), uint32(gid), true},
+		{"user and numeric gid", models.Spec{User: current.Username, UserGroup: "4000000002"}, current.Username, current.HomeDir, uint32(uid), 4000000002, true},
+		{"group only", models.Spec{UserGroup: "4000000002"}, current.Username, current.HomeDir, uint32(os.Getuid()), 4000000002, false},
+	}
+	for _, tt := range tests {
+		u, err := resolveRunAsUser(&tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if u.Username != tt.wantUser || u.HomeDir != tt.wantHome || u.UID != tt.wantUID || u.GID != tt.wantGID {
+			t.Errorf("%s: got %s:%s %d:%d, want %s:%s %d:%d", tt.name,
+				u.Username, u.HomeDir, u.UID, u.GID, tt.wantUser, tt.wantHome, tt.wantUID, tt.wantGID)
+		}
+		if tt.wantGroup && len(u.Groups) == 0 {
+			t.Errorf("%s: supplementary groups not resolved", tt.name)
+		}
+	}
+
+	for _, spec := range []models.Spec{
+		{User: "vigil-no-such-user"},
+		{User: current.Username, UserGroup: "vigil-no-such-group"},
+		{UserGroup: "vigil-no-such-group"},
+		{User: "-1"},
+	} {
+		if u, err := resolveRunAsUser(&spec); err == nil {
+			t.Errorf("resolveRunAsUser(%q, %q) = %+v, want error", spec.User, spec.UserGroup, u)
+		}
+	}
+}
+
+func TestValidateProcessUnknownUser(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		user, group string
+		want        string
+	}{
+		{"vigil-no-such-user", "", `unknown user "vigil-no-such-user"`},
+		{"", "vigil-no-such-group", `unknown group "vigil-no-such-group"`},
+	} {
+		p := testProcess(dir, "app")
+		p.Spec.User = tt.user
+		p.Spec.UserGroup = tt.group
+		err := ValidateProcess(&p)
+		if !errors.Is(err, ErrInvalidProcess) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ValidateProcess(user=%q, group=%q) = %v, want %s", tt.user, tt.group, err, tt.want)
+		}
+	}
+}
+
+func TestManagerRunAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip("user nobody not found")
+	}
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "app")
+	// 测试目录只有 root 可进入，工作目录放在 / 下；输出由 vigil 写入日志文件
+	p.Spec.WorkingDir = "/"
+	p.Spec.User = "nobody"
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "id -u; id -g; sleep 300"}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	want := nobody.Uid + "\n" + nobody.Gid
+	var got string
+	deadline := time.Now().Add(5 * time.Second)
+	for got != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("output = %q after 5s, want %q", got, want)
+		}
+		time.Sleep(20 * time.Millisecond)
+		data, _ := os.ReadFile(filepath.Join(dir, "app.stdout.log"))
+		got = strings.TrimSpace(string(data))
+	}
+	if err := m.StopProcess("test", "app"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+}
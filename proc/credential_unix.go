@@ -0,0 +1,78 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !windows
+
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// applyCredential 让 cmd 以目标用户身份运行
+func applyCredential(cmd *exec.Cmd, u *runAsUser) error {
+	if u == nil {
+		return nil
+	}
+	// 非 root 且目标就是当前身份时无需切换（非 root 无法调用 setgroups）
+	if os.Geteuid() != 0 && int(u.UID) == os.Geteuid() && int(u.GID) == os.Getegid() {
+		return nil
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    u.UID,
+		Gid:    u.GID,
+		Groups: u.Groups,
+	}
+	return nil
+}
+
+// fileAccessible 运行用户对文件是否有 perm（4 读、2 写、1 执行）权限，u 为 nil 时检查 vigil 自身。
+// 只按属主、属组和其他用户的权限位判断，不考虑 ACL
+func fileAccessible(info os.FileInfo, u *runAsUser, perm os.FileMode) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	uid, gid, groups := uint32(os.Geteuid()), uint32(os.Getegid()), []uint32(nil)
+	if u != nil {
+		uid, gid, groups = u.UID, u.GID, u.Groups
+	} else if gids, err := os.Getgroups(); err == nil {
+		for _, g := range gids {
+			groups = append(groups, uint32(g))
+		}
+	}
+	mode := info.Mode().Perm()
+	if uid == 0 {
+		// root 可以读写任何文件，执行则需要至少一个执行位
+		return perm&1 == 0 || mode&0111 != 0 || info.IsDir()
+	}
+	if st.Uid == uid {
+		return (mode>>6)&perm == perm
+	}
+	inGroup := st.Gid == gid
+	for _, g := range groups {
+		inGroup = inGroup || st.Gid == g
+	}
+	if inGroup {
+		return (mode>>3)&perm == perm
+	}
+	return mode&perm == perm
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// applyCredential 在 Windows 上不支持切换运行用户
+func applyCredential(cmd *exec.Cmd, u *runAsUser) error {
+	if u == nil {
+		return nil
+	}
+	return errors.New("running processes as another user is not supported on windows")
+}
+
+// fileAccessible 在 Windows 上不检查权限
+func fileAccessible(info os.FileInfo, u *runAsUser, perm os.FileMode) bool {
+	return true
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// ErrProcessInUse 表示进程仍被其他进程依赖，API 层据此返回 409
+var ErrProcessInUse = errors.New("process is in use")
+
+const (
+	// defaultDependencyTimeout 等待依赖满足条件的默认超时时间
+	defaultDependencyTimeout = 2 * time.Minute
+	// dependencyPollInterval 检查依赖状态的间隔
+	dependencyPollInterval = 200 * time.Millisecond
+)
+
+// validateDependencies 校验 depends_on 本身的格式，不涉及其他进程
+func validateDependencies(mp *models.ManagedProcess) error {
+	seen := make(map[string]bool)
+	for _, dep := range mp.Spec.DependsOn {
+		if dep.Name == "" {
+			return fmt.Errorf("depends_on: name is required")
+		}
+		if dep.Name == mp.Metadata.Name {
+			return fmt.Errorf("depends_on: process cannot depend on itself")
+		}
+		if seen[dep.Name] {
+			return fmt.Errorf("depends_on: duplicate dependency %q", dep.Name)
+		}
+		seen[dep.Name] = true
+		switch dep.Condition {
+		case "", models.DependencyConditionStarted, models.DependencyConditionHealthy:
+		default:
+			return fmt.Errorf("depends_on.%s: unsupported condition %q", dep.Name, dep.Condition)
+		}
+		if dep.Timeout < 0 {
+			return fmt.Errorf("depends_on.%s: timeout must not be negative", dep.Name)
+		}
+	}
+	return nil
+}
+
+// checkDependencyCycle 检查把 mp 加入（或替换）namespace 后是否形成依赖环。
+// 调用方需持有 m.depMu，避免并发的创建/修改各自通过检查后形成环。
+func (m *Manager) checkDependencyCycle(mp *models.ManagedProcess) error {
+	graph := m.dependencyGraph(mp.Metadata.Namespace)
+	graph[mp.Metadata.Name] = dependencyNames(mp)
+
+	// 新的环一定经过 mp，从 mp 出发深度优先查找回到 mp 的路径
+	visited := map[string]bool{mp.Metadata.Name: true}
+	var path []string
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		path = append(path, name)
+		for _, dep := range graph[name] {
+			if dep == mp.Metadata.Name {
+				path = append(path, dep)
+				return true
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if visit(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(mp.Metadata.Name) {
+		return fmt.Errorf("%w: dependency cycle: %s", ErrInvalidProcess, strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// dependencyGraph 返回 namespace 内每个进程依赖的进程名
+func (m *Manager) dependencyGraph(namespace string) map[string][]string {
+	graph := make(map[string][]string)
+	for _, e := range m.listEntries() {
+		e.mu.RLock()
+		if e.process.Metadata.Namespace == namespace {
+			graph[e.process.Metadata.Name] = dependencyNames(&e.process)
+		}
+		e.mu.RUnlock()
+	}
+	return graph
+}
+
+// dependents 返回依赖指定进程的进程名（已排序）
+func (m *Manager) dependents(namespace, name string) []string {
+	var result []string
+	for proc, deps := range m.dependencyGraph(namespace) {
+		for _, dep := range deps {
+			if dep == name {
+				result = append(result, proc)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func dependencyNames(mp *models.ManagedProcess) []string {
+	names := make([]string, 0, len(mp.Spec.DependsOn))
+	for _, dep := range mp.Spec.DependsOn {
+		names = append(names, dep.Name)
+	}
+	return names
+}
+
+// sortByDependencies 按依赖关系排序，被依赖的进程在前；没有依赖关系的进程保持原有顺序。
+// 只考虑 processes 之间的依赖，外部依赖在启动时等待。
+func sortByDependencies(processes []models.ManagedProcess) ([]models.ManagedProcess, error) {
+	index := make(map[string]int, len(processes))
+	for i, p := range processes {
+		index[fmt.Sprintf("%s/%s", p.Metadata.Namespace, p.Metadata.Name)] = i
+	}
+
+	// Kahn 算法，每轮取原顺序中最靠前的就绪进程
+	pending := make([]int, len(processes))
+	dependents := make([][]int, len(processes))
+	for i, p := range processes {
+		for _, dep := range p.Spec.DependsOn {
+			if j, ok := index[fmt.Sprintf("%s/%s", p.Metadata.Namespace, dep.Name)]; ok {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	var ready []int
+	for i := range processes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	result := make([]models.ManagedProcess, 0, len(processes))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		result = append(result, processes[i])
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if len(result) != len(processes) {
+		var cyclic []string
+		for i, p := range processes {
+			if pending[i] > 0 {
+				cyclic = append(cyclic, fmt.Sprintf("%s/%s", p.Metadata.Namespace, p.Metadata.Name))
+			}
+		}
+		return nil, fmt.Errorf("dependency cycle between %s", strings.Join(cyclic, ", "))
+	}
+	return result, nil
+}
+
+// dependencySatisfied 检查依赖进程是否满足条件，依赖进程已停止或失败时返回错误
+func dependencySatisfied(dep models.Dependency, mp models.ManagedProcess) (bool, error) {
+	switch mp.Status.Phase {
+	case models.PhaseRunning:
+		if dep.Condition != models.DependencyConditionHealthy {
+			return true, nil
+		}
+		cond := mp.Status.GetCondition(models.ConditionTypeReady)
+		return cond != nil && cond.Status == models.ConditionTrue, nil
+	case models.PhaseStopped, models.PhaseFailed, "":
+		return false, fmt.Errorf("dependency %s is not running (%s)", dep.Name, phaseOrUnknown(mp.Status.Phase))
+	default:
+		// Pending/Stopping/CrashLoopBackOff 等待状态变化
+		return false, nil
+	}
+}
+
+func phaseOrUnknown(phase models.Phase) models.Phase {
+	if phase == "" {
+		return models.PhaseUnknown
+	}
+	return phase
+}
+
+// waitForDependencies 等待进程的所有依赖满足条件
+func (m *Manager) waitForDependencies(mp models.ManagedProcess) error {
+	for _, dep := range mp.Spec.DependsOn {
+		timeout := dep.Timeout
+		if timeout <= 0 {
+			timeout = defaultDependencyTimeout
+		}
+		deadline := time.Now().Add(timeout)
+		for {
+			e, exists := m.getEntry(mp.Metadata.Namespace, dep.Name)
+			if !exists {
+				return fmt.Errorf("dependency %s is not managed", dep.Name)
+			}
+			ok, err := dependencySatisfied(dep, e.snapshot())
+			if err != nil {
+				return err
+			}
+			if ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				condition := dep.Condition
+				if condition == "" {
+					condition = models.DependencyConditionStarted
+				}
+				return fmt.Errorf("timed out after %v waiting for dependency %s to be %s", timeout, dep.Name, condition)
+			}
+			time.Sleep(dependencyPollInterval)
+		}
+	}
+	return nil
+}
+
+// startOrdered 按依赖顺序依次启动进程，依赖满足条件后才启动依赖方；
+// 依赖启动失败时跳过依赖方。已运行的进程视为启动成功。
+func (m *Manager) startOrdered(processes []models.ManagedProcess) []models.ProcessOperationResult {
+	return m.runBulk(processes, 1, false, m.startWithDependencies)
+}
+
+// StartNamespace 按依赖顺序启动 namespace 下的所有进程
+func (m *Manager) StartNamespace(namespace string) ([]models.ProcessOperationResult, error) {
+	return m.BulkOperation(namespace, models.BulkStart, models.BulkOptions{})
+}
+
+// StopNamespace 按依赖的逆序停止 namespace 下的所有进程，依赖方先于被依赖的进程停止
+func (m *Manager) StopNamespace(namespace string) ([]models.ProcessOperationResult, error) {
+	return m.BulkOperation(namespace, models.BulkStop, models.BulkOptions{})
+}
+
+// isActivePhase 进程是否正在运行或即将被自动重启
+func isActivePhase(phase models.Phase) bool {
+	switch phase {
+	case models.PhaseRunning, models.PhasePending, models.PhaseCrashLoopBackOff:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+	"gopkg.in/yaml.v3"
+)
+
+func withDeps(p models.ManagedProcess, deps ...string) models.ManagedProcess {
+	for _, d := range deps {
+		p.Spec.DependsOn = append(p.Spec.DependsOn, models.Dependency{Name: d})
+	}
+	return p
+}
+
+func TestDependencyShortForm(t *testing.T) {
+	var spec models.Spec
+	if err := yaml.Unmarshal([]byte("depends_on:\n  - zookeeper\n  - name: kafka\n    condition: healthy\n"), &spec); err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	want := []models.Dependency{{Name: "zookeeper"}, {Name: "kafka", Condition: models.DependencyConditionHealthy}}
+	if len(spec.DependsOn) != 2 || spec.DependsOn[0] != want[0] || spec.DependsOn[1] != want[1] {
+		t.Fatalf("yaml depends_on = %+v, want %+v", spec.DependsOn, want)
+	}
+
+	spec = models.Spec{}
+	if err := json.Unmarshal([]byte(`{"depends_on":["zookeeper",{"name":"kafka","condition":"healthy"}]}`), &spec); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if len(spec.DependsOn) != 2 || spec.DependsOn[0] != want[0] || spec.DependsOn[1] != want[1] {
+		t.Fatalf("json depends_on = %+v, want %+v", spec.DependsOn, want)
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	dir := t.TempDir()
+	processes := []models.ManagedProcess{
+		withDeps(testProcess(dir, "app"), "kafka", "db"),
+		testProcess(dir, "db"),
+		withDeps(testProcess(dir, "kafka"), "zookeeper"),
+		testProcess(dir, "zookeeper"),
+	}
+	ordered, err := sortByDependencies(processes)
+	if err != nil {
+		t.Fatalf("sortByDependencies: %v", err)
+	}
+	var names []string
+	for _, p := range ordered {
+		names = append(names, p.Metadata.Name)
+	}
+	if got, want := strings.Join(names, ","), "db,zookeeper,kafka,app"; got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+
+	processes[1] = withDeps(testProcess(dir, "db"), "app")
+	if _, err := sortByDependencies(processes); err == nil {
+		t.Fatalf("sortByDependencies with cycle: want error")
+	}
+}
+
+func TestManagerDependencies(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	for _, p := range []models.ManagedProcess{
+		withDeps(testProcess(dir, "app"), "kafka"),
+		withDeps(testProcess(dir, "kafka"), "zookeeper"),
+		testProcess(dir, "zookeeper"),
+	} {
+		if err := m.CreateProcess(p); err != nil {
+			t.Fatalf("CreateProcess %s: %v", p.Metadata.Name, err)
+		}
+	}
+
+	// 创建和修改时检测循环依赖
+	err := m.UpdateProcess(withDeps(testProcess(dir, "zookeeper"), "app"))
+	if !errors.Is(err, ErrInvalidProcess) || !strings.Contains(err.Error(), "zookeeper -> app -> kafka -> zookeeper") {
+		t.Fatalf("UpdateProcess with cycle = %v, want dependency cycle", err)
+	}
+	if err := m.CreateProcess(withDeps(testProcess(dir, "self"), "self")); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("CreateProcess depending on itself = %v, want ErrInvalidProcess", err)
+	}
+
+	results, err := m.StartNamespace("test")
+	if err != nil {
+		t.Fatalf("StartNamespace: %v", err)
+	}
+	var names []string
+	for _, r := range results {
+		if r.Error != "" {
+			t.Fatalf("start %s: %s", r.Name, r.Error)
+		}
+		names = append(names, r.Name)
+	}
+	if got, want := strings.Join(names, ","), "zookeeper,kafka,app"; got != want {
+		t.Fatalf("start order = %s, want %s", got, want)
+	}
+
+	// 被依赖的进程不能删除
+	if err := m.DeleteProcess("test", "kafka"); !errors.Is(err, ErrProcessInUse) {
+		t.Fatalf("DeleteProcess kafka = %v, want ErrProcessInUse", err)
+	}
+
+	results, err = m.StopNamespace("test")
+	if err != nil {
+		t.Fatalf("StopNamespace: %v", err)
+	}
+	names = names[:0]
+	for _, r := range results {
+		if r.Error != "" {
+			t.Fatalf("stop %s: %s", r.Name, r.Error)
+		}
+		names = append(names, r.Name)
+	}
+	if got, want := strings.Join(names, ","), "app,kafka,zookeeper"; got != want {
+		t.Fatalf("stop order = %s, want %s", got, want)
+	}
+
+	// 依赖方删除后，被依赖的进程才能删除
+	if err := m.DeleteProcess("test", "app"); err != nil {
+		t.Fatalf("DeleteProcess app: %v", err)
+	}
+	for _, name := range []string{"kafka", "zookeeper"} {
+		if err := m.DeleteProcess("test", name); err != nil {
+			t.Fatalf("DeleteProcess %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultEventRetention 事件的默认保留时间
+	defaultEventRetention = 7 * 24 * time.Hour
+	// defaultMaxEventsPerProcess 每个进程默认保留的事件数
+	defaultMaxEventsPerProcess = 1000
+	// eventPruneInterval 清理过期事件的周期
+	eventPruneInterval = time.Hour
+	// eventWatchBuffer 订阅者的缓冲区大小，订阅者处理不及时时丢弃事件
+	eventWatchBuffer = 256
+	// memoryEventLimit 未配置存储时内存中保留的事件数
+	memoryEventLimit = 1000
+)
+
+// eventHub 记录进程事件并分发给订阅者。
+// 事件 ID 在记录时同步分配，写入存储由后台协程批量完成，避免事件密集时每条事件一次事务。
+type eventHub struct {
+	mu     sync.Mutex
+	subs   map[*eventSubscription]struct{}
+	lastID int64
+
+	// 未配置存储时，事件只保存在内存中
+	memory []models.ProcessEvent
+
+	// pending 是等待写入存储的事件，flushMu 保证按顺序写入
+	pending []models.ProcessEvent
+	flushMu sync.Mutex
+	wake    chan struct{}
+
+	retention     time.Duration
+	maxPerProcess int
+}
+
+// eventSubscription 是一个事件订阅，namespace 或 name 为空表示不过滤
+type eventSubscription struct {
+	ch        chan models.ProcessEvent
+	namespace string
+	name      string
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subs:          make(map[*eventSubscription]struct{}),
+		wake:          make(chan struct{}, 1),
+		retention:     defaultEventRetention,
+		maxPerProcess: defaultMaxEventsPerProcess,
+	}
+}
+
+func eventMatches(ev *models.ProcessEvent, namespace, name string) bool {
+	return (namespace == "" || ev.Namespace == namespace) && (name == "" || ev.Name == name)
+}
+
+// SetEventRetention 设置事件的保留时间和每个进程保留的最大条数，0 表示使用默认值
+func (m *Manager) SetEventRetention(retention time.Duration, maxPerProcess int) {
+	m.events.mu.Lock()
+	defer m.events.mu.Unlock()
+	if retention > 0 {
+		m.events.retention = retention
+	}
+	if maxPerProcess > 0 {
+		m.events.maxPerProcess = maxPerProcess
+	}
+}
+
+// recordEvent 分配事件 ID、通知订阅者，并交给后台协程持久化
+func (m *Manager) recordEvent(ev models.ProcessEvent) {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	log.Printf("Process %s/%s event %s: %s", ev.Namespace, ev.Name, ev.Type, ev.Message)
+
+	h := m.events
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	ev.ID = h.lastID
+	if m.store != nil {
+		h.pending = append(h.pending, ev)
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	} else {
+		h.memory = append(h.memory, ev)
+		if len(h.memory) > memoryEventLimit {
+			h.memory = h.memory[len(h.memory)-memoryEventLimit:]
+		}
+	}
+
+	for sub := range h.subs {
+		if !eventMatches(&ev, sub.namespace, sub.name) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// 订阅者处理不及时，丢弃事件；客户端可按 ID 增量补齐
+		}
+	}
+}
+
+// startEventWriter 从存储中恢复事件 ID，并启动持久化事件的后台协程
+func (m *Manager) startEventWriter() {
+	if m.store == nil {
+		return
+	}
+	if id, err := m.store.MaxEventID(); err != nil {
+		log.Printf("Warning: %v", err)
+	} else {
+		m.events.mu.Lock()
+		if id > m.events.lastID {
+			m.events.lastID = id
+		}
+		m.events.mu.Unlock()
+	}
+	go func() {
+		for range m.events.wake {
+			m.flushEvents()
+		}
+	}()
+}
+
+// flushEvents 把等待中的事件写入存储
+func (m *Manager) flushEvents() {
+	h := m.events
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	h.mu.Lock()
+	batch := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+	if len(batch) == 0 || m.store == nil {
+		return
+	}
+	if err := m.store.SaveEvents(batch); err != nil {
+		log.Printf("Warning: failed to save %d events: %v", len(batch), err)
+	}
+}
+
+// emitEvent 记录进程的一条事件
+func (m *Manager) emitEvent(e *processEntry, eventType models.EventType, reason, message string) {
+	e.mu.RLock()
+	ev := models.ProcessEvent{
+		Namespace: e.process.Metadata.Namespace,
+		Name:      e.process.Metadata.Name,
+		Type:      eventType,
+		PID:       e.process.Status.PID,
+		Reason:    reason,
+		Message:   message,
+	}
+	e.mu.RUnlock()
+	m.recordEvent(ev)
+}
+
+// ListEvents 按时间顺序返回进程事件。namespace 或 name 为空时不过滤，
+// afterID 用于增量查询，limit 大于 0 时只返回最近的 limit 条。
+func (m *Manager) ListEvents(namespace, name string, afterID int64, limit int) ([]models.ProcessEvent, error) {
+	if m.store != nil {
+		// 先写入尚未持久化的事件，保证查询结果包含已记录的事件
+		m.flushEvents()
+		return m.store.ListEvents(namespace, name, afterID, limit)
+	}
+
+	h := m.events
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var events []models.ProcessEvent
+	for i := range h.memory {
+		if h.memory[i].ID > afterID && eventMatches(&h.memory[i], namespace, name) {
+			events = append(events, h.memory[i])
+		}
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// WatchEvents 订阅新的进程事件，返回的 cancel 用于取消订阅并关闭通道
+func (m *Manager) WatchEvents(namespace, name string) (<-chan models.ProcessEvent, func()) {
+	sub := &eventSubscription{
+		ch:        make(chan models.ProcessEvent, eventWatchBuffer),
+		namespace: namespace,
+		name:      name,
+	}
+	h := m.events
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, sub)
+			h.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// pruneEvents 按保留策略清理事件
+func (m *Manager) pruneEvents() {
+	if m.store == nil {
+		return
+	}
+	m.events.mu.Lock()
+	retention, maxPerProcess := m.events.retention, m.events.maxPerProcess
+	m.events.mu.Unlock()
+	m.flushEvents()
+	if err := m.store.PruneEvents(time.Now().Add(-retention), maxPerProcess); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func eventTypes(events []models.ProcessEvent) []models.EventType {
+	types := make([]models.EventType, 0, len(events))
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestManagerEvents(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	watch, cancel := m.WatchEvents("test", "crash")
+	defer cancel()
+
+	p := testProcess(dir, "crash")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "exit 3"}}
+	p.Spec.RestartPolicy = models.RestartPolicyOnFailure
+	p.Spec.RestartInterval = 20 * time.Millisecond
+	p.Spec.RestartBackoff = &models.RestartBackoff{MaxRestarts: 1}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "crash"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	// 启动 -> 退出 -> 自动重启 -> 再次退出后达到重启上限
+	want := []models.EventType{models.EventStarted, models.EventExited, models.EventStarted, models.EventRestarted, models.EventExited}
+	var got []models.ProcessEvent
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case ev := <-watch:
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatalf("events = %v, want %v", eventTypes(got), want)
+		}
+	}
+	for i := range want {
+		if got[i].Type != want[i] {
+			t.Fatalf("events = %v, want %v", eventTypes(got), want)
+		}
+	}
+	if exited := got[1]; exited.ExitCode == nil || *exited.ExitCode != 3 || exited.PID == 0 {
+		t.Fatalf("Exited event = %+v, want exit code 3 and pid", exited)
+	}
+
+	// 事件持久化，并支持按 ID 增量查询
+	stored, err := m.ListEvents("test", "crash", 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(stored) != len(want) || stored[1].ExitCode == nil || *stored[1].ExitCode != 3 {
+		t.Fatalf("stored events = %+v, want %v", stored, want)
+	}
+	after, _ := m.ListEvents("test", "crash", stored[2].ID, 0)
+	if len(after) != 2 || after[0].ID != stored[3].ID {
+		t.Fatalf("events after %d = %v, want last 2", stored[2].ID, eventTypes(after))
+	}
+	latest, _ := m.ListEvents("", "", 0, 1)
+	if len(latest) != 1 || latest[0].ID != stored[4].ID {
+		t.Fatalf("latest event = %+v, want %+v", latest, stored[4])
+	}
+
+	// 按条数保留
+	m.SetEventRetention(0, 2)
+	m.pruneEvents()
+	stored, _ = m.ListEvents("test", "crash", 0, 0)
+	if len(stored) != 2 || stored[1].Type != models.EventExited {
+		t.Fatalf("events after prune = %v, want last 2", eventTypes(stored))
+	}
+
+	if err := m.DeleteProcess("test", "crash"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
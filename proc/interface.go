@@ -39,6 +39,20 @@ type ProcessLifecycle interface {
   RestartProcess(namespace, name string) error
   // DeleteProcess 删除一个已管理的进程
   DeleteProcess(namespace, name string) error
+  // ScaleProcess 调整设置了 Replicas 的进程的实例数
+  ScaleProcess(namespace, name string, replicas int) error
+  // StartNamespace 按依赖顺序启动 namespace 下的所有进程
+  StartNamespace(namespace string) ([]models.ProcessOperationResult, error)
+  // StopNamespace 按依赖的逆序停止 namespace 下的所有进程
+  StopNamespace(namespace string) ([]models.ProcessOperationResult, error)
+  // BulkOperation 对 namespace 下匹配标签选择器的进程执行批量操作
+  BulkOperation(namespace string, action models.BulkAction, opts models.BulkOptions) ([]models.ProcessOperationResult, error)
+  // StartRollout 在后台滚动重启 namespace 下匹配标签选择器的进程
+  StartRollout(namespace string, opts models.RolloutOptions) (models.Rollout, error)
+  // CancelRollout 取消进行中的滚动重启
+  CancelRollout(id string) (models.Rollout, error)
+  // ResumeRollout 继续失败或被取消的滚动重启
+  ResumeRollout(id string) (models.Rollout, error)
 }
 
 // ProcessInfo 定义进程信息查询相关操作
@@ -47,12 +61,30 @@ type ProcessInfo interface {
   GetProcessStatus(namespace, name string) (models.ManagedProcess, error)
   // ListManagedProcesses 获取所有已管理的进程
   ListManagedProcesses(namespace string) ([]models.ManagedProcess, error)
+  // SelectProcesses 获取 namespace 下标签匹配选择器的进程
+  SelectProcesses(namespace, selector string) ([]models.ManagedProcess, error)
   // GetProcesses 获取所有进程的映射
   GetProcesses() map[string]*models.ManagedProcess
+  // GetRollout 获取滚动重启的状态
+  GetRollout(id string) (models.Rollout, error)
+  // ListRollouts 获取 namespace 下的滚动重启
+  ListRollouts(namespace string) []models.Rollout
+  // ListEvents 查询进程生命周期事件
+  ListEvents(namespace, name string, afterID int64, limit int) ([]models.ProcessEvent, error)
+  // ListJobRuns 查询 Job 的运行记录
+  ListJobRuns(namespace, name string, limit int) ([]models.JobRun, error)
+  // WatchEvents 订阅新的进程生命周期事件
+  WatchEvents(namespace, name string) (<-chan models.ProcessEvent, func())
+  // AttachProcess 跟随进程的输出，stdin 为 true 时同时占用进程的标准输入
+  AttachProcess(namespace, name string, stdin bool) (*AttachSession, error)
 }
 
 // ProcessConfig 定义进程配置相关操作
 type ProcessConfig interface {
+  // UpdateProcess 更新进程定义
+  UpdateProcess(process models.ManagedProcess) error
+  // ApplyProcesses 以一组进程定义作为 namespace 的期望状态进行创建、更新和清理
+  ApplyProcesses(namespace string, req models.ApplyRequest) ([]models.ApplyResult, error)
   // UpdateProcessConfig 更新进程配置
   UpdateProcessConfig(namespace, name string, config config.AppConfig) error
   // SaveManagedProcesses 保存所有已管理的进程到文件
@@ -61,6 +93,16 @@ type ProcessConfig interface {
   LoadManagedProcesses(filePath string) error
 }
 
+// ProcessSecrets 定义密钥管理相关操作
+type ProcessSecrets interface {
+  // SetSecret 新建或更新密钥，返回是否为新建
+  SetSecret(name, value string) (bool, error)
+  // ListSecrets 返回所有密钥（不含值）
+  ListSecrets() ([]models.Secret, error)
+  // DeleteSecret 删除未被进程引用的密钥
+  DeleteSecret(name string) error
+}
+
 // ProcessMonitor 定义进程监控相关操作
 type ProcessMonitor interface {
   // MonitorProcess 监控进程资源使用情况
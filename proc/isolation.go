@@ -0,0 +1,110 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// isolationInitArg 是隔离进程 init 的 argv[0]，vigil 以此识别自己被作为 init 重新执行
+	isolationInitArg = "vigil-isolation-init"
+	// isolationConfigEnv 是传递给 init 的配置（JSON），init 启动目标进程前会移除
+	isolationConfigEnv = "VIGIL_ISOLATION_CONFIG"
+)
+
+// capabilityNames 是 Linux capability 名称，下标即 capability 编号
+var capabilityNames = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER", "CAP_FSETID",
+	"CAP_KILL", "CAP_SETGID", "CAP_SETUID", "CAP_SETPCAP", "CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST", "CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_IPC_LOCK",
+	"CAP_IPC_OWNER", "CAP_SYS_MODULE", "CAP_SYS_RAWIO", "CAP_SYS_CHROOT", "CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT", "CAP_SYS_ADMIN", "CAP_SYS_BOOT", "CAP_SYS_NICE", "CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_MKNOD", "CAP_LEASE", "CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL", "CAP_SETFCAP", "CAP_MAC_OVERRIDE", "CAP_MAC_ADMIN", "CAP_SYSLOG",
+	"CAP_WAKE_ALARM", "CAP_BLOCK_SUSPEND", "CAP_AUDIT_READ", "CAP_PERFMON", "CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// parseCapabilities 把 capability 名称（可省略 CAP_ 前缀，不区分大小写）解析为编号，ALL 表示全部
+func parseCapabilities(names []string) ([]int, error) {
+	var caps []int
+	for _, name := range names {
+		upper := strings.ToUpper(name)
+		if upper == "ALL" {
+			caps = caps[:0]
+			for i := range capabilityNames {
+				caps = append(caps, i)
+			}
+			return caps, nil
+		}
+		if !strings.HasPrefix(upper, "CAP_") {
+			upper = "CAP_" + upper
+		}
+		found := false
+		for i, c := range capabilityNames {
+			if c == upper {
+				caps = append(caps, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown capability %q", name)
+		}
+	}
+	return caps, nil
+}
+
+// validateIsolation 校验隔离配置
+func validateIsolation(iso *models.Isolation) error {
+	if iso == nil {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("isolation is only supported on Linux")
+	}
+	if len(iso.Hostname) > 64 {
+		return fmt.Errorf("isolation.hostname must not be longer than 64 characters")
+	}
+	if _, err := parseCapabilities(iso.DropCapabilities); err != nil {
+		return fmt.Errorf("isolation.drop_capabilities: %v", err)
+	}
+	return nil
+}
+
+// hostMounts 返回需要在宿主机 mount 命名空间中应用和清理的挂载。
+// 启用隔离时挂载只在进程自己的 mount 命名空间内生效，随命名空间销毁，无需清理。
+func hostMounts(spec *models.Spec) []models.Mount {
+	if spec.Isolation != nil {
+		return nil
+	}
+	return spec.Mounts
+}
+
+// samplePID 返回采集资源使用和 /proc 信息时使用的 PID。
+// 启用隔离时 Status.PID 是命名空间内的 init，资源和崩溃信息应描述它启动的目标进程
+func samplePID(isolated bool, pid int) int {
+	if !isolated {
+		return pid
+	}
+	return isolatedTargetPID(pid)
+}
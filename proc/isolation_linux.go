@@ -0,0 +1,277 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build linux
+
+package proc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/casuallc/vigil/models"
+	"golang.org/x/sys/unix"
+)
+
+// isolationConfig 是 vigil 传递给隔离进程 init 的配置
+type isolationConfig struct {
+	// Path 和 Args 是目标进程的可执行文件和参数（Args[0] 为命令名）
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	// Dir 是目标进程的工作目录，HostDir 是 vigil 的工作目录（命名卷相对它创建）
+	Dir     string `json:"dir"`
+	HostDir string `json:"host_dir"`
+
+	Mounts     []models.Mount      `json:"mounts,omitempty"`
+	Isolation  models.Isolation    `json:"isolation"`
+	RunAs      *runAsUser          `json:"run_as,omitempty"`
+	Credential *syscall.Credential `json:"credential,omitempty"`
+}
+
+// applyIsolation 让 cmd 在新的命名空间中启动。
+// vigil 以 isolationInitArg 重新执行自身作为命名空间内的 init（PID 1）：init 在新的 mount 命名空间中
+// 应用挂载和安全设置，再以目标用户身份启动真正的进程，转发信号并回收孤儿进程。
+func applyIsolation(cmd *exec.Cmd, mp *models.ManagedProcess, runAs *runAsUser) error {
+	iso := mp.Spec.Isolation
+	if iso == nil {
+		return nil
+	}
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	hostDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	cfg := isolationConfig{
+		Path:      cmd.Path,
+		Args:      cmd.Args,
+		Dir:       cmd.Dir,
+		HostDir:   hostDir,
+		Mounts:    mp.Spec.Mounts,
+		Isolation: *iso,
+		RunAs:     runAs,
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// 挂载需要 root 权限，由 init 在应用挂载后再切换用户
+	cfg.Credential = cmd.SysProcAttr.Credential
+	cmd.SysProcAttr.Credential = nil
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	cmd.Path = "/proc/self/exe"
+	cmd.Args = []string{isolationInitArg}
+	cmd.Env = append(cmd.Env, isolationConfigEnv+"="+string(data))
+
+	flags := uintptr(syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC)
+	if iso.Network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr.Cloneflags = flags
+	return nil
+}
+
+// isolatedTargetPID 返回隔离进程 init（pid）启动的目标进程，找不到时返回 pid。
+// init 最先启动目标进程，之后过继给 init 的孤儿进程都启动得更晚，因此取最早启动的子进程
+func isolatedTargetPID(pid int) int {
+	// 子进程记录在创建它的线程下，init 是多线程的 Go 程序，需要遍历所有线程
+	files, _ := filepath.Glob(filepath.Join("/proc", strconv.Itoa(pid), "task", "*", "children"))
+	target, earliest := pid, int64(0)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(data)) {
+			child, err := strconv.Atoi(field)
+			if err != nil {
+				continue
+			}
+			start, err := processStartTime(child)
+			if err != nil {
+				continue
+			}
+			if target == pid || start < earliest {
+				target, earliest = child, start
+			}
+		}
+	}
+	return target
+}
+
+// IsolationInit 如果当前进程是被 vigil 重新执行的隔离进程 init，则运行 init 并以目标进程的退出码退出；
+// 否则直接返回。需要在 main 函数开始时调用。
+func IsolationInit() {
+	if len(os.Args) == 0 || os.Args[0] != isolationInitArg {
+		return
+	}
+	code, err := runIsolationInit()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", isolationInitArg, err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+func runIsolationInit() (int, error) {
+	// prctl 设置（no_new_privs、capability 边界集合）是线程级的，需要在同一线程中启动目标进程
+	runtime.LockOSThread()
+
+	var cfg isolationConfig
+	if err := json.Unmarshal([]byte(os.Getenv(isolationConfigEnv)), &cfg); err != nil {
+		return 0, fmt.Errorf("invalid isolation config: %v", err)
+	}
+	os.Unsetenv(isolationConfigEnv)
+
+	if err := setupIsolatedMounts(&cfg); err != nil {
+		return 0, err
+	}
+	if cfg.Isolation.Hostname != "" {
+		if err := unix.Sethostname([]byte(cfg.Isolation.Hostname)); err != nil {
+			return 0, fmt.Errorf("failed to set hostname: %v", err)
+		}
+	}
+	if cfg.Isolation.Network {
+		if err := setLoopbackUp(); err != nil {
+			return 0, fmt.Errorf("failed to bring up lo: %v", err)
+		}
+	}
+	if cfg.Isolation.NoNewPrivs {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return 0, fmt.Errorf("failed to set no_new_privs: %v", err)
+		}
+	}
+	caps, err := parseCapabilities(cfg.Isolation.DropCapabilities)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range caps {
+		// 内核不支持的 capability 返回 EINVAL，忽略
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return 0, fmt.Errorf("failed to drop %s: %v", capabilityNames[c], err)
+		}
+	}
+
+	// 在启动目标进程前订阅信号，避免错过 SIGCHLD
+	signals := make(chan os.Signal, 32)
+	signal.Notify(signals)
+
+	cmd := exec.Command(cfg.Path)
+	cmd.Args = cfg.Args
+	cmd.Env = os.Environ()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// 目标进程使用独立的进程组，init 把收到的信号转发给整个进程组
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Credential: cfg.Credential}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+
+	for sig := range signals {
+		switch sig {
+		case syscall.SIGCHLD:
+			// 回收所有退出的子进程，包括被过继给 init 的孤儿进程
+			if code, exited := reapChildren(pid); exited {
+				return code, nil
+			}
+		case syscall.SIGURG:
+			// Go 运行时用于抢占调度，不转发
+		default:
+			if s, ok := sig.(syscall.Signal); ok {
+				_ = syscall.Kill(-pid, s)
+			}
+		}
+	}
+	return 0, nil
+}
+
+// reapChildren 回收已退出的子进程，目标进程退出时返回其退出码（被信号终止时为 128+信号编号）
+func reapChildren(pid int) (int, bool) {
+	for {
+		var status syscall.WaitStatus
+		wpid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err != nil || wpid <= 0 {
+			return 0, false
+		}
+		if wpid != pid {
+			continue
+		}
+		if status.Signaled() {
+			return 128 + int(status.Signal()), true
+		}
+		return status.ExitStatus(), true
+	}
+}
+
+// setupIsolatedMounts 在新的 mount 命名空间中应用挂载、挂载 /proc，并按配置将根文件系统改为只读
+func setupIsolatedMounts(cfg *isolationConfig) error {
+	// 挂载事件不传播回宿主机
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %v", err)
+	}
+	// 命名卷相对 vigil 的工作目录创建，与不隔离时一致
+	if err := os.Chdir(cfg.HostDir); err != nil {
+		return err
+	}
+	if err := applyMounts(cfg.Mounts, cfg.RunAs); err != nil {
+		return fmt.Errorf("failed to apply mounts: %w", err)
+	}
+	// 新的 PID 命名空间需要自己的 /proc
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("failed to mount /proc: %v", err)
+	}
+	if cfg.Isolation.ReadOnlyRootfs {
+		if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY, ""); err != nil {
+			return fmt.Errorf("failed to remount / read-only: %v", err)
+		}
+	}
+	return os.Chdir(cfg.Dir)
+}
+
+// setLoopbackUp 启用新网络命名空间中的 lo
+func setLoopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// TestMain 让测试二进制也能作为隔离进程的 init 被重新执行
+func TestMain(m *testing.M) {
+	IsolationInit()
+	os.Exit(m.Run())
+}
+
+func TestManagerIsolation(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	if err := exec.Command("unshare", "-m", "-p", "-u", "-i", "-f", "true").Run(); err != nil {
+		t.Skipf("namespaces unavailable: %v", err)
+	}
+	m := newTestManager(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	target := filepath.Join(dir, "scratch")
+
+	p := testProcess(dir, "isolated")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c",
+		"{ tr -d '\\000' < /proc/1/cmdline; echo; hostname; touch /vigil-rootfs-test 2>/dev/null && echo rw || echo ro; " +
+			"touch " + target + "/file && echo tmpfs-rw; } > " + out + "; sleep 300"}}
+	// 根文件系统只读时，测试目录通过可写的 bind 挂载保留给进程
+	p.Spec.Mounts = []models.Mount{
+		{Type: "bind", Source: dir, Target: dir},
+		{Type: "tmpfs", Target: target, CreateTarget: true},
+	}
+	p.Spec.Isolation = &models.Isolation{Hostname: "sandbox", NoNewPrivs: true, DropCapabilities: []string{"NET_RAW"}, ReadOnlyRootfs: true}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "isolated"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var lines []string
+	for len(lines) < 4 {
+		if time.Now().After(deadline) {
+			data, _ := os.ReadFile(filepath.Join(dir, "isolated.stderr.log"))
+			t.Fatalf("output = %q after 5s, stderr: %s", lines, data)
+		}
+		time.Sleep(20 * time.Millisecond)
+		data, _ := os.ReadFile(out)
+		lines = strings.Fields(string(data))
+	}
+	// 新的 PID 命名空间中 init 是 PID 1
+	if want := []string{isolationInitArg, "sandbox", "ro", "tmpfs-rw"}; strings.Join(lines, ",") != strings.Join(want, ",") {
+		t.Fatalf("output = %q, want %q", lines, want)
+	}
+	// 资源采样描述 init 启动的目标进程而不是 init 本身
+	got, _ := m.GetProcessStatus("test", "isolated")
+	initPID := got.Status.PID
+	targetPID := isolatedTargetPID(initPID)
+	if targetPID == initPID {
+		t.Fatalf("isolatedTargetPID(%d) did not find the target process", initPID)
+	}
+	if ppid, _, err := procParentSession(targetPID); err != nil || ppid != initPID {
+		t.Fatalf("parent of target %d = %d, %v, want init %d", targetPID, ppid, err, initPID)
+	}
+	cache := make(map[string]*process.Process)
+	m.sampleAll(cache)
+	if p := cache["test/isolated"]; p == nil || int(p.Pid) != targetPID {
+		t.Fatalf("sampled process = %v, want target %d", p, targetPID)
+	}
+	if got, _ := m.GetProcessStatus("test", "isolated"); got.Status.ResourceStats == nil {
+		t.Fatalf("ResourceStats not set after sampling")
+	}
+
+	// tmpfs 只挂载在进程的 mount 命名空间中
+	if _, err := os.Stat(filepath.Join(target, "file")); !os.IsNotExist(err) {
+		t.Fatalf("tmpfs mount leaked to the host namespace: %v", err)
+	}
+
+	if err := m.StopProcess("test", "isolated"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	got, _ = m.GetProcessStatus("test", "isolated")
+	if info := got.Status.LastTerminationInfo; info == nil || info.Escalated {
+		t.Fatalf("LastTerminationInfo = %+v, want a graceful stop", info)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !linux
+
+package proc
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// IsolationInit 在非 Linux 平台上不做任何事
+func IsolationInit() {}
+
+// applyIsolation 在非 Linux 平台上不支持命名空间隔离
+func applyIsolation(cmd *exec.Cmd, mp *models.ManagedProcess, runAs *runAsUser) error {
+	if mp.Spec.Isolation == nil {
+		return nil
+	}
+	return fmt.Errorf("isolation is only supported on Linux")
+}
+
+// isolatedTargetPID 在非 Linux 平台上没有隔离进程 init，直接返回 pid
+func isolatedTargetPID(pid int) int {
+	return pid
+}
@@ -0,0 +1,622 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultJobBackoffLimit 是 Job 默认的失败重试次数
+	defaultJobBackoffLimit = 6
+	// defaultJobHistoryLimit 是每个 Job 默认保留的运行记录数
+	defaultJobHistoryLimit = 100
+	// jobOutputLimit 是运行记录中每路输出保留的字节数
+	jobOutputLimit = 16 * 1024
+	// jobOutputWait 是进程退出后等待输出复制完成的时间
+	jobOutputWait = time.Second
+	// memoryJobRunLimit 未配置存储时内存中保留的运行记录数
+	memoryJobRunLimit = 1000
+)
+
+// isJob 进程是否为 Job
+func isJob(mp *models.ManagedProcess) bool {
+	return mp.Kind == models.KindJob
+}
+
+// isCronJob 进程是否为定时 Job：它自己不运行，按时间表创建运行 Job 的实例
+func isCronJob(mp *models.ManagedProcess) bool {
+	return isJob(mp) && mp.Spec.Job != nil && mp.Spec.Job.Schedule != ""
+}
+
+// jobSpec 返回填充了默认值的 Job 配置
+func jobSpec(mp *models.ManagedProcess) models.JobSpec {
+	var spec models.JobSpec
+	if mp.Spec.Job != nil {
+		spec = *mp.Spec.Job
+	}
+	if spec.Completions <= 0 {
+		spec.Completions = 1
+	}
+	if spec.BackoffLimit == nil {
+		limit := defaultJobBackoffLimit
+		spec.BackoffLimit = &limit
+	}
+	if spec.ConcurrencyPolicy == "" {
+		spec.ConcurrencyPolicy = models.ConcurrencyAllow
+	}
+	if spec.HistoryLimit <= 0 {
+		spec.HistoryLimit = defaultJobHistoryLimit
+	}
+	return spec
+}
+
+// validateJob 校验 Kind 和 Job 配置
+func validateJob(mp *models.ManagedProcess) error {
+	switch mp.Kind {
+	case "", models.KindService:
+		if mp.Spec.Job != nil {
+			return fmt.Errorf("job is only allowed for kind %s", models.KindJob)
+		}
+		return nil
+	case models.KindJob:
+	default:
+		return fmt.Errorf("unsupported kind %q", mp.Kind)
+	}
+
+	switch {
+	case mp.Spec.Replicas != nil:
+		return fmt.Errorf("replicas is not supported for jobs")
+	case mp.Spec.HealthCheck != nil:
+		return fmt.Errorf("health_check is not supported for jobs")
+	case mp.Spec.Notify != nil:
+		return fmt.Errorf("notify is not supported for jobs")
+	}
+	job := mp.Spec.Job
+	if job == nil {
+		return nil
+	}
+	if job.Completions < 0 || job.ActiveDeadline < 0 || job.HistoryLimit < 0 || (job.BackoffLimit != nil && *job.BackoffLimit < 0) {
+		return fmt.Errorf("job: values must not be negative")
+	}
+	switch job.ConcurrencyPolicy {
+	case "", models.ConcurrencyAllow, models.ConcurrencyForbid, models.ConcurrencyReplace:
+	default:
+		return fmt.Errorf("job: unsupported concurrency_policy %q", job.ConcurrencyPolicy)
+	}
+	if job.Schedule != "" {
+		if _, err := parseCron(job.Schedule); err != nil {
+			return fmt.Errorf("job: %v", err)
+		}
+	}
+	return nil
+}
+
+func (e *processEntry) isJob() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return isJob(&e.process)
+}
+
+func (e *processEntry) isCronJob() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return isCronJob(&e.process)
+}
+
+// handleJobEvent 处理 Job 的事件：进程成功退出 Completions 次后完成，失败后按退避重试，
+// 超过 BackoffLimit 或 ActiveDeadline 时失败。Job 不会被重关联
+func (m *Manager) handleJobEvent(e *processEntry, ev reconcileEvent) error {
+	switch ev.kind {
+	case eventStart:
+		if e.run != nil || e.isRunning() {
+			return fmt.Errorf("job %s is already running", e.key)
+		}
+		e.cancelRestartTimer()
+		e.resetBackoff()
+		e.setStoppedByUser(false)
+		now := time.Now()
+		e.updateStatus(func(status *models.Status) {
+			status.Job = &models.JobStatus{StartTime: &now}
+		})
+		e.jobAttempt = 0
+		mp := e.snapshot()
+		if deadline := jobSpec(&mp).ActiveDeadline; deadline > 0 {
+			e.startJobTimer(deadline)
+		} else {
+			e.cancelJobTimer()
+		}
+		return m.startJobRun(e)
+
+	case eventRestart:
+		if e.run != nil {
+			e.jobStopReason = "Restarted"
+			if err := m.stopRun(e); err != nil {
+				return err
+			}
+		}
+		return m.handleJobEvent(e, reconcileEvent{kind: eventStart})
+
+	case eventStop, eventDelete:
+		e.cancelJobTimer()
+		if e.run != nil {
+			e.jobStopReason = "Stopped"
+		}
+		return m.handleEvent(e, ev)
+
+	case eventExited:
+		if ev.run != e.run {
+			return nil
+		}
+		m.finishRun(e, nil)
+		m.jobRunExited(e, ev.run.err)
+
+	case eventRestartTimer:
+		if ev.gen != e.timerGen || e.restartTimer == nil {
+			return nil
+		}
+		e.restartTimer = nil
+		if e.run != nil || e.isStoppedByUser() {
+			return nil
+		}
+		_ = m.startJobRun(e)
+
+	case eventJobTimer:
+		if ev.gen != e.jobGen {
+			return nil
+		}
+		e.cancelRestartTimer()
+		mp := e.snapshot()
+		spec := jobSpec(&mp)
+		if e.run != nil {
+			e.jobStopReason = "DeadlineExceeded"
+			if err := m.stopRun(e); err != nil {
+				log.Printf("Failed to stop job %s: %v", e.key, err)
+			}
+		}
+		m.failJob(e, "DeadlineExceeded", fmt.Sprintf("job did not complete within %s", spec.ActiveDeadline))
+
+	case eventAdopted, eventLost:
+		return nil
+
+	default:
+		return m.handleEvent(e, ev)
+	}
+	return nil
+}
+
+// startJobRun 开始 Job 的下一次运行，启动失败同样计为一次失败并按退避重试（仅在 reconcile 协程中调用）
+func (m *Manager) startJobRun(e *processEntry) error {
+	e.jobAttempt++
+	started := time.Now()
+	if err := m.startRun(e); err != nil {
+		log.Printf("Failed to start job %s: %v", e.key, err)
+		mp := e.snapshot()
+		m.saveJobRun(&mp, models.JobRun{
+			Namespace:  mp.Metadata.Namespace,
+			Name:       e.jobName(),
+			Instance:   e.jobInstance(),
+			Attempt:    e.jobAttempt,
+			StartTime:  started,
+			FinishTime: time.Now(),
+			ExitCode:   -1,
+			Reason:     "StartFailed",
+			Stderr:     err.Error(),
+		})
+		m.jobRunExited(e, err)
+		return err
+	}
+	return nil
+}
+
+// jobRunExited 统计一次运行的结果，决定继续运行、重试、完成还是失败（仅在 reconcile 协程中调用）
+func (m *Manager) jobRunExited(e *processEntry, exitErr error) {
+	mp := e.snapshot()
+	spec := jobSpec(&mp)
+	var succeeded, failed int
+	e.updateStatus(func(status *models.Status) {
+		if status.Job == nil {
+			status.Job = &models.JobStatus{}
+		}
+		if exitErr == nil {
+			status.Job.Succeeded++
+		} else {
+			status.Job.Failed++
+		}
+		succeeded, failed = status.Job.Succeeded, status.Job.Failed
+	})
+	if e.isStoppedByUser() {
+		return
+	}
+
+	if exitErr == nil {
+		if succeeded < spec.Completions {
+			_ = m.startJobRun(e)
+			return
+		}
+		e.cancelJobTimer()
+		now := time.Now()
+		message := fmt.Sprintf("completed %d/%d runs", succeeded, spec.Completions)
+		e.updateStatus(func(status *models.Status) {
+			status.Phase = models.PhaseSucceeded
+			status.NextRetryTime = nil
+			status.Job.CompletionTime = &now
+			status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "Completed", message)
+		})
+		m.emitEvent(e, models.EventCompleted, "", message)
+		return
+	}
+
+	if failed > *spec.BackoffLimit {
+		m.failJob(e, "BackoffLimitExceeded", fmt.Sprintf("failed %d times, backoff_limit is %d", failed, *spec.BackoffLimit))
+		return
+	}
+	delay := jobRetryDelay(&mp, failed)
+	next := time.Now().Add(delay)
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = models.PhaseCrashLoopBackOff
+		status.BackoffCount = int32(failed)
+		status.NextRetryTime = &next
+	})
+	e.startRestartTimer(delay)
+}
+
+// jobRetryDelay 返回第 failed 次失败后的重试间隔，从 RestartInterval 开始指数增长
+func jobRetryDelay(mp *models.ManagedProcess, failed int) time.Duration {
+	delay := mp.Spec.RestartInterval
+	if delay <= 0 {
+		delay = defaultRestartInterval
+	}
+	max := defaultBackoffMaxInterval
+	if mp.Spec.RestartBackoff != nil && mp.Spec.RestartBackoff.MaxInterval > 0 {
+		max = mp.Spec.RestartBackoff.MaxInterval
+	}
+	for i := 1; i < failed && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// failJob 把 Job 置为失败并停止后续重试
+func (m *Manager) failJob(e *processEntry, reason, message string) {
+	e.cancelJobTimer()
+	e.cancelRestartTimer()
+	log.Printf("Job %s failed: %s", e.key, message)
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = models.PhaseFailed
+		status.NextRetryTime = nil
+		status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, reason, message)
+	})
+	m.emitEvent(e, models.EventJobFailed, reason, message)
+}
+
+// recordJobRun 在运行结束时保存运行记录，包括退出码、耗时和最后的输出（仅在 reconcile 协程中调用）
+func (m *Manager) recordJobRun(e *processEntry, run *processRun, info *models.TerminationInfo, stop *stopResult) {
+	mp := e.snapshot()
+	record := models.JobRun{
+		Namespace:  mp.Metadata.Namespace,
+		Name:       e.jobName(),
+		Instance:   e.jobInstance(),
+		Attempt:    e.jobAttempt,
+		PID:        run.pid,
+		StartTime:  e.runStarted,
+		FinishTime: info.FinishedAt,
+		ExitCode:   info.ExitCode,
+		Signal:     info.Signal,
+		Succeeded:  run.err == nil && stop == nil,
+		Reason:     info.Reason,
+	}
+	if stop != nil {
+		record.Reason = e.jobStopReason
+		if record.Reason == "" {
+			record.Reason = "Stopped"
+		}
+	}
+	e.jobStopReason = ""
+	for i, o := range run.outputs {
+		if !o.wait(jobOutputWait) {
+			log.Printf("Warning: output of job %s is still open, recording partial output", e.key)
+		}
+		if i == 0 {
+			record.Stdout = o.tail.last(jobOutputLimit)
+		} else {
+			record.Stderr = o.tail.last(jobOutputLimit)
+		}
+	}
+	m.saveJobRun(&mp, record)
+}
+
+// saveJobRun 持久化运行记录；未配置存储时只保存在内存中
+func (m *Manager) saveJobRun(mp *models.ManagedProcess, run models.JobRun) {
+	run.Duration = run.FinishTime.Sub(run.StartTime)
+	if m.store != nil {
+		if _, err := m.store.SaveJobRun(run, jobSpec(mp).HistoryLimit); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return
+	}
+	m.jobRunsMu.Lock()
+	defer m.jobRunsMu.Unlock()
+	m.jobRunSeq++
+	run.ID = m.jobRunSeq
+	m.jobRuns = append(m.jobRuns, run)
+	if len(m.jobRuns) > memoryJobRunLimit {
+		m.jobRuns = m.jobRuns[len(m.jobRuns)-memoryJobRunLimit:]
+	}
+}
+
+// ListJobRuns 按时间顺序返回 Job 最近的 limit 条运行记录，limit 为 0 时返回全部
+func (m *Manager) ListJobRuns(namespace, name string, limit int) ([]models.JobRun, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	if m.store != nil {
+		return m.store.ListJobRuns(namespace, name, limit)
+	}
+	m.jobRunsMu.Lock()
+	defer m.jobRunsMu.Unlock()
+	var runs []models.JobRun
+	for _, run := range m.jobRuns {
+		if run.Namespace == namespace && run.Name == name {
+			runs = append(runs, run)
+		}
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	return runs, nil
+}
+
+// jobName 返回运行记录所属的 Job 名，定时 Job 的实例记录在定时 Job 名下
+func (e *processEntry) jobName() string {
+	if e.parent != "" {
+		return e.parent
+	}
+	return e.snapshot().Metadata.Name
+}
+
+// jobInstance 返回定时 Job 实例的名称，普通 Job 为空
+func (e *processEntry) jobInstance() string {
+	if e.parent == "" {
+		return ""
+	}
+	return e.snapshot().Metadata.Name
+}
+
+// startJobTimer 在 d 之后向 reconcile 协程发送 Job 定时事件（运行时间上限或下一次调度）
+func (e *processEntry) startJobTimer(d time.Duration) {
+	e.cancelJobTimer()
+	gen := e.jobGen
+	e.jobTimer = time.AfterFunc(d, func() {
+		e.send(reconcileEvent{kind: eventJobTimer, gen: gen})
+	})
+}
+
+// cancelJobTimer 取消尚未触发的 Job 定时事件
+func (e *processEntry) cancelJobTimer() {
+	if e.jobTimer != nil {
+		e.jobTimer.Stop()
+		e.jobTimer = nil
+	}
+	e.jobGen++
+}
+
+// handleCronJobEvent 处理定时 Job 的事件。定时 Job 处于 Running 时按时间表创建实例运行 Job，
+// stop 暂停调度并停止正在进行的运行
+func (m *Manager) handleCronJobEvent(e *processEntry, ev reconcileEvent) error {
+	switch ev.kind {
+	case eventStart:
+		e.setStoppedByUser(false)
+		m.scheduleNextJob(e)
+
+	case eventStop:
+		e.setStoppedByUser(true)
+		return m.stopCronJob(e)
+
+	case eventRestart:
+		e.setStoppedByUser(false)
+		if err := m.stopCronJob(e); err != nil {
+			return err
+		}
+		m.scheduleNextJob(e)
+
+	case eventDelete:
+		e.cancelJobTimer()
+		if err := forEachInstance(e.listInstances(), reconcileEvent{kind: eventDelete}); err != nil {
+			e.setInstances(liveInstances(e.listInstances()))
+			return fmt.Errorf("停止进程失败: %w", err)
+		}
+		e.setInstances(nil)
+
+	case eventUpdate:
+		e.mu.Lock()
+		e.process.Metadata = ev.process.Metadata
+		e.process.Spec = ev.process.Spec
+		e.mu.Unlock()
+		m.emitEvent(e, models.EventConfigChanged, "", "process definition updated")
+		if !e.isStoppedByUser() {
+			m.scheduleNextJob(e)
+		}
+
+	case eventJobTimer:
+		if ev.gen != e.jobGen {
+			return nil
+		}
+		mp := e.snapshot()
+		if mp.Status.Job != nil && mp.Status.Job.NextScheduleTime != nil {
+			m.runScheduledJob(e, *mp.Status.Job.NextScheduleTime)
+		}
+		m.scheduleNextJob(e)
+	}
+	return nil
+}
+
+// scheduleNextJob 按时间表安排下一次运行；Suspend 时停止调度
+func (m *Manager) scheduleNextJob(e *processEntry) {
+	mp := e.snapshot()
+	spec := jobSpec(&mp)
+	schedule, err := parseCron(spec.Schedule)
+	var next time.Time
+	if err == nil && !spec.Suspend {
+		next = schedule.next(time.Now())
+	}
+	if next.IsZero() {
+		e.cancelJobTimer()
+		e.updateStatus(func(status *models.Status) {
+			status.Phase = models.PhaseStopped
+			if status.Job != nil {
+				status.Job.NextScheduleTime = nil
+			}
+		})
+		return
+	}
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = models.PhaseRunning
+		if status.Job == nil {
+			status.Job = &models.JobStatus{}
+		}
+		status.Job.NextScheduleTime = &next
+	})
+	e.startJobTimer(time.Until(next))
+}
+
+// stopCronJob 暂停调度并停止正在进行的运行
+func (m *Manager) stopCronJob(e *processEntry) error {
+	e.cancelJobTimer()
+	var active []*processEntry
+	for _, inst := range e.listInstances() {
+		if isActivePhase(inst.snapshot().Status.Phase) {
+			active = append(active, inst)
+		}
+	}
+	err := forEachInstance(active, reconcileEvent{kind: eventStop})
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = models.PhaseStopped
+		if status.Job != nil {
+			status.Job.NextScheduleTime = nil
+		}
+	})
+	return err
+}
+
+// runScheduledJob 按 ConcurrencyPolicy 开始一次定时运行，并删除已经结束的实例（仅在 reconcile 协程中调用）
+func (m *Manager) runScheduledJob(e *processEntry, at time.Time) {
+	mp := e.snapshot()
+	spec := jobSpec(&mp)
+
+	var active, finished []*processEntry
+	for _, inst := range e.listInstances() {
+		if isActivePhase(inst.snapshot().Status.Phase) {
+			active = append(active, inst)
+		} else {
+			finished = append(finished, inst)
+		}
+	}
+	deleteInstances(finished)
+	e.setInstances(active)
+
+	if len(active) > 0 {
+		switch spec.ConcurrencyPolicy {
+		case models.ConcurrencyForbid:
+			m.emitEvent(e, models.EventScheduled, "ConcurrencyForbid",
+				fmt.Sprintf("skipped: %d run(s) still active", len(active)))
+			return
+		case models.ConcurrencyReplace:
+			if err := forEachInstance(active, reconcileEvent{kind: eventDelete}); err != nil {
+				log.Printf("Failed to replace running job %s: %v", e.key, err)
+			}
+			active = liveInstances(active)
+			e.setInstances(active)
+		}
+	}
+
+	inst := m.newJobInstance(e, &mp, at)
+	e.setInstances(append(e.listInstances(), inst))
+	e.updateStatus(func(status *models.Status) {
+		if status.Job == nil {
+			status.Job = &models.JobStatus{}
+		}
+		status.Job.LastScheduleTime = &at
+	})
+	m.emitEvent(e, models.EventScheduled, "", fmt.Sprintf("started %s", inst.snapshot().Metadata.Name))
+	if err := inst.do(reconcileEvent{kind: eventStart}); err != nil {
+		log.Printf("Failed to start scheduled job %s: %v", inst.key, err)
+	}
+}
+
+// newJobInstance 为一次定时运行创建实例，实例名为 <name>-<调度时间的 Unix 秒>，
+// 同一秒内的多次运行（@every 小于 1 秒）追加调度序号。实例不持久化，输出默认写入定时 Job 的日志文件
+func (m *Manager) newJobInstance(e *processEntry, mp *models.ManagedProcess, at time.Time) *processEntry {
+	e.jobSeq++
+	name := fmt.Sprintf("%s-%d", mp.Metadata.Name, at.Unix())
+	if at.Unix() == e.jobLastUnix {
+		name = fmt.Sprintf("%s-%d", name, e.jobSeq)
+	}
+	e.jobLastUnix = at.Unix()
+
+	process := models.ManagedProcess{Kind: models.KindJob, Metadata: mp.Metadata, Spec: mp.Spec}
+	process.Metadata.Name = name
+	process.Metadata.ID = ""
+	job := *mp.Spec.Job
+	job.Schedule = ""
+	process.Spec.Job = &job
+	if process.Spec.Log.StdoutFile == "" {
+		process.Spec.Log.StdoutFile = mp.Metadata.Name + ".stdout.log"
+	}
+	if process.Spec.Log.StderrFile == "" {
+		process.Spec.Log.StderrFile = mp.Metadata.Name + ".stderr.log"
+	}
+	process.Status.Phase = models.PhaseStopped
+
+	inst := newProcessEntry(process)
+	inst.parent = mp.Metadata.Name
+	inst.index = e.jobSeq
+	go m.reconcile(inst)
+	return inst
+}
+
+// cronJobStatus 列出定时 Job 的实例并统计正在进行的运行（调用方持有 e.mu）
+func cronJobStatus(status *models.Status, instances []*processEntry) {
+	status.Instances = make([]models.InstanceStatus, 0, len(instances))
+	active := 0
+	for _, inst := range instances {
+		mp := inst.snapshot()
+		status.Instances = append(status.Instances, models.InstanceStatus{Index: inst.index, Name: mp.Metadata.Name, Status: mp.Status})
+		if isActivePhase(mp.Status.Phase) {
+			active++
+		}
+	}
+	if status.Job == nil {
+		status.Job = &models.JobStatus{}
+	}
+	status.Job.Active = active
+}
+
+// checkJobUpdate 已有进程不能在服务、Job 和定时 Job 之间切换
+func checkJobUpdate(current, desired *models.ManagedProcess) error {
+	if isJob(current) != isJob(desired) || isCronJob(current) != isCronJob(desired) {
+		return fmt.Errorf("%w: kind and job.schedule cannot be added to or removed from an existing process", ErrInvalidProcess)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2025, time.March, 14, 10, 7, 30, 0, time.UTC) // 周五
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"*/15 * * * *", "2025-03-14 10:15"},
+		{"0 3 * * *", "2025-03-15 03:00"},
+		{"30 9 1 * *", "2025-04-01 09:30"},
+		{"0 0 * * MON", "2025-03-17 00:00"},
+		{"0 12 * * 7", "2025-03-16 12:00"},
+		{"0 0 13 * 1", "2025-03-17 00:00"},
+		{"0 0 */2 * 1", "2025-03-17 00:00"},
+		{"0 0 1 jan *", "2026-01-01 00:00"},
+		{"5-10/5 * * * *", "2025-03-14 10:10"},
+		{"@hourly", "2025-03-14 11:00"},
+		{"@every 90s", "2025-03-14 10:09"},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.expr, err)
+		}
+		if got := s.next(base).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%q: next = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	if s, _ := parseCron("0 0 30 2 *"); !s.next(base).IsZero() {
+		t.Errorf("February 30th should never be scheduled")
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * MON-", "*/0 * * * *", "@every -1s", "5-1 * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) accepted an invalid schedule", expr)
+		}
+	}
+}
+
+func TestValidateJob(t *testing.T) {
+	bad := map[string]func(p *models.ManagedProcess){
+		"unknown kind":     func(p *models.ManagedProcess) { p.Kind = "CronJob" },
+		"job on a service": func(p *models.ManagedProcess) { p.Kind = ""; p.Spec.Job = &models.JobSpec{} },
+		"replicas":         func(p *models.ManagedProcess) { n := 2; p.Spec.Replicas = &n },
+		"notify":           func(p *models.ManagedProcess) { p.Spec.Notify = &models.NotifyConfig{} },
+		"bad schedule":     func(p *models.ManagedProcess) { p.Spec.Job.Schedule = "every day" },
+		"bad policy":       func(p *models.ManagedProcess) { p.Spec.Job.ConcurrencyPolicy = "Queue" },
+		"negative":         func(p *models.ManagedProcess) { n := -1; p.Spec.Job.BackoffLimit = &n },
+	}
+	for name, mutate := range bad {
+		p := testProcess(t.TempDir(), "job")
+		p.Kind = models.KindJob
+		p.Spec.Job = &models.JobSpec{Schedule: "@daily"}
+		mutate(&p)
+		if err := ValidateProcess(&p); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestManagerJob(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	// 每次运行输出序号，成功完成 3 次
+	ok := testProcess(dir, "ok")
+	ok.Kind = models.KindJob
+	ok.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `n=$(($(cat count 2>/dev/null || echo 0) + 1)); echo $n > count; echo "run $n"`}}
+	ok.Spec.Job = &models.JobSpec{Completions: 3}
+	if err := m.CreateProcess(ok); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "ok"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status := waitForPhase(t, m, "ok", models.PhaseSucceeded)
+	if job := status.Status.Job; job == nil || job.Succeeded != 3 || job.Failed != 0 || job.CompletionTime == nil {
+		t.Fatalf("job status = %+v, want 3 succeeded", job)
+	}
+	runs, err := m.ListJobRuns("test", "ok", 0)
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("ListJobRuns = %d runs, %v, want 3", len(runs), err)
+	}
+	for i, run := range runs {
+		if !run.Succeeded || run.Attempt != i+1 || run.ExitCode != 0 || run.Stdout != fmt.Sprintf("run %d\n", i+1) {
+			t.Errorf("run %d = %+v", i, run)
+		}
+	}
+	events, _ := m.ListEvents("test", "ok", 0, 0)
+	if got := fmt.Sprint(eventTypes(events)); !strings.HasSuffix(got, "Completed]") {
+		t.Errorf("events = %s, want Completed last", got)
+	}
+
+	// 超过 backoff_limit 后失败
+	flaky := testProcess(dir, "flaky")
+	flaky.Kind = models.KindJob
+	flaky.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "echo boom >&2; exit 3"}}
+	flaky.Spec.RestartInterval = 50 * time.Millisecond
+	limit := 1
+	flaky.Spec.Job = &models.JobSpec{BackoffLimit: &limit}
+	if err := m.CreateProcess(flaky); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "flaky"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status = waitForPhase(t, m, "flaky", models.PhaseFailed)
+	if cond := status.Status.GetCondition(models.ConditionTypeReady); cond == nil || cond.Reason != "BackoffLimitExceeded" {
+		t.Fatalf("condition = %+v, want BackoffLimitExceeded", cond)
+	}
+	runs, _ = m.ListJobRuns("test", "flaky", 0)
+	if len(runs) != 2 || runs[1].Succeeded || runs[1].ExitCode != 3 || runs[1].Stderr != "boom\n" {
+		t.Fatalf("runs = %+v, want 2 failed runs with exit code 3", runs)
+	}
+
+	// 超过 active_deadline 时停止并失败
+	slow := testProcess(dir, "slow")
+	slow.Kind = models.KindJob
+	slow.Spec.Job = &models.JobSpec{ActiveDeadline: 300 * time.Millisecond}
+	if err := m.CreateProcess(slow); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "slow"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status = waitForPhase(t, m, "slow", models.PhaseFailed)
+	if cond := status.Status.GetCondition(models.ConditionTypeReady); cond == nil || cond.Reason != "DeadlineExceeded" {
+		t.Fatalf("condition = %+v, want DeadlineExceeded", cond)
+	}
+	runs, _ = m.ListJobRuns("test", "slow", 0)
+	if len(runs) != 1 || runs[0].Succeeded || runs[0].Reason != "DeadlineExceeded" {
+		t.Fatalf("runs = %+v, want one run stopped by the deadline", runs)
+	}
+}
+
+func TestManagerCronJob(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	tick := testProcess(dir, "tick")
+	tick.Kind = models.KindJob
+	tick.Spec.Exec = models.Exec{Command: "echo", Args: []string{"tick"}}
+	tick.Spec.Job = &models.JobSpec{Schedule: "@every 200ms"}
+	if err := m.CreateProcess(tick); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "tick"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "tick")
+	if status.Status.Phase != models.PhaseRunning || status.Status.Job == nil || status.Status.Job.NextScheduleTime == nil {
+		t.Fatalf("after start: %s %+v, want Running with a next schedule time", status.Status.Phase, status.Status.Job)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	var runs []models.JobRun
+	for len(runs) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("cron job ran %d times, want at least 2", len(runs))
+		}
+		time.Sleep(50 * time.Millisecond)
+		runs, _ = m.ListJobRuns("test", "tick", 0)
+	}
+	if !runs[0].Succeeded || runs[0].Stdout != "tick\n" || !strings.HasPrefix(runs[0].Instance, "tick-") || runs[0].Instance == runs[1].Instance {
+		t.Fatalf("runs = %+v, want successful runs of separate instances", runs)
+	}
+	if err := m.StopProcess("test", "tick"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if status, _ = m.GetProcessStatus("test", "tick"); status.Status.Phase != models.PhaseStopped {
+		t.Fatalf("after stop: %s, want Stopped", status.Status.Phase)
+	}
+
+	// Forbid：上一次运行未结束时跳过
+	busy := testProcess(dir, "busy")
+	busy.Kind = models.KindJob
+	busy.Spec.Job = &models.JobSpec{Schedule: "@every 150ms", ConcurrencyPolicy: models.ConcurrencyForbid}
+	if err := m.CreateProcess(busy); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "busy"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	time.Sleep(700 * time.Millisecond)
+	status, _ = m.GetProcessStatus("test", "busy")
+	if len(status.Status.Instances) != 1 || status.Status.Job.Active != 1 {
+		t.Fatalf("instances = %+v, want a single active run", status.Status.Instances)
+	}
+	events, _ := m.ListEvents("test", "busy", 0, 0)
+	forbidden := false
+	for _, ev := range events {
+		forbidden = forbidden || (ev.Type == models.EventScheduled && ev.Reason == "ConcurrencyForbid")
+	}
+	if !forbidden {
+		t.Fatalf("events = %v, want a skipped schedule", eventTypes(events))
+	}
+	pid := status.Status.Instances[0].PID
+	if err := m.DeleteProcess("test", "busy"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+	if pidAlive(pid) {
+		t.Fatalf("run %d is still alive after the cron job was deleted", pid)
+	}
+}
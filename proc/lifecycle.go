@@ -0,0 +1,134 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// 生命周期钩子名称，用于日志和错误信息
+const (
+	hookPreStart = "pre_start"
+	hookPostStop = "post_stop"
+)
+
+// defaultHookTimeout 钩子未配置超时时的默认超时时间
+const defaultHookTimeout = 30 * time.Second
+
+// runPreStart 执行 PreStart 钩子，失败或超时返回错误，调用方应中止启动
+func (m *Manager) runPreStart(mp *models.ManagedProcess) error {
+	if mp.Spec.Lifecycle == nil || mp.Spec.Lifecycle.PreStart == nil {
+		return nil
+	}
+	return runLifecycleHook(mp, hookPreStart, mp.Spec.Lifecycle.PreStart)
+}
+
+// runPostStop 执行 PostStop 钩子，失败只记录日志，不影响后续停止或重启流程
+func (m *Manager) runPostStop(mp *models.ManagedProcess) {
+	if mp.Spec.Lifecycle == nil || mp.Spec.Lifecycle.PostStop == nil {
+		return
+	}
+	if err := runLifecycleHook(mp, hookPostStop, mp.Spec.Lifecycle.PostStop); err != nil {
+		log.Printf("Process %s/%s %v", mp.Metadata.Namespace, mp.Metadata.Name, err)
+	}
+}
+
+// runLifecycleHook 在进程的环境变量和工作目录下执行钩子命令，输出追加到进程日志目录下的 <name>.lifecycle.log
+func runLifecycleHook(mp *models.ManagedProcess, hook string, cc *models.CommandConfig) error {
+	if cc.Command == "" {
+		return fmt.Errorf("%s hook: command is empty", hook)
+	}
+
+	timeout := cc.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	runAs, err := resolveRunAsUser(&mp.Spec)
+	if err != nil {
+		return fmt.Errorf("%s hook: %v", hook, err)
+	}
+
+	cmd := exec.CommandContext(ctx, cc.Command, cc.Args...)
+	cmd.Env = processEnv(mp, runAs)
+	cmd.Dir = processWorkingDir(mp)
+	if err := applyCredential(cmd, runAs); err != nil {
+		return fmt.Errorf("%s hook: %v", hook, err)
+	}
+
+	logDir := processLogDir(mp)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return fmt.Errorf("%s hook: failed to create log dir: %v", hook, err)
+	}
+	logFile, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("%s.lifecycle.log", mp.Metadata.Name)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("%s hook: failed to open log file: %v", hook, err)
+	}
+	defer logFile.Close()
+	runAs.chown(logFile.Name())
+
+	fmt.Fprintf(logFile, "[%s] %s: %s\n", time.Now().Format(time.RFC3339), hook, strings.TrimSpace(cc.Command+" "+strings.Join(cc.Args, " ")))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		fmt.Fprintf(logFile, "[%s] %s failed: %v\n", time.Now().Format(time.RFC3339), hook, err)
+		return fmt.Errorf("%s hook failed: %v", hook, err)
+	}
+	return nil
+}
+
+// processEnv 返回进程运行时的环境变量：继承当前环境，追加运行用户的 HOME/USER/LOGNAME 和 Spec.Env
+func processEnv(mp *models.ManagedProcess, runAs *runAsUser) []string {
+	env := append(os.Environ(), runAs.env()...)
+	for _, envVar := range mp.Spec.Env {
+		env = append(env, fmt.Sprintf("%s=%s", envVar.Name, envVar.Value))
+	}
+	return env
+}
+
+// processWorkingDir 返回进程的工作目录，未配置时使用当前目录
+func processWorkingDir(mp *models.ManagedProcess) string {
+	if mp.Spec.WorkingDir != "" {
+		return mp.Spec.WorkingDir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+// processLogDir 返回进程的日志目录，未配置时与 StartProcess 一致使用工作目录
+func processLogDir(mp *models.ManagedProcess) string {
+	if mp.Spec.Log.Dir != "" {
+		return mp.Spec.Log.Dir
+	}
+	return processWorkingDir(mp)
+}
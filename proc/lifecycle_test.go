@@ -0,0 +1,124 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestManagerPreStartFailure(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	p := testProcess(dir, "app")
+	p.Spec.Lifecycle = &models.Lifecycle{
+		PreStart: &models.CommandConfig{Command: "sh", Args: []string{"-c", "echo migration failed; exit 1"}},
+	}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	err := m.StartProcess("test", "app")
+	if err == nil || !strings.Contains(err.Error(), "pre_start hook failed") {
+		t.Fatalf("StartProcess = %v, want pre_start hook failure", err)
+	}
+
+	mp, _ := m.GetProcessStatus("test", "app")
+	if mp.Status.Phase != models.PhaseFailed || mp.Status.PID != 0 {
+		t.Fatalf("status = %s pid %d, want Failed without a process", mp.Status.Phase, mp.Status.PID)
+	}
+	if c := mp.Status.GetCondition(models.ConditionTypeReady); c == nil || c.Status != models.ConditionFalse || c.Reason != "PreStartFailed" {
+		t.Fatalf("Ready condition = %+v, want False/PreStartFailed", c)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "app.lifecycle.log"))
+	if !strings.Contains(string(data), "migration failed") || !strings.Contains(string(data), "pre_start failed") {
+		t.Fatalf("lifecycle log = %q", data)
+	}
+}
+
+func TestManagerPreStartTimeout(t *testing.T) {
+	m := newTestManager(t)
+	p := testProcess(t.TempDir(), "app")
+	p.Spec.Lifecycle = &models.Lifecycle{
+		PreStart: &models.CommandConfig{Command: "sleep", Args: []string{"10"}, Timeout: 200 * time.Millisecond},
+	}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	start := time.Now()
+	err := m.StartProcess("test", "app")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Fatalf("StartProcess = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("StartProcess took %v, the hook was not killed at its timeout", elapsed)
+	}
+	if mp, _ := m.GetProcessStatus("test", "app"); mp.Status.Phase != models.PhaseFailed {
+		t.Fatalf("phase = %s, want Failed", mp.Status.Phase)
+	}
+}
+
+func TestManagerPostStop(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "post_stop.out")
+	postStop := &models.CommandConfig{Command: "sh", Args: []string{"-c", `echo "$HOOK_NAME" >> post_stop.out`}}
+
+	// 用户停止
+	p := testProcess(dir, "stopped")
+	p.Spec.Env = []models.EnvVar{{Name: "HOOK_NAME", Value: "stopped"}}
+	p.Spec.Lifecycle = &models.Lifecycle{PostStop: postStop}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "stopped"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if err := m.StopProcess("test", "stopped"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if got := waitForFile(t, out); got != "stopped" {
+		t.Fatalf("post_stop.out = %q after stop", got)
+	}
+
+	// 进程崩溃退出
+	c := testProcess(dir, "crashed")
+	c.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "exit 3"}}
+	c.Spec.Env = []models.EnvVar{{Name: "HOOK_NAME", Value: "crashed"}}
+	c.Spec.Lifecycle = &models.Lifecycle{PostStop: postStop}
+	if err := m.CreateProcess(c); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "crashed"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if string(data) == "stopped\ncrashed\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("post_stop.out = %q, want the hook to run after the crash", data)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
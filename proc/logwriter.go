@@ -0,0 +1,432 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// rotatedTimeFormat 是轮转文件名中的时间后缀，按字典序即时间顺序
+const rotatedTimeFormat = "20060102-150405.000"
+
+// logTimestampFormat 是日志行时间戳前缀的格式
+const logTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// rotatingWriter 把进程输出写入日志文件，按大小或时间轮转。
+// 轮转时重命名当前文件并重新打开，子进程写的是 vigil 持有的管道，不需要 copytruncate。
+type rotatingWriter struct {
+	mu   sync.Mutex
+	path string
+
+	maxSize        int64
+	rotateInterval time.Duration
+	maxFiles       int
+	maxAge         time.Duration
+	compress       bool
+	owner          *runAsUser
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+}
+
+// newRotatingWriter 打开（追加）日志文件
+func newRotatingWriter(path string, cfg models.LogConfig, owner *runAsUser) (*rotatingWriter, error) {
+	maxSize, err := logMaxSize(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &rotatingWriter{
+		path:           path,
+		maxSize:        maxSize,
+		rotateInterval: cfg.RotateInterval,
+		maxFiles:       cfg.MaxFiles,
+		maxAge:         cfg.MaxAge,
+		compress:       cfg.Compress,
+		owner:          owner,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// logMaxSize 解析 LogConfig.MaxSize，未配置时返回 0
+func logMaxSize(cfg models.LogConfig) (int64, error) {
+	if cfg.MaxSize == "" {
+		return 0, nil
+	}
+	size, err := ParseQuantity(cfg.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("log.max_size: %v", err)
+	}
+	return size, nil
+}
+
+// validateLogConfig 校验日志轮转配置
+func validateLogConfig(cfg models.LogConfig) error {
+	if _, err := logMaxSize(cfg); err != nil {
+		return err
+	}
+	if cfg.MaxFiles < 0 || cfg.MaxAge < 0 || cfg.RotateInterval < 0 {
+		return fmt.Errorf("log: max_files, max_age and rotate_interval must not be negative")
+	}
+	return nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	// 日志文件由 vigil 创建，交给运行用户
+	w.owner.chown(w.path)
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	if w.size > 0 {
+		w.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write 写入日志，写入前检查是否需要轮转
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file != nil && w.size > 0 && ((w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize) ||
+		(w.rotateInterval > 0 && time.Since(w.openedAt) >= w.rotateInterval)) {
+		if err := w.rotate(); err != nil {
+			log.Printf("Warning: failed to rotate log %s: %v", w.path, err)
+		}
+	}
+	if w.file == nil {
+		// 轮转后未能重新打开文件（如磁盘已满），每次写入时重试
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate 把当前文件重命名为带时间后缀的文件并重新打开，随后异步压缩和清理旧文件
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	rotated := w.path + "." + time.Now().Format(rotatedTimeFormat)
+	if err := os.Rename(w.path, rotated); err != nil {
+		// 重命名失败时继续写原文件
+		if openErr := w.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	go w.cleanup(rotated)
+	return nil
+}
+
+// cleanup 压缩刚轮转的文件，并按 MaxFiles 和 MaxAge 删除旧文件
+func (w *rotatingWriter) cleanup(rotated string) {
+	if w.compress {
+		if err := compressFile(rotated); err != nil {
+			log.Printf("Warning: failed to compress log %s: %v", rotated, err)
+		}
+	}
+
+	files, err := w.rotatedFiles()
+	if err != nil {
+		return
+	}
+	// 新的在前
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	for i, f := range files {
+		remove := w.maxFiles > 0 && i >= w.maxFiles
+		if !remove && w.maxAge > 0 {
+			if info, err := os.Stat(f); err == nil && time.Since(info.ModTime()) > w.maxAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				log.Printf("Warning: failed to remove old log %s: %v", f, err)
+			}
+		}
+	}
+}
+
+// rotatedFiles 返回当前日志的所有轮转文件（包括压缩的）
+func (w *rotatingWriter) rotatedFiles() ([]string, error) {
+	matches, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, w.path+"."), ".gz")
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			files = append(files, m)
+		}
+	}
+	return files, nil
+}
+
+// Close 关闭日志文件
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// compressFile 把文件压缩为 .gz 并删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	if info, err := src.Stat(); err == nil {
+		os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	}
+	return os.Remove(path)
+}
+
+// timestampWriter 在每行开头添加时间戳
+type timestampWriter struct {
+	w           io.Writer
+	atLineStart bool
+	now         func() time.Time
+}
+
+func newTimestampWriter(w io.Writer) *timestampWriter {
+	return &timestampWriter{w: w, atLineStart: true, now: time.Now}
+}
+
+// Write 逐行写入，保证同一行的时间戳与内容在一次写入中完成
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		if t.atLineStart {
+			buf.WriteString(t.now().Format(logTimestampFormat))
+			buf.WriteByte(' ')
+		}
+		buf.Write(line)
+		t.atLineStart = line[len(line)-1] == '\n'
+	}
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// bestEffortWriter 吞掉写入错误，避免日志文件或 attach 写入失败中断管道复制，使子进程收到 SIGPIPE。
+// 连续失败只记录一次警告，写入恢复后再次失败会重新记录
+type bestEffortWriter struct {
+	w      io.Writer
+	name   string
+	failed bool
+}
+
+func (b *bestEffortWriter) Write(p []byte) (int, error) {
+	if _, err := b.w.Write(p); err != nil {
+		if !b.failed {
+			log.Printf("Warning: failed to write process output to %s: %v", b.name, err)
+		}
+		b.failed = true
+	} else {
+		b.failed = false
+	}
+	return len(p), nil
+}
+
+// outputTailSize 是每路输出在内存中保留的最近内容的大小
+const outputTailSize = 64 * 1024
+
+// tailBuffer 保留最近写入的 size 字节
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	data []byte
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(p) >= b.size {
+		b.data = append(b.data[:0], p[len(p)-b.size:]...)
+		return len(p), nil
+	}
+	if over := len(b.data) + len(p) - b.size; over > 0 {
+		b.data = append(b.data[:0], b.data[over:]...)
+	}
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+// last 返回最近的至多 n 字节
+func (b *tailBuffer) last(n int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > 0 && len(b.data) > n {
+		return string(b.data[len(b.data)-n:])
+	}
+	return string(b.data)
+}
+
+// processOutput 是子进程一路输出（stdout 或 stderr）：子进程写管道，vigil 读出后写入日志文件
+type processOutput struct {
+	// childEnd 交给子进程，启动后由 vigil 关闭
+	childEnd *os.File
+	reader   *os.File
+	writer   *rotatingWriter
+	// tail 保留最近的输出（不带时间戳）
+	tail *tailBuffer
+	// attach 把输出分发给 attach 会话（可选）
+	attach io.Writer
+	// done 在管道的所有写端关闭、输出复制完成后关闭
+	done chan struct{}
+}
+
+// newProcessOutput 打开日志文件并创建管道，在内存中保留最近 tailSize 字节的输出
+func newProcessOutput(path string, cfg models.LogConfig, owner *runAsUser, tailSize int) (*processOutput, error) {
+	writer, err := newRotatingWriter(path, cfg, owner)
+	if err != nil {
+		return nil, err
+	}
+	r, wr, err := os.Pipe()
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return &processOutput{childEnd: wr, reader: r, writer: writer, tail: newTailBuffer(tailSize), done: make(chan struct{})}, nil
+}
+
+// start 在子进程启动后关闭本端的写端，并开始把管道内容写入日志，直到所有写端关闭
+func (o *processOutput) start(timestamps bool) {
+	o.childEnd.Close()
+	var dst io.Writer = o.writer
+	if timestamps {
+		dst = newTimestampWriter(o.writer)
+	}
+	// 日志和 attach 的写入错误不中断复制，管道一直读到 EOF
+	writers := []io.Writer{&bestEffortWriter{w: dst, name: o.writer.path}, o.tail}
+	if o.attach != nil {
+		writers = append(writers, &bestEffortWriter{w: o.attach, name: "attach sessions"})
+	}
+	go func() {
+		defer close(o.done)
+		if _, err := io.Copy(io.MultiWriter(writers...), o.reader); err != nil {
+			log.Printf("Warning: failed to copy process output to %s: %v", o.writer.path, err)
+		}
+		o.reader.Close()
+		o.writer.Close()
+	}()
+}
+
+// wait 等待输出复制完成，返回是否在 timeout 内完成；子进程的后代仍持有管道时会超时
+func (o *processOutput) wait(timeout time.Duration) bool {
+	select {
+	case <-o.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// close 子进程未能启动时释放资源
+func (o *processOutput) close() {
+	o.childEnd.Close()
+	o.reader.Close()
+	o.writer.Close()
+}
+
+// processLogPath 返回 stdout/stderr 日志文件路径，StdoutFile/StderrFile 为相对路径时基于日志目录
+func processLogPath(logDir, name, file, stream string) string {
+	if file == "" {
+		return filepath.Join(logDir, fmt.Sprintf("%s.%s.log", name, stream))
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(logDir, file)
+}
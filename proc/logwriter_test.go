@@ -0,0 +1,176 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.stdout.log")
+	w, err := newRotatingWriter(path, models.LogConfig{MaxSize: "10", MaxFiles: 2, Compress: true}, nil)
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456789")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		// 轮转文件名精确到毫秒
+		time.Sleep(2 * time.Millisecond)
+	}
+	w.Close()
+
+	// 压缩和清理是异步的
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		files, _ := w.rotatedFiles()
+		compressed := 0
+		for _, f := range files {
+			if strings.HasSuffix(f, ".gz") {
+				compressed++
+			}
+		}
+		if len(files) == 2 && compressed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated files = %v, want 2 compressed files", files)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("current log = %q, %v; want the last write only", data, err)
+	}
+}
+
+func TestRotatingWriterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.stdout.log")
+	w, err := newRotatingWriter(path, models.LogConfig{}, nil)
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	w.Write([]byte("a\n"))
+	// 模拟轮转后重新打开失败
+	w.file.Close()
+	w.file = nil
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatalf("Write after failed reopen: %v", err)
+	}
+	w.Close()
+	if _, err := w.Write([]byte("c\n")); err == nil {
+		t.Fatal("Write after Close succeeded")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\n" {
+		t.Fatalf("log = %q, want a and b", data)
+	}
+}
+
+func TestTimestampWriter(t *testing.T) {
+	var sb strings.Builder
+	w := newTimestampWriter(&sb)
+	w.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	w.Write([]byte("hello\nwor"))
+	w.Write([]byte("ld\n"))
+
+	want := "2025-01-02T03:04:05.000000Z hello\n2025-01-02T03:04:05.000000Z world\n"
+	if sb.String() != want {
+		t.Fatalf("output = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestManagerProcessOutput(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "echo")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "echo out; echo err >&2"}}
+	p.Spec.Log.StderrFile = "errors/echo.log"
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "echo"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	// 输出经管道异步写入日志文件
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stdout, _ := os.ReadFile(filepath.Join(dir, "echo.stdout.log"))
+		stderr, _ := os.ReadFile(filepath.Join(dir, "errors", "echo.log"))
+		if string(stdout) == "out\n" && string(stderr) == "err\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stdout = %q, stderr = %q; want out and err", stdout, stderr)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := m.DeleteProcess("test", "echo"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
+
+func TestManagerProcessOutputWriteError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+	m := newTestManager(t)
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+
+	// 写入 /dev/full 总是返回 ENOSPC，子进程不应因此收到 SIGPIPE
+	p := testProcess(dir, "full")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c",
+		"i=0; while :; do i=$((i+1)); echo line $i; echo $i > " + count + "; sleep 0.05; done"}}
+	p.Spec.Log.StdoutFile = "/dev/full"
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "full"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "full")
+	pid := status.Status.PID
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if n, _ := strconv.Atoi(waitForFile(t, count)); n >= 20 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("process stopped writing output")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	status, _ = m.GetProcessStatus("test", "full")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID != pid {
+		t.Fatalf("phase = %s, pid = %d; want still running as %d", status.Status.Phase, status.Status.PID, pid)
+	}
+	if err := m.DeleteProcess("test", "full"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
@@ -25,6 +25,7 @@ import (
   "os/exec"
   "strconv"
   "strings"
+  "sync"
   "time"
 
   "github.com/casuallc/vigil/config"
@@ -34,48 +35,102 @@ import (
 // NewManager 创建一个新的进程管理器
 func NewManager() *Manager {
   return &Manager{
-    Processes:         make(map[string]*models.ManagedProcess),
-    monitoringStarted: make(map[string]bool),
+    entries: make(map[string]*processEntry),
+    events:  newEventHub(),
   }
 }
 
 // Manager 实现了所有进程管理相关的接口
-// 它实现了 ProcessScanner, ProcessLifecycle, ProcessInfo, ProcessConfig 和 ProcessMonitor 接口
+// 它实现了 ProcessScanner, ProcessLifecycle, ProcessInfo, ProcessConfig 和 ProcessMonitor 接口。
+// 每个纳管进程由自己的 reconcile 协程串行处理状态转换，资源采样和重关联检查由共享的采样器完成。
 type Manager struct {
-  Processes map[string]*models.ManagedProcess
-  // 监控协程启动标记，避免重复启动
-  monitoringStarted map[string]bool
+  // mu 保护 entries
+  mu      sync.RWMutex
+  entries map[string]*processEntry
   // 进程存储
   store *ProcessStore
+  // saveMu 保证持久化按顺序进行
+  saveMu sync.Mutex
+  // samplerOnce 保证共享采样器只启动一次
+  samplerOnce sync.Once
+  // pidDir 是进程 PID 文件的目录，为空时不写 PID 文件
+  pidDir string
+  // sampleMu 串行化采样，看门狗状态只在采样中访问
+  sampleMu sync.Mutex
+  // depMu 串行化依赖检查与创建/修改，避免并发操作形成依赖环
+  depMu sync.Mutex
+  // events 记录进程生命周期事件并分发给订阅者
+  events *eventHub
+  // secretKey 是加密密钥的 security.encryption_key
+  secretKey string
+  // jobRuns 是未配置存储时内存中的 Job 运行记录
+  jobRunsMu sync.Mutex
+  jobRuns   []models.JobRun
+  jobRunSeq int64
+  // rollouts 是滚动重启的记录（按创建顺序），只保存在内存中
+  rolloutMu  sync.Mutex
+  rollouts   []*rollout
+  rolloutSeq int64
+  // watchHook 在监视的文件变化触发动作后调用
+  watchHook func(models.WatchTrigger)
+  // crashDir 是崩溃报告的目录，为空时不收集崩溃报告
+  crashDir string
 }
 
 // SetStore 设置进程存储
 func (m *Manager) SetStore(store *ProcessStore) {
   m.store = store
+  m.startEventWriter()
 }
 
 // SaveManagedProcesses 保存所有已管理的进程
 func (m *Manager) SaveManagedProcesses(filePath string) error {
+  m.saveMu.Lock()
+  defer m.saveMu.Unlock()
+
+  processes := make([]models.ManagedProcess, 0)
+  for _, e := range m.listEntries() {
+    processes = append(processes, e.snapshot())
+  }
+
   // 如果使用 SQLite 存储，忽略 filePath 参数
   if m.store != nil {
-    return m.store.SaveManagedProcesses(m.Processes)
+    return m.store.SaveManagedProcesses(processes)
   }
   // 否则使用文件存储（向后兼容）
-  return saveProcessesToFile(m.Processes, filePath)
+  return saveProcessesToFile(processes, filePath)
+}
+
+// saveProcess 持久化单个进程的变更；使用文件存储时只能整体保存
+func (m *Manager) saveProcess(process models.ManagedProcess) error {
+  if m.store != nil {
+    return m.store.SaveManagedProcess(process)
+  }
+  return m.SaveManagedProcesses(ProcessesFilePath)
+}
+
+// forgetProcess 从持久化存储中删除进程
+func (m *Manager) forgetProcess(namespace, name string) error {
+  if m.store != nil {
+    return m.store.DeleteManagedProcess(namespace, name)
+  }
+  return m.SaveManagedProcesses(ProcessesFilePath)
 }
 
 // saveProcessesToFile 保存进程到文件（向后兼容）
-func saveProcessesToFile(processes map[string]*models.ManagedProcess, filePath string) error {
+func saveProcessesToFile(processes []models.ManagedProcess, filePath string) error {
   processesList := make([]models.ManagedProcess, 0, len(processes))
 
   // 过滤掉运行时的状态信息，只保存配置相关信息
-  for _, p := range processes {
-    processCopy := *p
+  for _, processCopy := range processes {
     // 重置运行时状态
     processCopy.Status.Phase = models.PhaseFailed
     processCopy.Status.PID = 0
     processCopy.Status.StartTime = &time.Time{}
     processCopy.Status.ResourceStats = nil
+    processCopy.Status.NextRetryTime = nil
+    processCopy.Status.BackoffCount = 0
+    processCopy.Status.Conditions = nil
 
     processesList = append(processesList, processCopy)
   }
@@ -96,29 +151,34 @@ func saveProcessesToFile(processes map[string]*models.ManagedProcess, filePath s
   return os.WriteFile(filePath, []byte(data), 0644)
 }
 
-// GetProcesses 获取所有进程的映射
+// GetProcesses 获取所有进程的映射，返回的是副本，修改不会影响纳管的进程
 func (m *Manager) GetProcesses() map[string]*models.ManagedProcess {
-  return m.Processes
+  result := make(map[string]*models.ManagedProcess)
+  for _, e := range m.listEntries() {
+    p := e.snapshot()
+    result[e.key] = &p
+  }
+  return result
 }
 
 // GetProcessStatus 获取进程状态
 func (m *Manager) GetProcessStatus(namespace, name string) (models.ManagedProcess, error) {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  managedProcess, exists := m.Processes[key]
+  e, exists := m.getEntry(namespace, name)
   if !exists {
     return models.ManagedProcess{}, fmt.Errorf("process %s/%s is not managed", namespace, name)
   }
-  return *managedProcess, nil
+  return e.snapshot(), nil
 }
 
 // ListManagedProcesses 获取所有已管理的进程
 func (m *Manager) ListManagedProcesses(namespace string) ([]models.ManagedProcess, error) {
   result := make([]models.ManagedProcess, 0)
 
-  for _, p := range m.Processes {
+  for _, e := range m.listEntries() {
+    p := e.snapshot()
     // 如果指定了namespace，则只返回该namespace的进程
     if namespace == "" || p.Metadata.Namespace == namespace {
-      result = append(result, *p)
+      result = append(result, p)
     }
   }
   return result, nil
@@ -127,31 +187,41 @@ func (m *Manager) ListManagedProcesses(namespace string) ([]models.ManagedProces
 // MonitorProcess 监控进程资源使用情况
 func (m *Manager) MonitorProcess(namespace, name string) (*models.ResourceStats, error) {
   // 检查进程是否存在
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  managedProcess, exists := m.Processes[key]
+  e, exists := m.getEntry(namespace, name)
   if !exists {
     return nil, fmt.Errorf("Process %s/%s is not managed", namespace, name)
   }
 
   // 检查进程是否正在运行
-  if managedProcess.Status.Phase != models.PhaseRunning {
+  e.mu.RLock()
+  running := e.process.Status.Phase == models.PhaseRunning
+  pid := e.process.Status.PID
+  isolated := e.process.Spec.Isolation != nil
+  cg := e.cgroup
+  e.mu.RUnlock()
+  if !running {
     return nil, fmt.Errorf("Process %s/%s is not running", namespace, name)
   }
 
-  pid := managedProcess.Status.PID
-  stats, err := GetUnixProcessResourceUsage(pid)
+  stats, err := GetUnixProcessResourceUsage(samplePID(isolated, pid))
   if err != nil {
     fmt.Printf("Warning: failed to get process resource usage: %v\n", err)
     return nil, err
   }
 
+  // 使用 cgroup 统计覆盖 CPU、内存和 IO（包含子进程）
+  if cg != nil {
+    cg.fillStats(stats)
+  }
+
   // 设置格式化的值
   stats.SetFormattedValues()
 
   // 更新进程的Stats信息
-  managedProcess.Status.ResourceStats = stats
+  e.setResourceStats(pid, stats)
 
-  return stats, nil
+  result := *stats
+  return &result, nil
 }
 
 // ScanProcesses 扫描系统进程
@@ -180,24 +250,28 @@ var ProcessesFilePath = "proc/managed_processes.yaml"
 
 // UpdateProcessConfig 实现ProcessManager接口，更新进程配置
 func (m *Manager) UpdateProcessConfig(namespace, name string, config config.AppConfig) error {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  managedProcess, exists := m.Processes[key]
+  e, exists := m.getEntry(namespace, name)
   if !exists {
     return fmt.Errorf("进程 %s/%s 未被纳管", namespace, name)
   }
 
   // 保存旧配置
-  oldConfig := managedProcess.Spec.Config
+  updated := e.snapshot()
+  oldConfig := updated.Spec.Config
 
   // 更新配置
-  managedProcess.Spec.Config = config
+  updated.Spec.Config = config
+  if err := e.do(reconcileEvent{kind: eventUpdate, process: &updated}); err != nil {
+    return err
+  }
 
   // 如果进程正在运行，需要重启来应用新配置
-  if managedProcess.Status.Phase == models.PhaseRunning {
+  if updated.Status.Phase == models.PhaseRunning {
     // 重启进程
     if err := m.RestartProcess(namespace, name); err != nil {
       // 如果重启失败，恢复旧配置
-      managedProcess.Spec.Config = oldConfig
+      updated.Spec.Config = oldConfig
+      _ = e.do(reconcileEvent{kind: eventUpdate, process: &updated})
       return err
     }
   }
@@ -205,82 +279,9 @@ func (m *Manager) UpdateProcessConfig(namespace, name string, config config.AppC
   return nil
 }
 
-// StartMonitoring 启动进程监控
+// StartMonitoring 启动进程监控。所有进程共享一个采样器，这里只需确保它已启动
 func (m *Manager) StartMonitoring(namespace, name string) {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  if m.monitoringStarted[key] {
-    return
-  }
-  m.monitoringStarted[key] = true
-  go m.startMonitoring(namespace, name)
-}
-
-// startMonitoring 开始监控进程
-func (m *Manager) startMonitoring(namespace, name string) {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  managedProcess, exists := m.Processes[key]
-  if !exists {
-    return
-  }
-
-  // 定期更新进程状态和资源使用情况
-  ticker := time.NewTicker(5 * time.Second)
-  defer ticker.Stop()
-
-  // 添加重关联检查的计时器
-  checkTicker := time.NewTicker(30 * time.Second)
-  defer checkTicker.Stop()
-
-  for {
-    select {
-    case <-ticker.C:
-      if managedProcess.Status.Phase == models.PhaseRunning {
-        // 更新资源使用情况
-        stats, err := m.MonitorProcess(namespace, name)
-        if err == nil {
-          managedProcess.Status.ResourceStats = stats
-        }
-      }
-
-    case <-checkTicker.C:
-      // 扩展：运行态与非运行态都做重关联检查
-      if managedProcess.Status.Phase == models.PhaseRunning {
-        // 运行态：校验当前 PID 是否仍对应同一逻辑进程（防 PID 复用）
-        currentPID := managedProcess.Status.PID
-        valid := false
-
-        if currentPID > 0 {
-          if pidExists, err := process.PidExists(int32(currentPID)); err == nil && pidExists {
-            if p, err := process.NewProcess(int32(currentPID)); err == nil {
-              if m.matchProcess(managedProcess, p) {
-                valid = true
-              }
-            }
-          }
-        }
-
-        if !valid {
-          // 尝试重关联到新的 PID
-          reAssociated, _ := m.tryCheckProcess(managedProcess)
-          if !reAssociated {
-            // 重关联失败，标记停止并按策略后续处理
-            managedProcess.Status.Phase = models.PhaseStopped
-            managedProcess.Status.PID = 0
-          }
-        }
-      } else {
-        // 非运行态（Failed/Stopped/Pending）：尝试发现进程是否已重新起来并重关联
-        reAssociated, _ := m.tryCheckProcess(managedProcess)
-        if reAssociated {
-          // 已自动重新关联到新 PID，状态在 tryCheckProcess 中置为 Running
-          // 可选：此处无需额外处理
-          fmt.Printf("re associated process %s status, current: %s\n", managedProcess.Metadata.Name, managedProcess.Status.Phase)
-        } else {
-          // 保持原状态（Failed/Stopped/Pending），等待下次检查或人工启动
-        }
-      }
-    }
-  }
+  m.startSampler()
 }
 
 // matchProcess 根据多个特征匹配进程，优先使用用户定义的识别脚本
@@ -457,78 +458,93 @@ func (m *Manager) updateProcessInfoFromSystem(managedProc *models.ManagedProcess
 
 // CheckProcesses 检查并重新关联可能已重启的进程
 func (m *Manager) CheckProcesses() {
+  candidates, err := process.Processes()
+  if err != nil {
+    fmt.Printf("Failed to list system processes: %v\n", err)
+    return
+  }
+  owned := m.ownedPIDs()
+
   // 遍历所有纳管的进程
-  for key, managedProc := range m.Processes {
+  for _, e := range m.runEntries() {
+    managedProc := e.snapshot()
     // 只处理应该运行但当前未运行的进程
-    if managedProc.Status.Phase != models.PhaseRunning &&
+    if managedProc.Status.Phase != models.PhaseRunning && !e.isStoppedByUser() && !isJob(&managedProc) &&
       (managedProc.Spec.RestartPolicy == models.RestartPolicyAlways ||
         managedProc.Spec.RestartPolicy == models.RestartPolicyOnFailure) {
 
       // 尝试通过标识重新发现进程
-      checked, err := m.tryCheckProcess(managedProc)
-      if err == nil && checked {
-        fmt.Printf("Successfully Checkd proc %s\n", key)
+      pid, err := m.findMatchingPID(&managedProc, candidates, owned)
+      if err == nil && pid > 0 {
+        owned[pid] = true
+        e.send(reconcileEvent{kind: eventAdopted, pid: pid})
+        fmt.Printf("Successfully Checkd proc %s\n", e.key)
       } else if err != nil {
-        fmt.Printf("Failed to Check proc %s: %v\n", key, err)
+        fmt.Printf("Failed to Check proc %s: %v\n", e.key, err)
       }
     }
   }
 }
 
-// tryCheckProcess 尝试通过进程特征检查进程状态
-func (m *Manager) tryCheckProcess(managedProc *models.ManagedProcess) (bool, error) {
+// ownedPIDs 返回已被纳管进程占用的 PID，避免多个进程重关联到同一个系统进程
+func (m *Manager) ownedPIDs() map[int]bool {
+  owned := map[int]bool{os.Getpid(): true}
+  for _, e := range m.runEntries() {
+    e.mu.RLock()
+    if pid := e.process.Status.PID; pid > 0 {
+      owned[pid] = true
+    }
+    e.mu.RUnlock()
+  }
+  return owned
+}
+
+// pidMatches 检查 PID 是否仍对应同一逻辑进程（防 PID 复用）
+func (m *Manager) pidMatches(managedProc *models.ManagedProcess, pid int) bool {
+  if pid <= 0 {
+    return false
+  }
+  if pidExists, err := process.PidExists(int32(pid)); err != nil || !pidExists {
+    return false
+  }
+  p, err := process.NewProcess(int32(pid))
+  if err != nil {
+    return false
+  }
+  return m.matchProcess(managedProc, p)
+}
+
+// findMatchingPID 通过进程特征查找系统中对应的进程，未找到时返回 0
+func (m *Manager) findMatchingPID(managedProc *models.ManagedProcess, candidates []*process.Process, owned map[int]bool) (int, error) {
   // 如果定义了进程匹配脚本，直接使用脚本获取进程ID
   if managedProc.Spec.ProcessMatcher != nil {
     pid, err := m.getMatchedPIDByScript(managedProc)
     if err != nil {
-      return false, fmt.Errorf("failed to get matched process ID: %w", err)
+      return 0, fmt.Errorf("failed to get matched process ID: %w", err)
     }
 
     // 检查进程是否存在
-    if pidExists, err := process.PidExists(int32(pid)); err != nil || !pidExists {
-      return false, nil // 进程不存在，返回false但不报错
+    if pidExists, err := process.PidExists(int32(pid)); err != nil || !pidExists || owned[pid] {
+      return 0, nil // 进程不存在，返回0但不报错
     }
+    return pid, nil
+  }
 
-    // 找到匹配的进程，更新状态
-    managedProc.Status.PID = pid
-    managedProc.Status.Phase = models.PhaseRunning
-    now := time.Now()
-    managedProc.Status.StartTime = &now
-
-    // 更新资源监控信息
-    stats, err := m.MonitorProcess(managedProc.Metadata.Namespace, managedProc.Metadata.Name)
-    if err == nil {
-      managedProc.Status.ResourceStats = stats
-    }
+  // 没有参数和工作目录时任何进程都能匹配上，不做重关联
+  if len(managedProc.Spec.Exec.Args) == 0 && managedProc.Spec.WorkingDir == "" {
+    return 0, nil
+  }
 
-    return true, nil
-  } else {
-    // 如果没有定义进程匹配脚本，使用传统方式遍历所有进程
-    allProcesses, err := process.Processes()
-    if err != nil {
-      return false, fmt.Errorf("failed to get all processes: %w", err)
+  // 如果没有定义进程匹配脚本，使用传统方式遍历所有进程
+  for _, sysProcess := range candidates {
+    if owned[int(sysProcess.Pid)] {
+      continue
     }
-
-    // 遍历所有进程，寻找匹配的进程
-    for _, sysProcess := range allProcesses {
-      // 尝试匹配进程特征
-      if m.matchProcessByAttributes(managedProc, sysProcess) {
-        // 找到匹配的进程，更新状态
-        managedProc.Status.PID = int(sysProcess.Pid)
-        managedProc.Status.Phase = models.PhaseRunning
-        now := time.Now()
-        managedProc.Status.StartTime = &now
-
-        // 更新资源监控信息
-        stats, err := m.MonitorProcess(managedProc.Metadata.Namespace, managedProc.Metadata.Name)
-        if err == nil {
-          managedProc.Status.ResourceStats = stats
-        }
-
-        return true, nil
-      }
+    // 尝试匹配进程特征
+    if m.matchProcessByAttributes(managedProc, sysProcess) {
+      return int(sysProcess.Pid), nil
     }
   }
 
-  return false, nil
+  return 0, nil
 }
@@ -0,0 +1,302 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a unix sleep binary")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not found")
+	}
+	store, err := NewProcessStore(filepath.Join(t.TempDir(), "vigil.db"))
+	if err != nil {
+		t.Fatalf("NewProcessStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	m := NewManager()
+	m.SetStore(store)
+	// 存储所在的临时目录最先注册，删除它之前先停止所有进程，避免残留子进程和日志写入
+	t.Cleanup(func() { deleteAllProcesses(m) })
+	return m
+}
+
+// deleteAllProcesses 停止并删除 m 中的所有进程，不检查依赖关系
+func deleteAllProcesses(m *Manager) {
+	for _, e := range m.listEntries() {
+		_ = e.do(reconcileEvent{kind: eventDelete})
+	}
+}
+
+func testProcess(dir, name string) models.ManagedProcess {
+	return models.ManagedProcess{
+		Metadata: models.Metadata{Name: name, Namespace: "test"},
+		Spec: models.Spec{
+			Exec:          models.Exec{Command: "sleep", Args: []string{"300"}},
+			WorkingDir:    dir,
+			Log:           models.LogConfig{Dir: dir},
+			RestartPolicy: models.RestartPolicyNever,
+		},
+	}
+}
+
+// waitForFile 等待测试脚本写出文件并返回其内容
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(path); err == nil && strings.HasSuffix(string(data), "\n") {
+			return strings.TrimSpace(string(data))
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s not written after 5s", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestManagerConcurrentLifecycle(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	const n = 200
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("p%03d", i)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n*4)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := m.CreateProcess(testProcess(dir, name)); err != nil {
+				errs <- fmt.Errorf("create %s: %v", name, err)
+				return
+			}
+			if err := m.StartProcess("test", name); err != nil {
+				errs <- fmt.Errorf("start %s: %v", name, err)
+			}
+		}(name)
+	}
+
+	// 并发读取和采样，与状态转换交错
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+				list, _ := m.ListManagedProcesses("test")
+				for _, p := range list {
+					_, _ = m.GetProcessStatus(p.Metadata.Namespace, p.Metadata.Name)
+				}
+				_ = m.GetProcesses()
+			}
+		}()
+	}
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		cache := make(map[string]*process.Process)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			m.sampleAll(cache)
+		}
+	}()
+	wg.Wait()
+
+	for _, name := range names {
+		p, err := m.GetProcessStatus("test", name)
+		if err != nil {
+			t.Fatalf("GetProcessStatus %s: %v", name, err)
+		}
+		if p.Status.Phase != models.PhaseRunning || p.Status.PID <= 0 {
+			t.Fatalf("%s: phase=%s pid=%d, want running", name, p.Status.Phase, p.Status.PID)
+		}
+	}
+
+	// 并发重复操作同一进程，状态转换由 reconcile 协程串行处理
+	for _, name := range names {
+		wg.Add(2)
+		go func(name string) {
+			defer wg.Done()
+			if err := m.RestartProcess("test", name); err != nil {
+				errs <- fmt.Errorf("restart %s: %v", name, err)
+			}
+		}(name)
+		go func(name string) {
+			defer wg.Done()
+			_ = m.StopProcess("test", name)
+		}(name)
+	}
+	wg.Wait()
+
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := m.DeleteProcess("test", name); err != nil {
+				errs <- fmt.Errorf("delete %s: %v", name, err)
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	list, _ := m.ListManagedProcesses("")
+	if len(list) != 0 {
+		t.Fatalf("ListManagedProcesses after delete = %d processes, want 0", len(list))
+	}
+}
+
+func TestManagerRestartPolicy(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "flaky")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "exit 1"}}
+	p.Spec.RestartPolicy = models.RestartPolicyOnFailure
+	p.Spec.RestartInterval = 50 * time.Millisecond
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "flaky"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := m.GetProcessStatus("test", "flaky")
+		if got.Status.RestartCount >= 3 {
+			if got.Status.LastTerminationInfo == nil || got.Status.LastTerminationInfo.ExitCode != 1 {
+				t.Fatalf("LastTerminationInfo = %+v, want exit code 1", got.Status.LastTerminationInfo)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("RestartCount = %d after 5s, want >= 3", got.Status.RestartCount)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 用户停止后不再自动重启
+	_ = m.StopProcess("test", "flaky")
+	before, _ := m.GetProcessStatus("test", "flaky")
+	time.Sleep(300 * time.Millisecond)
+	after, _ := m.GetProcessStatus("test", "flaky")
+	if after.Status.RestartCount != before.Status.RestartCount {
+		t.Fatalf("RestartCount changed from %d to %d after stop", before.Status.RestartCount, after.Status.RestartCount)
+	}
+
+	if err := m.DeleteProcess("test", "flaky"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
+
+func TestManagerRestartLimit(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "crashy")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "exit 2"}}
+	p.Spec.RestartPolicy = models.RestartPolicyAlways
+	p.Spec.RestartInterval = 20 * time.Millisecond
+	p.Spec.RestartBackoff = &models.RestartBackoff{MaxRestarts: 3, Window: time.Minute}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "crashy"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := m.GetProcessStatus("test", "crashy")
+		if got.Status.Phase == models.PhaseCrashLoopBackOff && got.Status.NextRetryTime != nil {
+			// 退避间隔按连续崩溃次数翻倍
+			want := p.Spec.RestartInterval << (got.Status.BackoffCount - 1)
+			if d := got.Status.NextRetryTime.Sub(got.Status.LastTerminationInfo.FinishedAt); d < want {
+				t.Fatalf("backoff %d: retry after %v, want >= %v", got.Status.BackoffCount, d, want)
+			}
+		}
+		if got.Status.Phase == models.PhaseFailed {
+			if got.Status.RestartCount != 3 {
+				t.Fatalf("RestartCount = %d, want 3", got.Status.RestartCount)
+			}
+			cond := got.Status.GetCondition(models.ConditionTypeReady)
+			if cond == nil || cond.Reason != "RestartLimitExceeded" {
+				t.Fatalf("Ready condition = %+v, want reason RestartLimitExceeded", cond)
+			}
+			if got.Status.NextRetryTime != nil {
+				t.Fatalf("NextRetryTime = %v after giving up, want nil", got.Status.NextRetryTime)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("phase = %s after 5s, want Failed", got.Status.Phase)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 手动启动重置退避，且不计入 RestartCount
+	if err := m.StartProcess("test", "crashy"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	got, _ := m.GetProcessStatus("test", "crashy")
+	if got.Status.RestartCount != 3 {
+		t.Fatalf("RestartCount = %d after manual start, want 3", got.Status.RestartCount)
+	}
+	_ = m.StopProcess("test", "crashy")
+	if err := m.DeleteProcess("test", "crashy"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
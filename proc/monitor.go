@@ -691,11 +691,17 @@ func GetUnixProcessResourceUsage(pid int) (*models.ResourceStats, error) {
   if err != nil {
     return nil, err
   }
+  return collectProcessResourceUsage(p, time.Second), nil
+}
 
+// collectProcessResourceUsage 采集进程资源使用情况。
+// interval 为 0 时 CPU 使用率取自上次调用以来的增量，用于采样器复用同一个 process.Process
+func collectProcessResourceUsage(p *process.Process, interval time.Duration) *models.ResourceStats {
+  pid := int(p.Pid)
   stats := &models.ResourceStats{}
 
-  // CPU 使用率（采样1s）
-  if cpuPercent, err := p.Percent(time.Second); err == nil {
+  // CPU 使用率
+  if cpuPercent, err := p.Percent(interval); err == nil {
     stats.CPUUsage = cpuPercent
   }
   // CPU 时间（user/system/total）
@@ -735,6 +741,13 @@ func GetUnixProcessResourceUsage(pid int) (*models.ResourceStats, error) {
   if n, err := p.NumFDs(); err == nil {
     stats.OpenFDs = n
   }
+  if limits, err := p.Rlimit(); err == nil {
+    for _, l := range limits {
+      if l.Resource == process.RLIMIT_NOFILE {
+        stats.FDLimit = l.Soft
+      }
+    }
+  }
 
   // 线程数与状态
   if t, err := p.NumThreads(); err == nil {
@@ -822,5 +835,5 @@ func GetUnixProcessResourceUsage(pid int) (*models.ResourceStats, error) {
     stats.SystemUptimeSeconds = float64(up)
   }
 
-  return stats, nil
+  return stats
 }
@@ -0,0 +1,259 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	// defaultReadyTimeout 是等待 READY=1 的默认时间
+	defaultReadyTimeout = 90 * time.Second
+	// notifyMessageMax 是单条 notify 消息的最大长度
+	notifyMessageMax = 4096
+	// notifySettleWait 是子进程退出后等待读取排队消息的时间
+	notifySettleWait = 100 * time.Millisecond
+	// mainPIDPollInterval 是轮询 MAINPID 进程是否退出的间隔，它不是 vigil 的子进程，无法 Wait
+	mainPIDPollInterval = 200 * time.Millisecond
+)
+
+// mainPIDExitError 表示通过 MAINPID= 报告的主进程已退出，它不是 vigil 的子进程，退出码未知
+type mainPIDExitError struct {
+	pid int
+}
+
+func (e *mainPIDExitError) Error() string {
+	return fmt.Sprintf("main process %d exited", e.pid)
+}
+
+// validateNotify 校验 notify 配置
+func validateNotify(cfg *models.NotifyConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("notify is not supported on Windows")
+	}
+	if cfg.ReadyTimeout < 0 || cfg.WatchdogTimeout < 0 {
+		return fmt.Errorf("notify: timeouts must not be negative")
+	}
+	return nil
+}
+
+// watchdogEnv 返回告知子进程看门狗间隔的环境变量
+func watchdogEnv(cfg *models.NotifyConfig) []string {
+	if cfg.WatchdogTimeout <= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("WATCHDOG_USEC=%d", cfg.WatchdogTimeout.Microseconds())}
+}
+
+// parseNotifyMessage 解析 notify 消息，每行一个 KEY=VALUE
+func parseNotifyMessage(message string) [][2]string {
+	var fields [][2]string
+	for _, line := range strings.Split(message, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok && key != "" {
+			fields = append(fields, [2]string{key, value})
+		}
+	}
+	return fields
+}
+
+// parseMainPID 返回消息中的 MAINPID=
+func parseMainPID(message string) (int, bool) {
+	for _, field := range parseNotifyMessage(message) {
+		if field[0] == "MAINPID" {
+			pid, err := strconv.Atoi(field[1])
+			return pid, err == nil && pid > 0
+		}
+	}
+	return 0, false
+}
+
+// startNotify 启动 notify 消息的读取，并开始等待 READY=1（仅在 reconcile 协程中调用）
+func (m *Manager) startNotify(e *processEntry, run *processRun, cfg *models.NotifyConfig) {
+	e.watchdog = cfg.WatchdogTimeout
+	timeout := cfg.ReadyTimeout
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+	e.setCondition(models.ConditionTypeReady, models.ConditionFalse, "WaitingForReady", "waiting for READY=1")
+	e.startNotifyTimer(timeout)
+
+	go run.notify.serve(func(message string, sender int) {
+		// MAINPID= 在收到时校验：原进程随后可能退出，之后就无法确认报告的进程是否是它的后代
+		pid, ok := parseMainPID(message)
+		if ok && run.acceptMainPID(sender, pid) {
+			run.notify.mainPID.Store(int64(pid))
+		} else if ok {
+			log.Printf("Process %s: ignoring MAINPID=%d sent by pid %d", e.key, pid, sender)
+			pid = 0
+		}
+		e.send(reconcileEvent{kind: eventNotify, run: run, message: message, pid: pid})
+	})
+}
+
+// handleNotify 处理当前运行实例发送的一条 notify 消息，mainPID 是已校验的 MAINPID=，未报告或未通过校验时为 0（仅在 reconcile 协程中调用）
+func (m *Manager) handleNotify(e *processEntry, message string, mainPID int) {
+	for _, field := range parseNotifyMessage(message) {
+		key, value := field[0], field[1]
+		switch key {
+		case "READY":
+			if value != "1" || e.snapshot().Status.Phase != models.PhasePending {
+				continue
+			}
+			e.setPhase(models.PhaseRunning)
+			e.resetWatchdog()
+			m.emitEvent(e, models.EventReady, "", "process reported READY=1")
+			m.startProbes(e)
+
+		case "STATUS":
+			e.updateStatus(func(status *models.Status) {
+				status.StatusText = value
+			})
+
+		case "MAINPID":
+			if mainPID <= 0 {
+				continue
+			}
+			e.mu.Lock()
+			previous := e.process.Status.PID
+			e.process.Status.PID = mainPID
+			e.mu.Unlock()
+			if previous != mainPID {
+				log.Printf("Process %s reported main pid %d (was %d)", e.key, mainPID, previous)
+				m.recordPID(e, mainPID)
+			}
+
+		case "WATCHDOG_USEC":
+			usec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || usec <= 0 {
+				continue
+			}
+			e.watchdog = time.Duration(usec) * time.Microsecond
+			e.resetWatchdog()
+
+		case "WATCHDOG":
+			switch value {
+			case "1":
+				e.resetWatchdog()
+			case "trigger":
+				m.notifyFailed(e, "WatchdogTimeout", "process requested a watchdog restart (WATCHDOG=trigger)")
+				return
+			}
+		}
+	}
+}
+
+// notifyTimedOut 处理 READY=1 或看门狗心跳超时（仅在 reconcile 协程中调用）
+func (m *Manager) notifyTimedOut(e *processEntry) {
+	if e.snapshot().Status.Phase == models.PhasePending {
+		m.notifyFailed(e, "ReadyTimeout", "process did not report READY=1 in time")
+		return
+	}
+	m.notifyFailed(e, "WatchdogTimeout", fmt.Sprintf("no WATCHDOG=1 within %s", e.watchdog))
+}
+
+// notifyFailed 停止未就绪或看门狗超时的进程，按重启策略视为一次失败；不再重启时进程置为 Failed
+func (m *Manager) notifyFailed(e *processEntry, reason, message string) {
+	log.Printf("Process %s: %s", e.key, message)
+	m.emitEvent(e, models.EventNotifyTimeout, reason, message)
+	if err := m.stopRun(e); err != nil {
+		log.Printf("Failed to stop process %s: %v", e.key, err)
+		return
+	}
+	e.updateStatus(func(status *models.Status) {
+		if status.LastTerminationInfo == nil {
+			status.LastTerminationInfo = &models.TerminationInfo{FinishedAt: time.Now()}
+		}
+		status.LastTerminationInfo.Reason = reason
+		status.LastTerminationInfo.Message = message
+		status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, reason, message)
+	})
+	m.scheduleRestart(e, errors.New(message))
+	e.updateStatus(func(status *models.Status) {
+		if status.Phase == models.PhaseStopped {
+			status.Phase = models.PhaseFailed
+		}
+	})
+}
+
+// resetWatchdog 进程就绪后重新开始看门狗计时，未启用看门狗时取消计时；就绪前仍按 ReadyTimeout 计时
+func (e *processEntry) resetWatchdog() {
+	if e.snapshot().Status.Phase != models.PhaseRunning {
+		return
+	}
+	if e.watchdog > 0 {
+		e.startNotifyTimer(e.watchdog)
+	} else {
+		e.cancelNotifyTimer()
+	}
+}
+
+// startNotifyTimer 在 d 之后向 reconcile 协程发送 notify 超时事件
+func (e *processEntry) startNotifyTimer(d time.Duration) {
+	e.cancelNotifyTimer()
+	gen := e.notifyGen
+	e.notifyTimer = time.AfterFunc(d, func() {
+		e.send(reconcileEvent{kind: eventNotifyTimeout, gen: gen})
+	})
+}
+
+// cancelNotifyTimer 取消尚未触发的 notify 超时
+func (e *processEntry) cancelNotifyTimer() {
+	if e.notifyTimer != nil {
+		e.notifyTimer.Stop()
+		e.notifyTimer = nil
+	}
+	e.notifyGen++
+}
+
+// waitMainPID 在子进程退出后等待 MAINPID 报告的主进程退出。
+// fork 型守护进程的原进程退出后由 MAINPID 继续提供服务，此时不视为退出
+func waitMainPID(run *processRun, err error) error {
+	pid := int(run.notify.mainPID.Load())
+	if !run.followMainPID || pid <= 0 || pid == run.pid || !processRunning(pid) {
+		return err
+	}
+	for processRunning(pid) {
+		time.Sleep(mainPIDPollInterval)
+	}
+	return &mainPIDExitError{pid: pid}
+}
+
+// processRunning 进程是否存在且不是僵尸进程
+func processRunning(pid int) bool {
+	if !pidAlive(pid) {
+		return false
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false
+	}
+	status, err := p.Status()
+	return err != nil || len(status) == 0 || status[0] != process.Zombie
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// notifyOOBSize 是接收 SCM_CREDENTIALS 控制消息所需的缓冲区大小
+var notifyOOBSize = unix.CmsgSpace(unix.SizeofUcred)
+
+// enablePassCred 让内核为每个数据报附带发送方的凭据（SO_PASSCRED）
+func enablePassCred(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PASSCRED, 1)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// notifySender 从控制消息中解析发送方 PID，没有凭据时返回 0
+func notifySender(oob []byte) int {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for i := range msgs {
+		if cred, err := unix.ParseUnixCredentials(&msgs[i]); err == nil {
+			return int(cred.Pid)
+		}
+	}
+	return 0
+}
+
+// acceptMainPID 按 systemd NotifyAccess=main 的规则校验 MAINPID=：
+// 发送方必须属于本次运行或是已接受的主进程；报告的 PID 必须属于本次运行或是原进程的后代
+func (run *processRun) acceptMainPID(sender, pid int) bool {
+	if !run.followMainPID || sender <= 0 || pid <= 0 {
+		return false
+	}
+	if !run.owns(sender) && sender != int(run.notify.mainPID.Load()) {
+		return false
+	}
+	// 原进程可能在消息处理前就已退出，此时它的子进程已被收养，只能按会话或 cgroup 判断
+	return run.owns(pid) || isDescendant(pid, run.pid)
+}
+
+// owns pid 是否是本次运行的进程，或与其同一会话或 cgroup。
+// vigil 启动的进程是会话首进程，会话 ID 即其 PID
+func (run *processRun) owns(pid int) bool {
+	if pid == run.pid || run.cgroup.contains(pid) {
+		return true
+	}
+	_, sid, err := procParentSession(pid)
+	return err == nil && sid == run.pid
+}
+
+// isDescendant pid 是否是 ancestor 的后代
+func isDescendant(pid, ancestor int) bool {
+	for pid > 1 {
+		ppid, _, err := procParentSession(pid)
+		if err != nil {
+			return false
+		}
+		if ppid == ancestor {
+			return true
+		}
+		pid = ppid
+	}
+	return false
+}
+
+// procParentSession 从 /proc/<pid>/stat 读取父进程 PID 和会话 ID
+func procParentSession(pid int) (int, int, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// 进程名可能包含空格和括号，从最后一个 ')' 之后解析：state ppid pgrp session
+	s := string(data)
+	r := strings.LastIndex(s, ")")
+	if r < 0 {
+		return 0, 0, fmt.Errorf("invalid stat format")
+	}
+	fields := strings.Fields(s[r+1:])
+	if len(fields) < 4 {
+		return 0, 0, fmt.Errorf("invalid stat format")
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	sid, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return 0, 0, err
+	}
+	return ppid, sid, nil
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !linux
+
+package proc
+
+import (
+	"net"
+)
+
+// notifyOOBSize 非 Linux 平台无法获取 unixgram 发送方的凭据
+const notifyOOBSize = 0
+
+func enablePassCred(conn *net.UnixConn) error { return nil }
+
+func notifySender(oob []byte) int { return 0 }
+
+// acceptMainPID 无法确认发送方时不接受 MAINPID=
+func (run *processRun) acceptMainPID(sender, pid int) bool { return false }
@@ -0,0 +1,342 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// sendNotify 像 sd_notify 一样向 notify socket 发送一条消息
+func sendNotify(t *testing.T, socket, message string) {
+	t.Helper()
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("dial notify socket: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(message)); err != nil {
+		t.Fatalf("send %q: %v", message, err)
+	}
+}
+
+// waitForPhase 等待进程进入 phase
+func waitForPhase(t *testing.T, m *Manager, name string, phase models.Phase) models.ManagedProcess {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := m.GetProcessStatus("test", name)
+		if err == nil && status.Status.Phase == phase {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process %s did not become %s: %+v", name, phase, status.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestParseNotifyMessage(t *testing.T) {
+	fields := parseNotifyMessage("READY=1\nSTATUS=listening on :8080\n\nbogus\nMAINPID=42")
+	if got := fmt.Sprint(fields); got != "[[READY 1] [STATUS listening on :8080] [MAINPID 42]]" {
+		t.Fatalf("fields = %s", got)
+	}
+	if pid, ok := parseMainPID("STATUS=x\nMAINPID=42"); !ok || pid != 42 {
+		t.Fatalf("parseMainPID = %d, %t", pid, ok)
+	}
+	if _, ok := parseMainPID("MAINPID=abc"); ok {
+		t.Fatalf("parseMainPID accepted an invalid pid")
+	}
+}
+
+// TestNotifyHelperProcess 不是真正的测试：由 notify 测试作为纳管进程启动，按 VIGIL_TEST_NOTIFY_HELPER 模拟 fork 型守护进程
+func TestNotifyHelperProcess(t *testing.T) {
+	mode := os.Getenv("VIGIL_TEST_NOTIFY_HELPER")
+	if mode == "" {
+		return
+	}
+	wait := func(name string) string {
+		for i := 0; i < 500; i++ {
+			if data, err := os.ReadFile(name); err == nil {
+				return strings.TrimSpace(string(data))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		os.Exit(1)
+		return ""
+	}
+	send := func(message string) {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: os.Getenv("NOTIFY_SOCKET"), Net: "unixgram"})
+		if err != nil {
+			os.Exit(1)
+		}
+		conn.Write([]byte(message))
+		conn.Close()
+	}
+
+	switch mode {
+	case "fork":
+		// 启动主进程并报告 MAINPID 后立即退出，由主进程发送 READY=1
+		os.WriteFile("original", []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+		daemon := exec.Command(os.Args[0], os.Args[1:]...)
+		daemon.Env = append(os.Environ(), "VIGIL_TEST_NOTIFY_HELPER=daemon", fmt.Sprintf("VIGIL_TEST_NOTIFY_PARENT=%d", os.Getpid()))
+		if err := daemon.Start(); err != nil {
+			os.Exit(1)
+		}
+		send(fmt.Sprintf("MAINPID=%d", daemon.Process.Pid))
+		os.Exit(0)
+
+	case "daemon":
+		// 原进程退出后才就绪，然后持续发送看门狗心跳
+		parent := os.Getenv("VIGIL_TEST_NOTIFY_PARENT")
+		for strconv.Itoa(os.Getppid()) == parent {
+			time.Sleep(20 * time.Millisecond)
+		}
+		send("READY=1\nSTATUS=serving")
+		for {
+			send("WATCHDOG=1")
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	// 实际提供服务的进程是本进程的子进程，本进程退出后由它继续运行
+	daemon := exec.Command("sleep", "300")
+	if err := daemon.Start(); err != nil {
+		os.Exit(1)
+	}
+	os.WriteFile("daemon.notify", []byte(fmt.Sprintf("%s %d\n", os.Getenv("NOTIFY_SOCKET"), daemon.Process.Pid)), 0644)
+	send(fmt.Sprintf("MAINPID=%s\nSTATUS=unrelated", wait("unrelated")))
+	wait("go")
+	send(fmt.Sprintf("STATUS=warming up\nMAINPID=%d", daemon.Process.Pid))
+	send("READY=1\nSTATUS=serving")
+	wait("exit")
+	os.Exit(0)
+}
+
+// waitForStatusText 等待进程的 STATUS= 变为 text
+func waitForStatusText(t *testing.T, m *Manager, name, text string) models.ManagedProcess {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := m.GetProcessStatus("test", name)
+		if err == nil && status.Status.StatusText == text {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process %s status text = %q, want %q", name, status.Status.StatusText, text)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestManagerNotify(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("MAINPID requires SCM_CREDENTIALS")
+	}
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "daemon")
+	p.Spec.Exec = models.Exec{Command: os.Args[0], Args: []string{"-test.run=^TestNotifyHelperProcess$"}}
+	p.Spec.Env = []models.EnvVar{{Name: "VIGIL_TEST_NOTIFY_HELPER", Value: "mainpid"}}
+	p.Spec.Notify = &models.NotifyConfig{}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "daemon"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	socket, pidText, _ := strings.Cut(waitForFile(t, filepath.Join(dir, "daemon.notify")), " ")
+	daemonPID, _ := strconv.Atoi(pidText)
+	daemon, _ := os.FindProcess(daemonPID)
+	defer daemon.Kill()
+	status, _ := m.GetProcessStatus("test", "daemon")
+	pid := status.Status.PID
+	if status.Status.Phase != models.PhasePending || pid <= 0 {
+		t.Fatalf("before READY=1: %s pid %d, want Pending", status.Status.Phase, pid)
+	}
+
+	// 不属于本次运行的进程发送的 MAINPID= 被忽略
+	sendNotify(t, socket, fmt.Sprintf("MAINPID=%d\nSTATUS=outsider", daemonPID))
+	if status = waitForStatusText(t, m, "daemon", "outsider"); status.Status.PID != pid {
+		t.Fatalf("MAINPID from an outsider changed pid to %d", status.Status.PID)
+	}
+
+	// 报告的进程不是本次运行的后代时同样被忽略
+	unrelated := exec.Command("sleep", "300")
+	if err := unrelated.Start(); err != nil {
+		t.Fatalf("start unrelated process: %v", err)
+	}
+	defer func() {
+		unrelated.Process.Kill()
+		unrelated.Wait()
+	}()
+	if err := os.WriteFile(filepath.Join(dir, "unrelated"), []byte(strconv.Itoa(unrelated.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status = waitForStatusText(t, m, "daemon", "unrelated"); status.Status.PID != pid {
+		t.Fatalf("MAINPID of an unrelated process changed pid to %d", status.Status.PID)
+	}
+
+	// fork 型守护进程：实际提供服务的进程通过 MAINPID 报告，原进程随后退出
+	if err := os.WriteFile(filepath.Join(dir, "go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	status = waitForPhase(t, m, "daemon", models.PhaseRunning)
+	if status.Status.PID != daemonPID || status.Status.StatusText != "serving" {
+		t.Fatalf("after READY=1: pid %d status %q, want pid %d and serving", status.Status.PID, status.Status.StatusText, daemonPID)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "exit"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("original process %d did not exit", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	status, _ = m.GetProcessStatus("test", "daemon")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID != daemonPID {
+		t.Fatalf("after the original process exited: %s pid %d, want still running as %d", status.Status.Phase, status.Status.PID, daemonPID)
+	}
+
+	// 主进程退出后才视为进程退出
+	if err := daemon.Kill(); err != nil {
+		t.Fatalf("kill daemon: %v", err)
+	}
+	status = waitForPhase(t, m, "daemon", models.PhaseStopped)
+	if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "MainProcessExited" {
+		t.Fatalf("termination info = %+v, want MainProcessExited", info)
+	}
+}
+
+func TestManagerNotifyForkedDaemon(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("MAINPID requires SCM_CREDENTIALS")
+	}
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	p := testProcess(dir, "forking")
+	p.Spec.Exec = models.Exec{Command: os.Args[0], Args: []string{"-test.run=^TestNotifyHelperProcess$"}}
+	p.Spec.Env = []models.EnvVar{{Name: "VIGIL_TEST_NOTIFY_HELPER", Value: "fork"}}
+	p.Spec.Notify = &models.NotifyConfig{ReadyTimeout: 5 * time.Second, WatchdogTimeout: time.Second}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "forking"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	pid, _ := strconv.Atoi(waitForFile(t, filepath.Join(dir, "original")))
+
+	// READY=1 由主进程在原进程退出之后发送
+	status := waitForPhase(t, m, "forking", models.PhaseRunning)
+	daemonPID := status.Status.PID
+	if daemonPID == pid || processRunning(pid) {
+		t.Fatalf("after READY=1: pid %d, original %d still running %t", daemonPID, pid, processRunning(pid))
+	}
+	daemon, _ := os.FindProcess(daemonPID)
+	defer daemon.Kill()
+
+	// 主进程的看门狗心跳在多个周期内都能收到
+	time.Sleep(2500 * time.Millisecond)
+	status, _ = m.GetProcessStatus("test", "forking")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID != daemonPID {
+		t.Fatalf("while the main process pings the watchdog: %s pid %d, want Running as %d", status.Status.Phase, status.Status.PID, daemonPID)
+	}
+	events, _ := m.ListEvents("test", "forking", 0, 0)
+	if got := fmt.Sprint(eventTypes(events)); strings.Contains(got, "NotifyTimeout") {
+		t.Fatalf("events = %s, want no NotifyTimeout", got)
+	}
+
+	if err := daemon.Kill(); err != nil {
+		t.Fatalf("kill main process: %v", err)
+	}
+	status = waitForPhase(t, m, "forking", models.PhaseStopped)
+	if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "MainProcessExited" {
+		t.Fatalf("termination info = %+v, want MainProcessExited", info)
+	}
+}
+
+func TestManagerNotifyTimeouts(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	// 未在 ready_timeout 内就绪视为启动失败
+	slow := testProcess(dir, "slow")
+	slow.Spec.Notify = &models.NotifyConfig{ReadyTimeout: 300 * time.Millisecond}
+	if err := m.CreateProcess(slow); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "slow"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "slow")
+	pid := status.Status.PID
+	status = waitForPhase(t, m, "slow", models.PhaseFailed)
+	if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "ReadyTimeout" {
+		t.Fatalf("termination info = %+v, want ReadyTimeout", info)
+	}
+	if pidAlive(pid) {
+		t.Fatalf("process %d is still running after the ready timeout", pid)
+	}
+
+	// 就绪后未按时发送 WATCHDOG=1 时停止进程
+	wd := testProcess(dir, "watchdog")
+	wd.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `echo "$NOTIFY_SOCKET $WATCHDOG_USEC" > watchdog.notify; exec sleep 300`}}
+	wd.Spec.Notify = &models.NotifyConfig{WatchdogTimeout: 400 * time.Millisecond}
+	if err := m.CreateProcess(wd); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "watchdog"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	socket, usec, _ := strings.Cut(waitForFile(t, filepath.Join(dir, "watchdog.notify")), " ")
+	if usec != "400000" {
+		t.Fatalf("WATCHDOG_USEC = %q, want 400000", usec)
+	}
+	sendNotify(t, socket, "READY=1")
+	waitForPhase(t, m, "watchdog", models.PhaseRunning)
+	for i := 0; i < 8; i++ {
+		sendNotify(t, socket, "WATCHDOG=1")
+		time.Sleep(100 * time.Millisecond)
+	}
+	if status, _ = m.GetProcessStatus("test", "watchdog"); status.Status.Phase != models.PhaseRunning {
+		t.Fatalf("phase while pinging the watchdog = %s, want Running", status.Status.Phase)
+	}
+	status = waitForPhase(t, m, "watchdog", models.PhaseFailed)
+	if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "WatchdogTimeout" {
+		t.Fatalf("termination info = %+v, want WatchdogTimeout", info)
+	}
+	events, _ := m.ListEvents("test", "watchdog", 0, 0)
+	if got := fmt.Sprint(eventTypes(events)); !strings.Contains(got, "Ready NotifyTimeout") {
+		t.Fatalf("events = %s, want Ready followed by NotifyTimeout", got)
+	}
+}
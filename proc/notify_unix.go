@@ -0,0 +1,101 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !windows
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// notifySocket 是一次运行的 sd_notify socket（unixgram），子进程通过 NOTIFY_SOCKET 向其发送消息
+type notifySocket struct {
+	// dir 是本次运行独占的目录，path 是其中的 socket 文件
+	dir  string
+	path string
+	conn *net.UnixConn
+	// mainPID 是最近一次 MAINPID= 报告的 PID，由读取协程写入
+	mainPID atomic.Int64
+	// handling 在读取协程处理一条消息期间持有
+	handling sync.Mutex
+}
+
+// newNotifySocket 在新建的随机目录（0700）中创建 notify socket，目录和 socket 交给运行用户，其他用户无法访问
+func newNotifySocket(runAs *runAsUser) (*notifySocket, error) {
+	dir, err := os.MkdirTemp("", "vigil-notify-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notify socket directory: %w", err)
+	}
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create notify socket: %w", err)
+	}
+	if err := enablePassCred(conn); err != nil {
+		conn.Close()
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to enable credentials on notify socket: %w", err)
+	}
+	runAs.chown(dir)
+	runAs.chown(path)
+	return &notifySocket{dir: dir, path: path, conn: conn}, nil
+}
+
+// serve 读取消息直到 socket 关闭，每个数据报连同发送方 PID（未知时为 0）交给 handle 处理。
+// 原进程退出后继续读取，fork 出的主进程仍可以发送 READY=1、WATCHDOG=1 等消息
+func (n *notifySocket) serve(handle func(message string, sender int)) {
+	buf := make([]byte, notifyMessageMax)
+	oob := make([]byte, notifyOOBSize)
+	for {
+		size, oobn, _, _, err := n.conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		n.handling.Lock()
+		handle(string(buf[:size]), notifySender(oob[:oobn]))
+		n.handling.Unlock()
+	}
+}
+
+// settle 在子进程退出后等待退出前排队的消息（如 MAINPID=）处理完，不停止读取
+func (n *notifySocket) settle() {
+	time.Sleep(notifySettleWait)
+	n.handling.Lock()
+	n.handling.Unlock()
+}
+
+// env 返回传给子进程的环境变量
+func (n *notifySocket) env() []string {
+	return []string{"NOTIFY_SOCKET=" + n.path}
+}
+
+// close 关闭 socket 并删除 socket 所在的目录
+func (n *notifySocket) close() {
+	_ = n.conn.Close()
+	_ = os.RemoveAll(n.dir)
+}
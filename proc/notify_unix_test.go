@@ -0,0 +1,56 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !windows
+
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNotifySocketDir(t *testing.T) {
+	a, err := newNotifySocket(nil)
+	if err != nil {
+		t.Fatalf("newNotifySocket: %v", err)
+	}
+	b, err := newNotifySocket(nil)
+	if err != nil {
+		t.Fatalf("newNotifySocket: %v", err)
+	}
+	defer b.close()
+
+	// 每次运行使用独立的随机目录，只有属主可以访问
+	if a.dir == b.dir {
+		t.Fatalf("both sockets are in %s", a.dir)
+	}
+	info, err := os.Stat(a.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Fatalf("socket directory mode = %o, want 700", perm)
+	}
+	if filepath.Dir(a.path) != a.dir {
+		t.Fatalf("socket %s is not in %s", a.path, a.dir)
+	}
+	a.close()
+	if _, err := os.Stat(a.dir); !os.IsNotExist(err) {
+		t.Fatalf("socket directory still exists after close: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// notifySocket Windows 下不支持 sd_notify
+type notifySocket struct {
+	mainPID atomic.Int64
+}
+
+func newNotifySocket(runAs *runAsUser) (*notifySocket, error) {
+	return nil, fmt.Errorf("notify is not supported on Windows")
+}
+
+func (n *notifySocket) serve(handle func(message string, sender int)) {}
+
+func (n *notifySocket) settle() {}
+
+func (n *notifySocket) env() []string { return nil }
+
+func (n *notifySocket) close() {}
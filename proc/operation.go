@@ -20,10 +20,14 @@ import (
   "errors"
   "fmt"
   "github.com/casuallc/vigil/models"
+  "github.com/shirou/gopsutil/v3/process"
+  "log"
   "os"
   "os/exec"
   "path/filepath"
   "runtime"
+  "sort"
+  "strings"
   "syscall"
   "time"
 )
@@ -35,72 +39,216 @@ func (m *Manager) CreateProcess(process models.ManagedProcess) error {
     process.Metadata.Namespace = "default"
   }
 
-  key := fmt.Sprintf("%s/%s", process.Metadata.Namespace, process.Metadata.Name)
-  if _, exists := m.Processes[key]; exists {
-    return fmt.Errorf("proc %s/%s is already managed", process.Metadata.Namespace, process.Metadata.Name)
+  if err := ValidateProcess(&process); err != nil {
+    return err
   }
 
   // Generate ID for the proc
   process.Metadata.ID = fmt.Sprintf("%s-%d", process.Metadata.Name, time.Now().UnixNano())
+  if process.Metadata.CreationTimestamp.IsZero() {
+    process.Metadata.CreationTimestamp = time.Now()
+  }
 
-  // Store the proc
-  m.Processes[key] = &process
+  // 检查依赖环并纳管进程
+  m.depMu.Lock()
+  if err := m.checkDependencyCycle(&process); err != nil {
+    m.depMu.Unlock()
+    return err
+  }
+  _, err := m.addEntry(process)
+  m.depMu.Unlock()
+  if err != nil {
+    return err
+  }
 
   // 保存进程信息
-  if err := m.SaveManagedProcesses(ProcessesFilePath); err != nil {
+  if err := m.saveProcess(process); err != nil {
     fmt.Printf("Warning: failed to save managed processes: %v\n", err)
   }
 
-  // Start monitoring the proc
-  go m.startMonitoring(process.Metadata.Namespace, process.Metadata.Name)
-
   return nil
 }
 
+// addEntry 纳管一个进程并启动它的 reconcile 协程
+func (m *Manager) addEntry(process models.ManagedProcess) (*processEntry, error) {
+  e := newProcessEntry(process)
+  if process.Spec.Replicas != nil {
+    if err := m.initInstances(e); err != nil {
+      return nil, err
+    }
+  }
+  if isCronJob(&process) {
+    // 定时 Job 的实例不持久化，保存的实例状态已经失效
+    e.process.Status.Instances = nil
+  }
+
+  m.mu.Lock()
+  if _, exists := m.entries[e.key]; exists {
+    m.mu.Unlock()
+    deleteInstances(e.listInstances())
+    return nil, fmt.Errorf("proc %s/%s is already managed", process.Metadata.Namespace, process.Metadata.Name)
+  }
+  m.entries[e.key] = e
+  m.mu.Unlock()
+
+  go m.reconcile(e)
+  m.startSampler()
+  return e, nil
+}
+
+// getEntry 查找纳管的进程
+func (m *Manager) getEntry(namespace, name string) (*processEntry, bool) {
+  m.mu.RLock()
+  defer m.mu.RUnlock()
+  e, ok := m.entries[fmt.Sprintf("%s/%s", namespace, name)]
+  return e, ok
+}
+
+// listEntries 返回所有纳管进程，按 namespace/name 排序
+func (m *Manager) listEntries() []*processEntry {
+  m.mu.RLock()
+  entries := make([]*processEntry, 0, len(m.entries))
+  for _, e := range m.entries {
+    entries = append(entries, e)
+  }
+  m.mu.RUnlock()
+
+  sort.Slice(entries, func(i, j int) bool {
+    return entries[i].key < entries[j].key
+  })
+  return entries
+}
+
 // DeleteProcess 删除一个纳管的进程
 func (m *Manager) DeleteProcess(namespace, name string) error {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  process, exists := m.Processes[key]
+  e, exists := m.getEntry(namespace, name)
   if !exists {
     return fmt.Errorf("进程 %s/%s 未被纳管", namespace, name)
   }
 
-  // 如果进程正在运行，先停止它
-  if process.Status.Phase == models.PhaseRunning {
-    if err := m.StopProcess(namespace, name); err != nil {
-      return fmt.Errorf("停止进程失败: %w", err)
-    }
+  // 仍被其他进程依赖时拒绝删除
+  if dependents := m.dependents(namespace, name); len(dependents) > 0 {
+    return fmt.Errorf("%w: %s/%s is required by %s", ErrProcessInUse, namespace, name, strings.Join(dependents, ", "))
+  }
+
+  // 由 reconcile 协程先停止进程，成功后退出
+  if err := e.do(reconcileEvent{kind: eventDelete}); err != nil {
+    return err
   }
 
-  // 从管理列表中删除进程 - 修复使用正确的键
-  delete(m.Processes, key)
+  // 从管理列表中删除进程
+  m.mu.Lock()
+  if m.entries[e.key] == e {
+    delete(m.entries, e.key)
+  }
+  m.mu.Unlock()
 
   // 保存更新后的进程列表
-  if err := m.SaveManagedProcesses(ProcessesFilePath); err != nil {
+  if err := m.forgetProcess(namespace, name); err != nil {
     fmt.Printf("Warning: failed to save managed processes: %v\n", err)
   }
 
   return nil
 }
 
+// UpdateProcess 更新进程的 Metadata 和 Spec，保留当前 Status；运行中的进程在下次启动时使用新定义
+func (m *Manager) UpdateProcess(process models.ManagedProcess) error {
+  e, exists := m.getEntry(process.Metadata.Namespace, process.Metadata.Name)
+  if !exists {
+    return fmt.Errorf("process %s/%s is not managed", process.Metadata.Namespace, process.Metadata.Name)
+  }
+  if err := ValidateProcess(&process); err != nil {
+    return err
+  }
+
+  // ID 和创建时间由 vigil 维护
+  current := e.snapshot()
+  process.Metadata.ID = current.Metadata.ID
+  process.Metadata.CreationTimestamp = current.Metadata.CreationTimestamp
+  if (process.Spec.Replicas == nil) != (current.Spec.Replicas == nil) {
+    return fmt.Errorf("%w: replicas cannot be added to or removed from an existing process", ErrInvalidProcess)
+  }
+  if err := checkJobUpdate(&current, &process); err != nil {
+    return err
+  }
+
+  m.depMu.Lock()
+  if err := m.checkDependencyCycle(&process); err != nil {
+    m.depMu.Unlock()
+    return err
+  }
+  err := e.do(reconcileEvent{kind: eventUpdate, process: &process})
+  m.depMu.Unlock()
+  if err != nil {
+    return err
+  }
+
+  if err := m.saveProcess(process); err != nil {
+    fmt.Printf("Warning: failed to save managed processes: %v\n", err)
+  }
+  return nil
+}
+
 // StartProcess implements ProcManager interface to start a proc
 func (m *Manager) StartProcess(namespace, name string) error {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  process, exists := m.Processes[key]
+  e, exists := m.getEntry(namespace, name)
   if !exists {
     return fmt.Errorf("proc %s/%s is not managed", namespace, name)
   }
+  return e.do(reconcileEvent{kind: eventStart})
+}
+
+// StopProcess 实现ProcessManager接口，停止一个进程
+func (m *Manager) StopProcess(namespace, name string) error {
+  e, exists := m.getEntry(namespace, name)
+  if !exists {
+    return fmt.Errorf("进程 %s/%s 未被纳管", namespace, name)
+  }
+  return e.do(reconcileEvent{kind: eventStop})
+}
+
+// RestartProcess 实现ProcessManager接口，重启一个进程
+func (m *Manager) RestartProcess(namespace, name string) error {
+  e, exists := m.getEntry(namespace, name)
+  if !exists {
+    return fmt.Errorf("进程 %s/%s 未被纳管", namespace, name)
+  }
+  return e.do(reconcileEvent{kind: eventRestart})
+}
+
+// startRun 启动进程（仅在 reconcile 协程中调用）
+func (m *Manager) startRun(e *processEntry) error {
+  process := e.snapshot()
+  name := process.Metadata.Name
 
-  if process.Status.Phase == models.PhaseRunning {
+  if process.Status.Phase == models.PhaseRunning || e.run != nil {
     return fmt.Errorf("proc %s is already running", name)
   }
 
   // Set proc status to pending
-  process.Status.Phase = models.PhasePending
+  e.runStarted = time.Time{}
+  e.setPhase(models.PhasePending)
+
+  // 解析运行用户
+  runAs, err := resolveRunAsUser(&process.Spec)
+  if err != nil {
+    e.setPhase(models.PhaseFailed)
+    return err
+  }
+
+  // 加载 EnvFrom 并解析 ${secret:name}，只作用于本次启动使用的副本
+  if err := m.expandEnv(&process); err != nil {
+    e.updateStatus(func(status *models.Status) {
+      status.Phase = models.PhaseFailed
+      status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "EnvResolveFailed", err.Error())
+    })
+    return err
+  }
 
   // 在 Linux 下应用目录挂载（bind/tmpfs/named）
-  if err := applyMounts(process.Spec.Mounts); err != nil {
-    process.Status.Phase = models.PhaseFailed
+  if err := applyMounts(hostMounts(&process.Spec), runAs); err != nil {
+    e.setPhase(models.PhaseFailed)
+    m.emitEvent(e, models.EventMountFailed, "", err.Error())
     return fmt.Errorf("failed to apply mounts: %w", err)
   }
 
@@ -114,23 +262,33 @@ func (m *Manager) StartProcess(namespace, name string) error {
     // Use current directory by default
     currentDir, err := os.Getwd()
     if err != nil {
-      process.Status.Phase = models.PhaseFailed
+      e.setPhase(models.PhaseFailed)
       return err
     }
     cmd.Dir = currentDir
   }
 
   // Set environment variables - 从 EnvVar 数组构建
-  cmd.Env = os.Environ()
-  // 从配置中添加环境变量
-  for _, envVar := range process.Spec.Env {
-    cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", envVar.Name, envVar.Value))
+  cmd.Env = processEnv(&process, runAs)
+
+  // 以 Spec.User / Spec.UserGroup 身份运行
+  if err := applyCredential(cmd, runAs); err != nil {
+    e.setPhase(models.PhaseFailed)
+    cleanupMounts(hostMounts(&process.Spec))
+    return err
+  }
+  // 在独立的会话中运行，停止时向整个进程树发送信号
+  applyProcessGroup(cmd)
+  // 在独立的命名空间中运行（Spec.Isolation）
+  if err := applyIsolation(cmd, &process, runAs); err != nil {
+    e.setPhase(models.PhaseFailed)
+    return err
   }
 
   // 确保日志目录存在
   if process.Spec.Log.Dir != "" {
     if err := os.MkdirAll(process.Spec.Log.Dir, 0755); err != nil {
-      process.Status.Phase = models.PhaseFailed
+      e.setPhase(models.PhaseFailed)
       return err
     }
   }
@@ -141,26 +299,92 @@ func (m *Manager) StartProcess(namespace, name string) error {
     logDir = cmd.Dir
   }
 
-  stdout, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("%s.stdout.log", name)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-  if err != nil {
-    process.Status.Phase = models.PhaseFailed
-    // 启动失败时清理挂载
-    cleanupMounts(process.Spec.Mounts)
-    return err
+  // 子进程写 vigil 持有的管道，由 vigil 写入日志文件并负责轮转
+  var outputs []*processOutput
+  var notify *notifySocket
+  // Spec.Stdin 时为子进程保持一个标准输入管道，写端由 attach 会话写入
+  var stdinChild, stdin *os.File
+  started := false
+  defer func() {
+    if !started {
+      for _, o := range outputs {
+        o.close()
+      }
+      if notify != nil {
+        notify.close()
+      }
+      if stdin != nil {
+        stdinChild.Close()
+        stdin.Close()
+      }
+    }
+  }()
+
+  // 启用 sd_notify 时为本次运行创建 notify socket
+  if process.Spec.Notify != nil {
+    notify, err = newNotifySocket(runAs)
+    if err != nil {
+      e.setPhase(models.PhaseFailed)
+      cleanupMounts(hostMounts(&process.Spec))
+      return err
+    }
+    cmd.Env = append(cmd.Env, notify.env()...)
+    cmd.Env = append(cmd.Env, watchdogEnv(process.Spec.Notify)...)
+  }
+  for _, stream := range []struct {
+    name string
+    file string
+    id   byte
+  }{
+    {"stdout", process.Spec.Log.StdoutFile, models.AttachStreamStdout},
+    {"stderr", process.Spec.Log.StderrFile, models.AttachStreamStderr},
+  } {
+    path := processLogPath(logDir, name, stream.file, stream.name)
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+      e.setPhase(models.PhaseFailed)
+      cleanupMounts(hostMounts(&process.Spec))
+      return err
+    }
+    output, err := newProcessOutput(path, process.Spec.Log, runAs, outputTailLimit(&process.Spec))
+    if err != nil {
+      e.setPhase(models.PhaseFailed)
+      // 启动失败时清理挂载
+      cleanupMounts(hostMounts(&process.Spec))
+      return err
+    }
+    output.attach = attachWriter{hub: e.attach, stream: stream.id}
+    outputs = append(outputs, output)
+  }
+  cmd.Stdout = outputs[0].childEnd
+  cmd.Stderr = outputs[1].childEnd
+  if process.Spec.Stdin {
+    stdinChild, stdin, err = os.Pipe()
+    if err != nil {
+      e.setPhase(models.PhaseFailed)
+      cleanupMounts(hostMounts(&process.Spec))
+      return err
+    }
+    cmd.Stdin = stdinChild
   }
-  defer stdout.Close()
 
-  stderr, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("%s.stderr.log", name)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-  if err != nil {
-    process.Status.Phase = models.PhaseFailed
-    // 启动失败时清理挂载
-    cleanupMounts(process.Spec.Mounts)
+  // 执行 PreStart 钩子，失败则中止启动
+  if err := m.runPreStart(&process); err != nil {
+    e.updateStatus(func(status *models.Status) {
+      status.Phase = models.PhaseFailed
+      status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "PreStartFailed", err.Error())
+    })
+    cleanupMounts(hostMounts(&process.Spec))
     return err
   }
-  defer stderr.Close()
 
-  cmd.Stdout = stdout
-  cmd.Stderr = stderr
+  // 按 Spec.Resources 创建 cgroup 并应用资源限制，不可用时仅记录警告条件
+  cg, err := setupCgroup(&process, cmd)
+  if err != nil {
+    log.Printf("Warning: resource limits for process %s are not enforced: %v", e.key, err)
+    e.setCondition(models.ConditionTypeResourcesEnforced, models.ConditionFalse, "CgroupUnavailable", err.Error())
+  } else if cg != nil {
+    e.setCondition(models.ConditionTypeResourcesEnforced, models.ConditionTrue, "CgroupApplied", cg.path)
+  }
 
   // Start proc with timeout support
   done := make(chan error, 1)
@@ -175,104 +399,232 @@ func (m *Manager) StartProcess(namespace, name string) error {
   select {
   case err := <-done:
     if err != nil {
-      process.Status.Phase = models.PhaseFailed
-      // 启动失败时清理挂载
-      cleanupMounts(process.Spec.Mounts)
+      e.setPhase(models.PhaseFailed)
+      // 启动失败时清理挂载和 cgroup
+      cleanupMounts(hostMounts(&process.Spec))
+      if cg != nil {
+        cg.remove()
+      }
       return err
     }
   case <-time.After(timeout):
     // Timeout, kill the proc
-    process.Status.Phase = models.PhaseFailed
+    e.setPhase(models.PhaseFailed)
     if cmd.Process != nil {
       cmd.Process.Kill()
     }
-    // 超时也清理挂载
-    cleanupMounts(process.Spec.Mounts)
+    // 超时也清理挂载和 cgroup
+    cleanupMounts(hostMounts(&process.Spec))
+    if cg != nil {
+      cg.remove()
+    }
     return fmt.Errorf("proc start timed out after %v", timeout)
   }
 
-  // Update proc information
-  process.Status.PID = cmd.Process.Pid
-  process.Status.Phase = models.PhaseRunning
-  now := time.Now()
-  process.Status.StartTime = &now
-  process.Status.RestartCount++
+  if cg != nil {
+    cg.closeFD()
+  }
 
-  // 异步等待进程退出，增加重启策略和最大重启次数的支持
-  go func() {
-    err := cmd.Wait()
-    if err != nil {
-      // 如果有退出码，记录下来
-      var exitErr *exec.ExitError
-      if errors.As(err, &exitErr) {
-        if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-          if process.Status.LastTerminationInfo == nil {
-            process.Status.LastTerminationInfo = &models.TerminationInfo{}
-          }
-          process.Status.LastTerminationInfo.ExitCode = status.ExitStatus()
-        }
+  // 管道写端已由子进程继承，关闭本端后开始复制输出
+  started = true
+  for _, o := range outputs {
+    o.start(process.Spec.Log.Timestamps)
+  }
+  if stdin != nil {
+    stdinChild.Close()
+    e.attach.setStdin(stdin)
+  }
+
+  run := &processRun{
+    cmd:    cmd,
+    pid:    cmd.Process.Pid,
+    cgroup: cg,
+    exited: make(chan struct{}),
+    outputs: outputs,
+    stdin:  stdin,
+    notify: notify,
+    // PID 命名空间内报告的 MAINPID 在宿主机上没有意义
+    followMainPID: process.Spec.Isolation == nil,
+  }
+  e.run = run
+
+  // Update proc information，启用 sd_notify 时保持 Pending 直到进程报告 READY=1
+  phase := models.PhaseRunning
+  if notify != nil {
+    phase = models.PhasePending
+  }
+  now := time.Now()
+  e.mu.Lock()
+  e.process.Status.PID = run.pid
+  e.process.Status.Phase = phase
+  e.process.Status.StatusText = ""
+  e.process.Status.StartTime = &now
+  e.process.Status.NextRetryTime = nil
+  e.cgroup = cg
+  e.adopted = false
+  e.adoptedStartTime = 0
+  e.mu.Unlock()
+  e.runStarted = now
+  m.recordPID(e, run.pid)
+  m.emitEvent(e, models.EventStarted, "", fmt.Sprintf("started with pid %d", run.pid))
+  if process.Spec.OnCrash != nil {
+    if process.Spec.OnCrash.CoreDir != "" {
+      if err := enableCoreDump(run.pid); err != nil {
+        log.Printf("Warning: failed to enable core dumps for process %s: %v", e.key, err)
       }
     }
+    e.saveProcSnapshot(run.pid, nil)
+  }
 
-    process.Status.Phase = models.PhaseStopped
-    process.Status.PID = 0
+  // 启动健康检查探针；启用 sd_notify 时在 READY=1 之后启动
+  if notify != nil {
+    m.startNotify(e, run, process.Spec.Notify)
+  } else {
+    m.startProbes(e)
+  }
+  m.startWatch(e)
+
+  // 异步等待进程退出，由 reconcile 协程处理退出和重启策略
+  go func() {
+    run.err = cmd.Wait()
+    if run.notify != nil {
+      // 先处理退出前发送的消息（如 MAINPID=），再跟踪 fork 出的主进程；socket 在 finishRun 中关闭
+      run.notify.settle()
+      run.err = waitMainPID(run, run.err)
+    }
+    close(run.exited)
+    e.send(reconcileEvent{kind: eventExited, run: run})
+  }()
 
-    // 进程退出后清理挂载（Linux）
-    cleanupMounts(process.Spec.Mounts)
+  return nil
+}
 
-    // 应用重启策略
-    shouldRestart := false
-    switch process.Spec.RestartPolicy {
-    case models.RestartPolicyAlways:
-      shouldRestart = true
-    case models.RestartPolicyOnFailure:
-      shouldRestart = err != nil
-    case models.RestartPolicyOnSuccess:
-      shouldRestart = err == nil
-    case models.RestartPolicyNever:
-      shouldRestart = false
+// finishRun 记录当前运行实例的退出信息并清理资源（仅在 reconcile 协程中调用）。
+// stop 不为空表示进程是被信号停止的，记录使用的信号以及是否升级为 SIGKILL。
+func (m *Manager) finishRun(e *processEntry, stop *stopResult) {
+  run := e.run
+  e.run = nil
+  m.stopProbes(e)
+  m.stopWatch(e)
+  e.cancelNotifyTimer()
+
+  process := e.snapshot()
+  var info *models.TerminationInfo
+  if run != nil || stop != nil {
+    info = &models.TerminationInfo{FinishedAt: time.Now()}
+  }
+  if stop != nil {
+    info.StopSignal = stop.signal
+    info.Escalated = stop.escalated
+  }
+  pid := 0
+  if run != nil {
+    pid = run.pid
+    if run.notify != nil {
+      run.notify.close()
+    }
+    if run.stdin != nil {
+      e.attach.setStdin(nil)
+      run.stdin.Close()
+    }
+    // 如果有退出码，记录下来
+    var exitErr *exec.ExitError
+    var mainErr *mainPIDExitError
+    if errors.As(run.err, &mainErr) {
+      pid = mainErr.pid
+      info.Reason = "MainProcessExited"
+      info.Message = mainErr.Error()
+    } else if errors.As(run.err, &exitErr) {
+      if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+        info.ExitCode = status.ExitStatus()
+        if status.Signaled() {
+          info.Signal = int(status.Signal())
+        }
+      }
     }
 
-    // 检查是否达到最大重启次数
-    if shouldRestart {
-      // 应用重启间隔
-      restartInterval := process.Spec.RestartInterval
-      if restartInterval == 0 {
-        restartInterval = 5 * time.Second // 默认 5 秒
+    // 检查是否被 OOM kill，并删除本次运行的 cgroup
+    if run.cgroup != nil {
+      if run.cgroup.oomKilled() {
+        info.Reason = "OOMKilled"
+        info.Message = "process was killed by the OOM killer (memory limit exceeded)"
       }
+      run.cgroup.remove()
+    }
+  }
 
-      go func() {
-        time.Sleep(restartInterval)
-        m.StartProcess(namespace, name)
-      }()
+  e.mu.Lock()
+  e.process.Status.Phase = models.PhaseStopped
+  e.process.Status.PID = 0
+  e.process.Status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "ProcessExited", "process exited")
+  if info != nil {
+    e.process.Status.LastTerminationInfo = info
+  }
+  e.cgroup = nil
+  e.adopted = false
+  e.adoptedStartTime = 0
+  snap := e.crashSnapshot
+  e.crashSnapshot = nil
+  e.mu.Unlock()
+  m.removePIDFile(e)
+
+  if run != nil {
+    exitCode := info.ExitCode
+    message := fmt.Sprintf("exited with code %d", exitCode)
+    if info.Signal != 0 {
+      message = fmt.Sprintf("killed by signal %d", info.Signal)
     }
-  }()
+    m.recordEvent(models.ProcessEvent{
+      Namespace: process.Metadata.Namespace,
+      Name:      process.Metadata.Name,
+      Type:      models.EventExited,
+      PID:       pid,
+      ExitCode:  &exitCode,
+      Signal:    info.Signal,
+      Reason:    info.Reason,
+      Message:   message,
+    })
+    if isJob(&process) {
+      m.recordJobRun(e, run, info, stop)
+    }
+    if m.shouldReportCrash(&process, run, info, stop) {
+      m.reportCrash(e, &process, run, info, pid, snap)
+    }
+  }
 
-  return nil
+  // 进程退出后清理挂载（Linux）
+  cleanupMounts(hostMounts(&process.Spec))
+  // 执行 PostStop 钩子（在重启之前）
+  if err := m.expandEnv(&process); err != nil {
+    log.Printf("Warning: process %s: %v", e.key, err)
+  }
+  m.runPostStop(&process)
 }
 
-// StopProcess 实现ProcessManager接口，停止一个进程
-func (m *Manager) StopProcess(namespace, name string) error {
-  key := fmt.Sprintf("%s/%s", namespace, name)
-  process, exists := m.Processes[key]
-  if !exists {
-    return fmt.Errorf("进程 %s/%s 未被纳管", namespace, name)
-  }
+// stopRun 停止进程并等待其退出（仅在 reconcile 协程中调用）
+func (m *Manager) stopRun(e *processEntry) error {
+  process := e.snapshot()
+  name := process.Metadata.Name
 
-  if process.Status.Phase == models.PhaseStopped {
+  if process.Status.Phase == models.PhaseStopped || (e.run == nil && process.Status.PID == 0) {
     return fmt.Errorf("进程 %s 已经停止", name)
   }
 
   // 设置进程状态为停止中
-  process.Status.Phase = models.PhaseStopping
+  e.setPhase(models.PhaseStopping)
+  m.stopProbes(e)
+  m.stopWatch(e)
 
-  // 如果有自定义停止命令，使用它
+  // 如果有自定义停止命令，先使用它
+  stopped := false
   if process.Spec.Exec.StopCommand != nil {
-    cmd := exec.Command(process.Spec.Exec.StopCommand.Command, process.Spec.Exec.StopCommand.Args...)
-    cmd.Env = os.Environ()
-    for _, envVar := range process.Spec.Env {
-      cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", envVar.Name, envVar.Value))
+    if err := m.expandEnv(&process); err != nil {
+      log.Printf("Warning: process %s: %v", e.key, err)
     }
+    cmd := exec.Command(process.Spec.Exec.StopCommand.Command, process.Spec.Exec.StopCommand.Args...)
+    runAs, _ := resolveRunAsUser(&process.Spec)
+    cmd.Env = processEnv(&process, runAs)
+    _ = applyCredential(cmd, runAs)
 
     // 设置工作目录
     if process.Spec.WorkingDir != "" {
@@ -291,109 +643,72 @@ func (m *Manager) StopProcess(namespace, name string) error {
       done <- cmd.Run()
     }()
 
-    // 等待命令完成或超时
+    // 等待命令完成或超时，然后等待进程退出
     select {
     case err := <-done:
-      if err != nil {
-        // 停止命令失败，尝试强制终止
-        if err := m.forceStopProcess(process); err != nil {
-          return err
-        }
+      if err == nil {
+        stopped = m.waitForExit(e.run, process.Status.PID, timeout)
       }
     case <-time.After(timeout):
-      // 超时，尝试强制终止
-      if err := m.forceStopProcess(process); err != nil {
-        return err
+      if cmd.Process != nil {
+        cmd.Process.Kill()
       }
     }
-
-    process.Status.Phase = models.PhaseStopped
-    process.Status.PID = 0
-
-    // 停止后清理挂载
-    cleanupMounts(process.Spec.Mounts)
-    return nil
-  } else {
-    // 没有自定义停止命令，使用强制终止
-    if err := m.forceStopProcess(process); err != nil {
-      return err
-    }
-    // 停止后清理挂载
-    cleanupMounts(process.Spec.Mounts)
-    return nil
   }
-}
 
-// 新增一个辅助方法，用于强制终止进程
-func (m *Manager) forceStopProcess(process *models.ManagedProcess) error {
-  // 获取进程
-  sysProcess, err := os.FindProcess(process.Status.PID)
-  if err != nil {
-    return err
-  }
-
-  // 在Windows系统上，我们使用Terminate方法
-  // 在Unix/Linux系统上，我们先发送SIGTERM，然后在必要时发送SIGKILL
-
-  // 发送终止信号
-  if runtime.GOOS == "windows" {
-    if err := sysProcess.Kill(); err != nil {
-      return err
+  // 没有自定义停止命令或停止命令失败，使用信号终止
+  var stop *stopResult
+  if !stopped {
+    result, err := m.signalStop(e.run, process.Status.PID, process.Spec.Exec)
+    if result.escalated {
+      _, grace, _, _ := stopOptions(process.Spec.Exec)
+      m.emitEvent(e, models.EventKilled, "StopTimeout",
+        fmt.Sprintf("process did not exit within %s after %s, sent SIGKILL", grace, result.signal))
     }
-  } else {
-    // 先发送SIGTERM
-    if err := sysProcess.Signal(syscall.SIGTERM); err != nil {
-      // 如果失败，尝试直接杀死进程
-      if err := sysProcess.Kill(); err != nil {
-        return err
-      }
-    } else {
-      // 等待进程退出，最多等待10秒
-      timer := time.NewTimer(10 * time.Second)
-      defer timer.Stop()
-
-      // 检查进程是否还在运行
-      for {
-        select {
-        case <-timer.C:
-          // 超时，强制杀死进程
-          if err := sysProcess.Kill(); err != nil {
-            return err
-          }
-          return nil
-        default:
-          // 检查进程是否存在
-          sysProcess.Signal(syscall.Signal(0)) // 发送0信号检查进程是否存在
-          time.Sleep(100 * time.Millisecond)
-        }
-      }
+    if err != nil {
+      e.setPhase(process.Status.Phase)
+      return err
     }
+    stop = &result
   }
 
-  process.Status.Phase = models.PhaseStopped
-  process.Status.PID = 0
+  m.finishRun(e, stop)
   return nil
 }
 
-// RestartProcess 实现ProcessManager接口，重启一个进程
-func (m *Manager) RestartProcess(namespace, name string) error {
-  // 先停止进程
-  if err := m.StopProcess(namespace, name); err != nil {
-    // 如果进程已经停止，继续启动
-    if !errors.Is(err, fmt.Errorf("进程 %s/%s 已经停止", namespace, name)) {
-      return err
+// waitForExit 等待进程退出，返回是否在超时前退出
+func (m *Manager) waitForExit(run *processRun, pid int, timeout time.Duration) bool {
+  if run != nil {
+    select {
+    case <-run.exited:
+      return true
+    case <-time.After(timeout):
+      return false
     }
   }
 
-  // 等待一段时间
-  time.Sleep(1 * time.Second)
+  // 重关联的进程不是 vigil 的子进程，只能轮询
+  deadline := time.Now().Add(timeout)
+  for time.Now().Before(deadline) {
+    if !pidAlive(pid) {
+      return true
+    }
+    time.Sleep(100 * time.Millisecond)
+  }
+  return !pidAlive(pid)
+}
 
-  // 然后启动进程
-  return m.StartProcess(namespace, name)
+// pidAlive 检查进程是否存在
+func pidAlive(pid int) bool {
+  if pid <= 0 {
+    return false
+  }
+  exists, err := process.PidExists(int32(pid))
+  return err == nil && exists
 }
 
 // Linux 下应用挂载（bind/tmpfs/named）
-func applyMounts(mounts []models.Mount) error {
+func applyMounts(mounts []models.Mount, runAs *runAsUser) error {
   if len(mounts) == 0 || runtime.GOOS != "linux" {
     return nil
   }
@@ -421,6 +736,9 @@ func applyMounts(mounts []models.Mount) error {
       }
       if m.UID != 0 || m.GID != 0 {
         _ = os.Chown(m.Target, m.UID, m.GID)
+      } else if runAs != nil {
+        // 未显式指定所有者时交给进程的运行用户
+        runAs.chown(m.Target)
       }
     }
 
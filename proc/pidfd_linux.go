@@ -0,0 +1,45 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build linux
+
+package proc
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// waitProcessExit 等待非子进程 pid 退出。优先使用 pidfd（Linux 5.3+），进程退出时 pidfd 变为可读；
+// 内核不支持时退回轮询
+func waitProcessExit(pid int, startTime int64) {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		pollProcessExit(pid, startTime)
+		return
+	}
+	defer unix.Close(fd)
+
+	// 打开 pidfd 之前 PID 可能已经被复用，确认仍是同一个进程
+	if st, err := processStartTime(pid); err != nil || st != startTime {
+		return
+	}
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		if _, err := unix.Poll(fds, -1); err != unix.EINTR {
+			return
+		}
+	}
+}
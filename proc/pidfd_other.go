@@ -0,0 +1,24 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !linux
+
+package proc
+
+// waitProcessExit 等待非子进程 pid 退出，非 Linux 平台上没有 pidfd，只能轮询
+func waitProcessExit(pid int, startTime int64) {
+	pollProcessExit(pid, startTime)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// adoptedPollInterval 是无法使用 pidfd 时检查重关联进程是否退出的周期
+const adoptedPollInterval = time.Second
+
+// errAdoptedExited 是重关联进程退出时的错误。进程不是 vigil 的子进程，拿不到退出码，按失败处理
+var errAdoptedExited = errors.New("adopted process exited")
+
+// pidFile 是 vigil 为每个运行中的进程写入的 PID 文件：第一行是 PID，第二行是进程的启动时间（Unix 毫秒）。
+// 启动时间作为指纹，PID 被复用时不会重关联到其他进程
+type pidFile struct {
+	pid       int
+	startTime int64
+}
+
+// SetPIDDir 设置进程 PID 文件的目录（process.pid_dir），为空时不写 PID 文件
+func (m *Manager) SetPIDDir(dir string) {
+	m.pidDir = dir
+}
+
+// pidFilePath 返回进程的 PID 文件路径，未设置目录时返回空
+func (m *Manager) pidFilePath(e *processEntry) string {
+	if m.pidDir == "" {
+		return ""
+	}
+	namespace, name, _ := strings.Cut(e.key, "/")
+	return filepath.Join(m.pidDir, namespace, name+".pid")
+}
+
+// processStartTime 返回进程的启动时间（Unix 毫秒）
+func processStartTime(pid int) (int64, error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// readPIDFile 读取 PID 文件
+func readPIDFile(path string) (pidFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pidFile{}, err
+	}
+	lines := strings.Fields(string(data))
+	if len(lines) != 2 {
+		return pidFile{}, fmt.Errorf("invalid pid file %s", path)
+	}
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil || pid <= 0 {
+		return pidFile{}, fmt.Errorf("invalid pid in %s: %q", path, lines[0])
+	}
+	startTime, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return pidFile{}, fmt.Errorf("invalid start time in %s: %q", path, lines[1])
+	}
+	return pidFile{pid: pid, startTime: startTime}, nil
+}
+
+// writePIDFile 写入进程的 PID 文件，先写临时文件再改名，读取方不会看到写了一半的内容
+func (m *Manager) writePIDFile(e *processEntry, pid int, startTime int64) {
+	path := m.pidFilePath(e)
+	if path == "" {
+		return
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, []byte(fmt.Sprintf("%d\n%d\n", pid, startTime)), 0644); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		log.Printf("Warning: failed to write pid file of process %s: %v", e.key, err)
+	}
+}
+
+// removePIDFile 删除进程的 PID 文件
+func (m *Manager) removePIDFile(e *processEntry) {
+	if path := m.pidFilePath(e); path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to remove pid file of process %s: %v", e.key, err)
+		}
+	}
+}
+
+// recordPID 为 vigil 启动的进程写入 PID 文件，Job 的运行不做重关联，不写 PID 文件
+func (m *Manager) recordPID(e *processEntry, pid int) {
+	if m.pidDir == "" || e.isJob() {
+		return
+	}
+	startTime, err := processStartTime(pid)
+	if err != nil {
+		log.Printf("Warning: failed to read start time of process %s (pid %d): %v", e.key, pid, err)
+		return
+	}
+	m.writePIDFile(e, pid, startTime)
+}
+
+// recoverProcesses 在 vigil 启动时按 PID 文件重关联仍在运行的进程。
+// 只有 PID 和启动时间都一致时才重关联，失效的 PID 文件被删除
+func (m *Manager) recoverProcesses() {
+	for _, e := range m.runEntries() {
+		if e.isJob() {
+			continue
+		}
+		if err := e.do(reconcileEvent{kind: eventRecover}); err != nil {
+			log.Printf("Failed to recover process %s: %v", e.key, err)
+		}
+	}
+}
+
+// adoptFromPIDFile 按 PID 文件重关联进程，返回是否重关联（仅在 reconcile 协程中调用）
+func (m *Manager) adoptFromPIDFile(e *processEntry) bool {
+	path := m.pidFilePath(e)
+	if path == "" {
+		return false
+	}
+	pf, err := readPIDFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: process %s: %v", e.key, err)
+			m.removePIDFile(e)
+		}
+		return false
+	}
+	if startTime, err := processStartTime(pf.pid); err != nil || startTime != pf.startTime {
+		log.Printf("Process %s: pid file %s is stale (pid %d is gone or was reused)", e.key, path, pf.pid)
+		m.removePIDFile(e)
+		return false
+	}
+	m.adopt(e, pf.pid, pf.startTime, fmt.Sprintf("adopted running process %d from pid file", pf.pid))
+	return true
+}
+
+// adopt 把进程标记为运行中的重关联进程，并跟踪它的退出（仅在 reconcile 协程中调用）。
+// startTime 为 0 表示无法读取进程的启动时间，由采样器按进程特征检查进程是否仍然存在
+func (m *Manager) adopt(e *processEntry, pid int, startTime int64, message string) {
+	now := time.Now()
+	e.mu.Lock()
+	e.process.Status.PID = pid
+	e.process.Status.Phase = models.PhaseRunning
+	e.process.Status.StartTime = &now
+	if startTime > 0 {
+		t := time.UnixMilli(startTime)
+		e.process.Status.StartTime = &t
+	}
+	e.adopted = true
+	e.adoptedStartTime = startTime
+	e.mu.Unlock()
+	e.runStarted = now
+	m.emitEvent(e, models.EventReAssociated, "", message)
+	m.startProbes(e)
+	m.startWatch(e)
+
+	if startTime > 0 {
+		m.writePIDFile(e, pid, startTime)
+		go func() {
+			waitProcessExit(pid, startTime)
+			e.send(reconcileEvent{kind: eventAdoptedExited, pid: pid})
+		}()
+	}
+}
+
+// loseAdopted 重关联的进程 pid 已退出时把进程标记为停止，返回是否处理（仅在 reconcile 协程中调用）
+func (m *Manager) loseAdopted(e *processEntry, pid int, message string) bool {
+	e.mu.Lock()
+	lost := e.adopted && e.process.Status.PID == pid
+	if lost {
+		e.process.Status.Phase = models.PhaseStopped
+		e.process.Status.PID = 0
+		e.process.Status.LastTerminationInfo = &models.TerminationInfo{
+			FinishedAt: time.Now(),
+			Reason:     "ProcessLost",
+			Message:    message,
+		}
+		e.adopted = false
+		e.adoptedStartTime = 0
+	}
+	e.mu.Unlock()
+	if !lost {
+		return false
+	}
+	m.stopProbes(e)
+	m.stopWatch(e)
+	m.removePIDFile(e)
+	e.setCondition(models.ConditionTypeReady, models.ConditionFalse, "ProcessLost", "process is no longer running")
+	mp := e.snapshot()
+	m.recordEvent(models.ProcessEvent{
+		Namespace: mp.Metadata.Namespace,
+		Name:      mp.Metadata.Name,
+		Type:      models.EventExited,
+		PID:       pid,
+		Reason:    "ProcessLost",
+		Message:   message,
+	})
+	return true
+}
+
+// pollProcessExit 轮询直到进程 pid 退出或被复用（启动时间变化）
+func pollProcessExit(pid int, startTime int64) {
+	for {
+		if st, err := processStartTime(pid); err != nil || st != startTime {
+			return
+		}
+		time.Sleep(adoptedPollInterval)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestManagerAdoptFromPIDFile(t *testing.T) {
+	pidDir := t.TempDir()
+	dir := t.TempDir()
+	m := newTestManager(t)
+	m.SetPIDDir(pidDir)
+
+	p := testProcess(dir, "sleeper")
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "sleeper"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "sleeper")
+	pid := status.Status.PID
+	pf, err := readPIDFile(filepath.Join(pidDir, "test", "sleeper.pid"))
+	if startTime, _ := processStartTime(pid); err != nil || pf.pid != pid || pf.startTime != startTime {
+		t.Fatalf("pid file = %+v, %v, want pid %d started at %d", pf, err, pid, startTime)
+	}
+
+	// 模拟 vigil 重启：新的 Manager 按 PID 文件重关联同一个进程
+	restarted := newTestManager(t)
+	restarted.SetPIDDir(pidDir)
+	stale := testProcess(dir, "stale")
+	stalePath := filepath.Join(pidDir, "test", "stale.pid")
+	if err := os.WriteFile(stalePath, []byte(fmt.Sprintf("%d\n1\n", os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []models.ManagedProcess{p, stale} {
+		if _, err := restarted.addEntry(p); err != nil {
+			t.Fatalf("addEntry: %v", err)
+		}
+	}
+	restarted.recoverProcesses()
+
+	status, _ = restarted.GetProcessStatus("test", "sleeper")
+	if status.Status.Phase != models.PhaseRunning || status.Status.PID != pid {
+		t.Fatalf("after recovery: %s pid %d, want Running pid %d", status.Status.Phase, status.Status.PID, pid)
+	}
+	events, _ := restarted.ListEvents("test", "sleeper", 0, 0)
+	if got := fmt.Sprint(eventTypes(events)); got != "[ReAssociated]" {
+		t.Fatalf("events = %s, want [ReAssociated]", got)
+	}
+	// PID 存在但启动时间不一致（PID 被复用），不重关联并删除 PID 文件
+	if status, _ = restarted.GetProcessStatus("test", "stale"); status.Status.Phase == models.PhaseRunning {
+		t.Fatalf("stale pid file was adopted: %+v", status.Status)
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("stale pid file was not removed: %v", err)
+	}
+
+	// 重关联的进程退出后感知到退出
+	if sleeper, err := os.FindProcess(pid); err != nil || sleeper.Kill() != nil {
+		t.Fatalf("failed to kill %d", pid)
+	}
+	status = waitForPhase(t, restarted, "sleeper", models.PhaseStopped)
+	if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "ProcessLost" {
+		t.Fatalf("termination info = %+v, want ProcessLost", info)
+	}
+	// Exited 事件在进入 Stopped 之后记录
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, _ = restarted.ListEvents("test", "sleeper", 0, 0)
+		got := fmt.Sprint(eventTypes(events))
+		if got == "[ReAssociated Exited]" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("events = %s, want [ReAssociated Exited]", got)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
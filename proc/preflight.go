@@ -0,0 +1,484 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/casuallc/vigil/docker"
+	"github.com/casuallc/vigil/models"
+	gnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var (
+	// envNamePattern 是 shell 可以引用的环境变量名
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// envRefPattern 匹配 ${...} 形式的变量引用，vigil 只展开其中的 ${secret:name}
+	envRefPattern = regexp.MustCompile(`\$\{[^}]*\}`)
+)
+
+// mountPropagations 是 mount --make-<propagation> 支持的传播选项
+var mountPropagations = map[string]bool{
+	"shared": true, "rshared": true, "slave": true, "rslave": true,
+	"private": true, "rprivate": true, "unbindable": true, "runbindable": true,
+}
+
+// preflightReport 收集预检发现的问题
+type preflightReport struct {
+	// instance 是正在检查的 Replicas 实例
+	instance string
+	findings []models.PreflightFinding
+}
+
+func (r *preflightReport) errorf(field, format string, args ...interface{}) {
+	r.findings = append(r.findings, models.PreflightFinding{
+		Severity: models.FindingError, Field: field, Instance: r.instance, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *preflightReport) warnf(field, format string, args ...interface{}) {
+	r.findings = append(r.findings, models.PreflightFinding{
+		Severity: models.FindingWarning, Field: field, Instance: r.instance, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Preflight 检查进程定义能否在本机启动：先做与创建和修改相同的校验，再检查可执行文件、工作目录、
+// 日志目录、挂载、环境变量和健康检查端口。只检查不做任何修改，mp 不需要已被纳管
+func (m *Manager) Preflight(mp models.ManagedProcess) models.PreflightResult {
+	r := &preflightReport{}
+	if err := ValidateProcess(&mp); err != nil {
+		r.errorf("spec", "%s", strings.TrimPrefix(err.Error(), ErrInvalidProcess.Error()+": "))
+	}
+
+	// 进程正在运行时端口由它自己占用
+	running := false
+	if e, ok := m.getEntry(mp.Metadata.Namespace, mp.Metadata.Name); ok {
+		running = isActivePhase(e.snapshot().Status.Phase)
+	}
+	if mp.Spec.Replicas == nil {
+		m.preflightRun(r, &mp, running)
+	}
+	for i := 0; mp.Spec.Replicas != nil && i < *mp.Spec.Replicas; i++ {
+		inst, err := renderInstance(&mp, i)
+		if err != nil {
+			// 已由 ValidateProcess 报告
+			break
+		}
+		r.instance = inst.Metadata.Name
+		m.preflightRun(r, &inst, running)
+	}
+
+	result := models.PreflightResult{
+		Namespace: mp.Metadata.Namespace,
+		Name:      mp.Metadata.Name,
+		Passed:    true,
+		Findings:  r.findings,
+	}
+	if result.Findings == nil {
+		result.Findings = []models.PreflightFinding{}
+	}
+	for _, f := range result.Findings {
+		if f.Severity == models.FindingError {
+			result.Passed = false
+		}
+	}
+	return result
+}
+
+// preflightRun 检查一个运行实例的运行环境
+func (m *Manager) preflightRun(r *preflightReport, mp *models.ManagedProcess, running bool) {
+	// 运行用户无法解析时已由 ValidateProcess 报告，按 vigil 自身身份继续检查
+	runAs, _ := resolveRunAsUser(&mp.Spec)
+	preflightExecutable(r, mp, runAs)
+	preflightWorkingDir(r, mp, runAs)
+	preflightLogDir(r, mp)
+	m.preflightEnv(r, mp)
+	preflightMounts(r, mp)
+	if !running {
+		preflightPorts(r, mp)
+	}
+	preflightWatch(r, mp)
+	preflightCrash(r, mp)
+}
+
+// describeRunAs 返回运行用户的描述
+func describeRunAs(runAs *runAsUser) string {
+	if runAs == nil {
+		return "the vigil user"
+	}
+	return fmt.Sprintf("user %s (uid %d, gid %d)", runAs.Username, runAs.UID, runAs.GID)
+}
+
+// preflightExecutable 检查可执行文件存在并且运行用户可以执行。
+// 不含路径分隔符的命令与启动时一样在 vigil 的 PATH 中查找，相对路径基于工作目录
+func preflightExecutable(r *preflightReport, mp *models.ManagedProcess, runAs *runAsUser) {
+	const field = "spec.exec.command"
+	command := mp.Spec.Exec.Command
+	if command == "" {
+		r.errorf(field, "command is required")
+		return
+	}
+	path := command
+	if !strings.ContainsAny(command, `/\`) {
+		resolved, err := exec.LookPath(command)
+		if err != nil {
+			r.errorf(field, "%s not found in PATH", command)
+			return
+		}
+		path = resolved
+	} else if !filepath.IsAbs(command) {
+		path = filepath.Join(processWorkingDir(mp), command)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		r.errorf(field, "%v", err)
+		return
+	}
+	if info.IsDir() {
+		r.errorf(field, "%s is a directory", path)
+		return
+	}
+	if !fileAccessible(info, runAs, 1) {
+		r.errorf(field, "%s is not executable by %s", path, describeRunAs(runAs))
+	}
+}
+
+// preflightWorkingDir 检查工作目录存在并且运行用户可以进入
+func preflightWorkingDir(r *preflightReport, mp *models.ManagedProcess, runAs *runAsUser) {
+	const field = "spec.working_dir"
+	if mp.Spec.WorkingDir == "" {
+		return
+	}
+	info, err := os.Stat(mp.Spec.WorkingDir)
+	switch {
+	case err != nil:
+		r.errorf(field, "%v", err)
+	case !info.IsDir():
+		r.errorf(field, "%s is not a directory", mp.Spec.WorkingDir)
+	case !fileAccessible(info, runAs, 1):
+		r.errorf(field, "%s is not accessible by %s", mp.Spec.WorkingDir, describeRunAs(runAs))
+	}
+}
+
+// preflightLogDir 检查 vigil 可以在日志目录中写日志，目录不存在时检查能否创建
+func preflightLogDir(r *preflightReport, mp *models.ManagedProcess) {
+	const field = "spec.log.dir"
+	dir := processLogDir(mp)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				r.errorf(field, "%s is not a directory", dir)
+			} else if !fileAccessible(info, nil, 3) {
+				r.errorf(field, "%s is not writable by the vigil user", dir)
+			}
+			return
+		}
+		if !os.IsNotExist(err) {
+			r.errorf(field, "%v", err)
+			return
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
+// preflightEnv 检查环境变量名和值的写法、引用的密钥以及 EnvFrom 文件
+func (m *Manager) preflightEnv(r *preflightReport, mp *models.ManagedProcess) {
+	seen := map[string]bool{}
+	for i, env := range mp.Spec.Env {
+		field := fmt.Sprintf("spec.env[%d]", i)
+		if env.Name != "" && !envNamePattern.MatchString(env.Name) {
+			r.warnf(field, "%q is not a valid shell variable name, shells and most programs cannot read it", env.Name)
+		}
+		if seen[env.Name] {
+			r.warnf(field, "%s is set more than once, the last value is used", env.Name)
+		}
+		seen[env.Name] = true
+
+		for _, match := range secretRefPattern.FindAllStringSubmatch(env.Value, -1) {
+			if !secretNamePattern.MatchString(match[1]) {
+				continue
+			}
+			if _, err := m.resolveSecret(match[1]); err != nil {
+				r.errorf(field, "%s: %v", env.Name, err)
+			}
+		}
+		rest := secretRefPattern.ReplaceAllString(env.Value, "")
+		for _, ref := range envRefPattern.FindAllString(rest, -1) {
+			r.warnf(field, "%s: %s is passed literally, only ${secret:name} is expanded", env.Name, ref)
+		}
+		if strings.Contains(envRefPattern.ReplaceAllString(rest, ""), "${") {
+			r.warnf(field, "%s: unterminated ${ in value", env.Name)
+		}
+	}
+
+	for i, src := range mp.Spec.EnvFrom {
+		field := fmt.Sprintf("spec.env_from[%d]", i)
+		if src.File == "" {
+			continue
+		}
+		path := src.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(processWorkingDir(mp), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			if !src.Optional || !os.IsNotExist(err) {
+				r.errorf(field, "%v", err)
+			}
+			continue
+		}
+		vars, err := docker.LoadEnvFile(path)
+		if err != nil {
+			r.errorf(field, "%s: %v", src.File, err)
+			continue
+		}
+		for name := range vars {
+			if !envNamePattern.MatchString(src.Prefix + name) {
+				r.warnf(field, "%s: %q is not a valid shell variable name", src.File, src.Prefix+name)
+			}
+		}
+	}
+}
+
+// preflightMounts 检查挂载的类型、源、目标和传播选项（挂载只在 Linux 下生效）
+func preflightMounts(r *preflightReport, mp *models.ManagedProcess) {
+	if len(mp.Spec.Mounts) == 0 {
+		return
+	}
+	if runtime.GOOS != "linux" {
+		r.warnf("spec.mounts", "mounts are only applied on Linux and are ignored on %s", runtime.GOOS)
+		return
+	}
+	if os.Geteuid() != 0 {
+		r.errorf("spec.mounts", "mounts require vigil to run as root")
+	}
+	if _, err := exec.LookPath("mount"); err != nil {
+		r.errorf("spec.mounts", "mount not found in PATH")
+	}
+
+	for i, mt := range mp.Spec.Mounts {
+		field := fmt.Sprintf("spec.mounts[%d]", i)
+		var source os.FileInfo
+		switch mt.Type {
+		case "", "bind":
+			if mt.Source == "" {
+				r.errorf(field+".source", "bind mount requires source")
+				break
+			}
+			info, err := os.Stat(mt.Source)
+			if err != nil {
+				r.errorf(field+".source", "%v", err)
+				break
+			}
+			source = info
+		case "tmpfs":
+		case "named":
+			if mt.Name == "" {
+				r.errorf(field+".name", "named volume requires name")
+			}
+		default:
+			r.errorf(field+".type", "unsupported mount type %q (bind, tmpfs or named)", mt.Type)
+		}
+
+		if mt.Target == "" {
+			r.errorf(field+".target", "target is required")
+		} else if info, err := os.Stat(mt.Target); err == nil {
+			if source != nil && source.IsDir() != info.IsDir() {
+				r.errorf(field+".target", "cannot bind mount %s onto %s: one is a directory and the other is not", mt.Source, mt.Target)
+			}
+		} else if !os.IsNotExist(err) {
+			r.errorf(field+".target", "%v", err)
+		} else if !mt.CreateTarget {
+			r.errorf(field+".target", "%s does not exist, set create_target to create it", mt.Target)
+		}
+		if mt.Mode != "" {
+			if _, err := ParseFileMode(mt.Mode); err != nil {
+				r.errorf(field+".mode", "invalid mode %q", mt.Mode)
+			}
+		}
+
+		if mt.Propagation == "" {
+			continue
+		}
+		switch {
+		case !mountPropagations[mt.Propagation]:
+			r.errorf(field+".propagation", "unsupported propagation %q", mt.Propagation)
+		case mp.Spec.Isolation != nil:
+			r.warnf(field+".propagation", "mounts of an isolated process are private to its mount namespace, %s has no effect on the host", mt.Propagation)
+		case strings.HasSuffix(mt.Propagation, "slave") && mt.Source != "" && !mountIsShared(mt.Source):
+			r.warnf(field+".propagation", "the mount containing %s is not shared, %s propagation receives no mount events", mt.Source, mt.Propagation)
+		}
+	}
+}
+
+// mountIsShared 包含 path 的挂载点是否为 shared（按 /proc/self/mountinfo 判断，无法判断时返回 true）
+func mountIsShared(path string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	best, shared := "", true
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 shared:2 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		point := fields[4]
+		if !(path == point || point == "/" || strings.HasPrefix(path, point+"/")) || len(point) < len(best) {
+			continue
+		}
+		best, shared = point, false
+		for _, opt := range fields[6:] {
+			if opt == "-" {
+				break
+			}
+			if strings.HasPrefix(opt, "shared:") {
+				shared = true
+			}
+		}
+	}
+	return shared
+}
+
+// preflightPorts 检查健康检查的端口没有被其他进程占用，否则健康检查探测的是别的进程
+func preflightPorts(r *preflightReport, mp *models.ManagedProcess) {
+	hc := mp.Spec.HealthCheck
+	if hc == nil || (mp.Spec.Isolation != nil && mp.Spec.Isolation.Network) {
+		return
+	}
+	type probePort struct {
+		field string
+		port  int
+	}
+	var ports []probePort
+	if hc.TCP != nil {
+		ports = append(ports, probePort{"spec.health_check.tcp.port", hc.TCP.Port})
+	}
+	if hc.HTTP != nil {
+		ports = append(ports, probePort{"spec.health_check.http.port", hc.HTTP.Port})
+	}
+	for _, p := range ports {
+		if p.port < 1 || p.port > 65535 {
+			continue
+		}
+		// 健康检查连接的是 127.0.0.1
+		ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(p.port)))
+		if err == nil {
+			ln.Close()
+			continue
+		}
+		owner := portOwner(p.port)
+		if owner == "" && !errors.Is(err, syscall.EADDRINUSE) {
+			// 如非 root 无法监听特权端口，无法判断是否被占用
+			continue
+		}
+		r.errorf(p.field, "port %d is already in use%s", p.port, owner)
+	}
+}
+
+// portOwner 返回监听 TCP 端口的进程描述，如 " by pid 1234 (nginx)"，找不到时返回空
+func portOwner(port int) string {
+	conns, err := gnet.Connections("tcp")
+	if err != nil {
+		return ""
+	}
+	for _, c := range conns {
+		if c.Status != "LISTEN" || int(c.Laddr.Port) != port || c.Pid <= 0 {
+			continue
+		}
+		if p, err := process.NewProcess(c.Pid); err == nil {
+			if name, err := p.Name(); err == nil {
+				return fmt.Sprintf(" by pid %d (%s)", c.Pid, name)
+			}
+		}
+		return fmt.Sprintf(" by pid %d", c.Pid)
+	}
+	return ""
+}
+
+// preflightWatch 检查监视的目录存在，不存在的目录不会被监视
+func preflightWatch(r *preflightReport, mp *models.ManagedProcess) {
+	if mp.Spec.Watch == nil {
+		return
+	}
+	for i, pattern := range watchPatterns(mp) {
+		field := fmt.Sprintf("spec.watch.paths[%d]", i)
+		dir := filepath.Dir(pattern)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			r.warnf(field, "directory %s does not exist, changes in it are not watched", dir)
+		}
+	}
+}
+
+// preflightCrash 检查 core dump 会被写入 OnCrash.CoreDir（Linux 按 kernel.core_pattern 判断）
+func preflightCrash(r *preflightReport, mp *models.ManagedProcess) {
+	if mp.Spec.OnCrash == nil || mp.Spec.OnCrash.CoreDir == "" {
+		return
+	}
+	field := "spec.on_crash.core_dir"
+	dir := filepath.Clean(mp.Spec.OnCrash.CoreDir)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		r.warnf(field, "directory %s does not exist, core dumps are not collected", dir)
+		return
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	data, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		return
+	}
+	pattern := strings.TrimSpace(string(data))
+	if strings.HasPrefix(pattern, "|") {
+		r.warnf(field, "kernel.core_pattern pipes core dumps to a program (%s), they are not written to %s", pattern, dir)
+		return
+	}
+	if !strings.Contains(pattern, "%p") {
+		if usesPID, _ := os.ReadFile("/proc/sys/kernel/core_uses_pid"); strings.TrimSpace(string(usesPID)) != "1" {
+			r.warnf(field, "kernel.core_pattern %q does not include the pid (%%p), core dumps cannot be matched to the process", pattern)
+		}
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(processWorkingDir(mp), pattern)
+	}
+	if coreDir := filepath.Dir(pattern); coreDir != dir {
+		r.warnf(field, "kernel.core_pattern writes core dumps to %s, not %s", coreDir, dir)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// preflightFindings 返回 "severity field[ instance]" 列表
+func preflightFindings(result models.PreflightResult) []string {
+	var findings []string
+	for _, f := range result.Findings {
+		finding := string(f.Severity) + " " + f.Field
+		if f.Instance != "" {
+			finding += " " + f.Instance
+		}
+		findings = append(findings, finding)
+	}
+	sort.Strings(findings)
+	return findings
+}
+
+func TestManagerPreflight(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	if result := m.Preflight(testProcess(dir, "ok")); !result.Passed || len(result.Findings) != 0 {
+		t.Fatalf("Preflight of a valid process = %+v", result)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	script := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("GOOD=1\nbad-name=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProcess(dir, "broken")
+	p.Spec.Exec.Command = "./run.sh"
+	p.Spec.Env = []models.EnvVar{
+		{Name: "HOME_DIR", Value: "${HOME}/data"},
+		{Name: "my-var", Value: "x"},
+		{Name: "HOME_DIR", Value: "${secret:db-password}"},
+		{Name: "BROKEN", Value: "${PATH"},
+	}
+	p.Spec.EnvFrom = []models.EnvFromSource{{File: "app.env"}, {File: "missing.env"}, {File: "optional.env", Optional: true}}
+	p.Spec.HealthCheck = &models.HealthCheck{TCP: &models.TCPProbe{Port: port}}
+	p.Spec.Watch = &models.WatchConfig{Paths: []string{"conf/app.yaml"}}
+	result := m.Preflight(p)
+	want := []string{
+		"error spec.env[2]",
+		"error spec.exec.command",
+		"error spec.health_check.tcp.port",
+		"error spec.env_from[1]",
+		"warning spec.env[0]",
+		"warning spec.env[1]",
+		"warning spec.env[2]",
+		"warning spec.env[3]",
+		"warning spec.env_from[0]",
+		"warning spec.watch.paths[0]",
+	}
+	sort.Strings(want)
+	if got := preflightFindings(result); result.Passed || strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("findings = %v (passed %t), want %v\n%+v", got, result.Passed, want, result.Findings)
+	}
+	for _, f := range result.Findings {
+		if f.Field == "spec.exec.command" && !strings.Contains(f.Message, "not executable") {
+			t.Fatalf("exec finding = %q", f.Message)
+		}
+	}
+
+	// 校验错误同样报告，Replicas 进程按实例检查
+	replicas := 2
+	r := testProcess(dir, "web")
+	r.Spec.Replicas = &replicas
+	r.Spec.WorkingDir = filepath.Join(dir, "{{.Name}}")
+	if err := os.Mkdir(filepath.Join(dir, "web-0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r.Spec.HealthCheck = &models.HealthCheck{HTTP: &models.HTTPProbe{Port: 70000}}
+	result = m.Preflight(r)
+	if got, want := strings.Join(preflightFindings(result), ","), "error spec,error spec.working_dir web-1"; got != want {
+		t.Fatalf("findings = %s, want %s\n%+v", got, want, result.Findings)
+	}
+
+	// 运行中的进程占用自己的健康检查端口
+	running := testProcess(dir, "running")
+	running.Spec.HealthCheck = &models.HealthCheck{TCP: &models.TCPProbe{Port: port}}
+	if err := m.CreateProcess(running); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "running"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if result := m.Preflight(running); !result.Passed || len(result.Findings) != 0 {
+		t.Fatalf("Preflight of a running process = %+v", result)
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// 健康检查默认值（与 Kubernetes probe 保持一致）
+const (
+	defaultProbePeriod           = 10 * time.Second
+	defaultProbeTimeout          = 1 * time.Second
+	defaultProbeFailureThreshold = 3
+	// 探针输出写入条件 Message 时的最大长度
+	maxProbeMessageLen = 256
+)
+
+// startProbes 为运行中的进程启动健康检查协程，已有的探针协程会先被停止（仅在 reconcile 协程中调用）
+func (m *Manager) startProbes(e *processEntry) {
+	m.stopProbes(e)
+
+	mp := e.snapshot()
+	hc := mp.Spec.HealthCheck
+	if hc != nil && hc.Exec != nil {
+		// exec 探针使用与进程相同的环境变量
+		if err := m.expandEnv(&mp); err != nil {
+			log.Printf("Warning: process %s: %v", e.key, err)
+		}
+	}
+	e.mu.Lock()
+	gen := e.probeGen
+	if hc == nil || (hc.Exec == nil && hc.TCP == nil && hc.HTTP == nil) {
+		// 未配置健康检查：进程启动即视为就绪
+		e.process.Status.SetCondition(models.ConditionTypeReady, models.ConditionTrue, "Started", "process started")
+		e.mu.Unlock()
+		return
+	}
+	e.process.Status.SetCondition(models.ConditionTypeHealthy, models.ConditionUnknown, "ProbePending", "waiting for first health check")
+	e.process.Status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "ProbePending", "waiting for first health check")
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.probeCancel = cancel
+	go m.runProbes(ctx, e, gen, mp)
+}
+
+// stopProbes 停止进程的健康检查协程，并使其尚未写入的结果失效（仅在 reconcile 协程中调用）
+func (m *Manager) stopProbes(e *processEntry) {
+	if e.probeCancel != nil {
+		e.probeCancel()
+		e.probeCancel = nil
+	}
+	e.mu.Lock()
+	e.probeGen++
+	e.mu.Unlock()
+}
+
+// setProbeConditions 写入探针结论，探针已过期时忽略
+func (e *processEntry) setProbeConditions(gen uint64, status models.ConditionStatus, reason, message string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if gen != e.probeGen {
+		return false
+	}
+	e.process.Status.SetCondition(models.ConditionTypeHealthy, status, reason, message)
+	e.process.Status.SetCondition(models.ConditionTypeReady, status, reason, message)
+	return true
+}
+
+// runProbes 按 PeriodSeconds 周期执行探针，连续失败达到 FailureThreshold 时通知 reconcile 协程按重启策略处理
+func (m *Manager) runProbes(ctx context.Context, e *processEntry, gen uint64, mp models.ManagedProcess) {
+	hc := mp.Spec.HealthCheck
+
+	period := time.Duration(hc.PeriodSeconds) * time.Second
+	if period <= 0 {
+		period = defaultProbePeriod
+	}
+	threshold := int(hc.FailureThreshold)
+	if threshold <= 0 {
+		threshold = defaultProbeFailureThreshold
+	}
+
+	// 初始延迟
+	if hc.InitialDelaySeconds > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(hc.InitialDelaySeconds) * time.Second):
+		}
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		err := RunHealthCheck(ctx, &mp, hc)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			failures = 0
+			e.setProbeConditions(gen, models.ConditionTrue, "ProbeSucceeded", "")
+		} else {
+			failures++
+			msg := fmt.Sprintf("health check failed (%d/%d): %v", failures, threshold, err)
+			if failures < threshold {
+				log.Printf("Process %s %s", e.key, msg)
+			} else {
+				if !e.setProbeConditions(gen, models.ConditionFalse, "ProbeFailed", msg) {
+					return
+				}
+				m.emitEvent(e, models.EventProbeFailed, "Unhealthy", msg)
+				if restartOnUnhealthy(mp.Spec.RestartPolicy) {
+					e.send(reconcileEvent{kind: eventProbeFailed, gen: gen, message: msg})
+					return
+				}
+				// 重启策略不允许重启时，保持探测以便进程恢复后重新标记为健康
+				failures = 0
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// restartOnUnhealthy 判断健康检查失败后是否需要重启；不健康视为一次失败
+func restartOnUnhealthy(policy models.RestartPolicy) bool {
+	switch policy {
+	case models.RestartPolicyAlways, models.RestartPolicyOnFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateHealthCheck 校验健康检查的命令和端口
+func validateHealthCheck(hc *models.HealthCheck) error {
+	if hc == nil {
+		return nil
+	}
+	if hc.Exec != nil && hc.Exec.Command == "" {
+		return fmt.Errorf("health_check.exec: command is required")
+	}
+	if hc.TCP != nil && (hc.TCP.Port < 1 || hc.TCP.Port > 65535) {
+		return fmt.Errorf("health_check.tcp: invalid port %d", hc.TCP.Port)
+	}
+	if hc.HTTP != nil && (hc.HTTP.Port < 1 || hc.HTTP.Port > 65535) {
+		return fmt.Errorf("health_check.http: invalid port %d", hc.HTTP.Port)
+	}
+	return nil
+}
+
+// RunHealthCheck 执行一次健康检查，依次运行已配置的 Exec/TCP/HTTP 探针，任一失败即返回错误
+func RunHealthCheck(ctx context.Context, mp *models.ManagedProcess, hc *models.HealthCheck) error {
+	timeout := time.Duration(hc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	if hc.Exec != nil {
+		if err := execProbe(ctx, mp, hc.Exec, timeout); err != nil {
+			return err
+		}
+	}
+	if hc.TCP != nil {
+		if err := tcpProbe(ctx, hc.TCP, timeout); err != nil {
+			return err
+		}
+	}
+	if hc.HTTP != nil {
+		if err := httpProbe(ctx, hc.HTTP, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execProbe 在进程的环境变量和工作目录下执行命令，退出码为 0 视为成功
+func execProbe(ctx context.Context, mp *models.ManagedProcess, cc *models.CommandConfig, timeout time.Duration) error {
+	if cc.Timeout > 0 {
+		timeout = cc.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	runAs, err := resolveRunAsUser(&mp.Spec)
+	if err != nil {
+		return fmt.Errorf("exec probe failed: %v", err)
+	}
+
+	cmd := exec.CommandContext(ctx, cc.Command, cc.Args...)
+	cmd.Env = processEnv(mp, runAs)
+	if mp.Spec.WorkingDir != "" {
+		cmd.Dir = mp.Spec.WorkingDir
+	}
+	if err := applyCredential(cmd, runAs); err != nil {
+		return fmt.Errorf("exec probe failed: %v", err)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("exec probe timed out after %v", timeout)
+		}
+		return fmt.Errorf("exec probe failed: %v, output: %s", err, truncateProbeOutput(output.String()))
+	}
+	return nil
+}
+
+// tcpProbe 检查本机指定端口是否可连接
+func tcpProbe(ctx context.Context, probe *models.TCPProbe, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(probe.Port)))
+	if err != nil {
+		return fmt.Errorf("tcp probe failed: %v", err)
+	}
+	conn.Close()
+	return nil
+}
+
+// httpProbe 对本机端点发起 GET 请求，状态码 200-399 视为成功
+func httpProbe(ctx context.Context, probe *models.HTTPProbe, timeout time.Duration) error {
+	path := probe.Path
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort("127.0.0.1", strconv.Itoa(probe.Port)), path)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("http probe failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("http probe failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("http probe failed: %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// truncateProbeOutput 截断探针输出，避免条件信息过长
+func truncateProbeOutput(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxProbeMessageLen {
+		return s[:maxProbeMessageLen] + "..."
+	}
+	return s
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// waitForCondition 等待进程的条件变为 status，返回此时的进程
+func waitForCondition(t *testing.T, m *Manager, name, condType string, status models.ConditionStatus) models.ManagedProcess {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mp, err := m.GetProcessStatus("test", name)
+		if err == nil {
+			if c := mp.Status.GetCondition(condType); c != nil && c.Status == status {
+				return mp
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("condition %s of %s did not become %s: %+v", condType, name, status, mp.Status.Conditions)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// probeFailedEvents 返回进程的 ProbeFailed 事件数
+func probeFailedEvents(t *testing.T, m *Manager, name string) int {
+	t.Helper()
+	events, err := m.ListEvents("test", name, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	n := 0
+	for _, ev := range events {
+		if ev.Type == models.EventProbeFailed {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRestartOnUnhealthy(t *testing.T) {
+	cases := map[models.RestartPolicy]bool{
+		models.RestartPolicyAlways:    true,
+		models.RestartPolicyOnFailure: true,
+		models.RestartPolicyOnSuccess: false,
+		models.RestartPolicyNever:     false,
+	}
+	for policy, want := range cases {
+		if got := restartOnUnhealthy(policy); got != want {
+			t.Errorf("restartOnUnhealthy(%s) = %t, want %t", policy, got, want)
+		}
+	}
+}
+
+func TestManagerExecProbeRestart(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	healthy := filepath.Join(dir, "healthy")
+	if err := os.WriteFile(healthy, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProcess(dir, "app")
+	p.Spec.RestartPolicy = models.RestartPolicyAlways
+	p.Spec.HealthCheck = &models.HealthCheck{
+		Exec:                &models.CommandConfig{Command: "test", Args: []string{"-f", "healthy"}},
+		InitialDelaySeconds: 1,
+		PeriodSeconds:       1,
+		FailureThreshold:    2,
+	}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	defer m.StopProcess("test", "app")
+
+	// 初始延迟内还没有探针结果：Ready 为 False、Healthy 为 Unknown
+	mp, _ := m.GetProcessStatus("test", "app")
+	if c := mp.Status.GetCondition(models.ConditionTypeHealthy); c == nil || c.Status != models.ConditionUnknown || c.Reason != "ProbePending" {
+		t.Fatalf("Healthy condition before the first probe = %+v, want Unknown/ProbePending", c)
+	}
+	if c := mp.Status.GetCondition(models.ConditionTypeReady); c == nil || c.Status != models.ConditionFalse {
+		t.Fatalf("Ready condition before the first probe = %+v, want False", c)
+	}
+	mp = waitForCondition(t, m, "app", models.ConditionTypeReady, models.ConditionTrue)
+	if c := mp.Status.GetCondition(models.ConditionTypeHealthy); c == nil || c.Status != models.ConditionTrue || c.Reason != "ProbeSucceeded" {
+		t.Fatalf("Healthy condition = %+v, want True/ProbeSucceeded", c)
+	}
+	pid := mp.Status.PID
+
+	// 连续失败 FailureThreshold 次后重启
+	if err := os.Remove(healthy); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	deadline := start.Add(10 * time.Second)
+	for {
+		mp, _ = m.GetProcessStatus("test", "app")
+		if mp.Status.RestartCount == 1 && mp.Status.PID != pid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process was not restarted after failing health checks: %+v", mp.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("restarted after %v, before the failure threshold was reached", elapsed)
+	}
+	if info := mp.Status.LastTerminationInfo; info == nil || info.Reason != "Unhealthy" {
+		t.Fatalf("termination info = %+v, want Unhealthy", info)
+	}
+	if n := probeFailedEvents(t, m, "app"); n != 1 {
+		t.Fatalf("%d ProbeFailed events, want 1", n)
+	}
+
+	// 重启后的实例重新探测
+	if err := os.WriteFile(healthy, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForCondition(t, m, "app", models.ConditionTypeHealthy, models.ConditionTrue)
+}
+
+func TestManagerTCPProbeRecovery(t *testing.T) {
+	m := newTestManager(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	p := testProcess(t.TempDir(), "web")
+	p.Spec.HealthCheck = &models.HealthCheck{
+		TCP:              &models.TCPProbe{Port: port},
+		PeriodSeconds:    1,
+		FailureThreshold: 1,
+	}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "web"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	defer m.StopProcess("test", "web")
+	mp := waitForCondition(t, m, "web", models.ConditionTypeReady, models.ConditionTrue)
+	pid := mp.Status.PID
+
+	// 重启策略为 Never 时只标记为不健康，进程继续运行
+	ln.Close()
+	mp = waitForCondition(t, m, "web", models.ConditionTypeReady, models.ConditionFalse)
+	if c := mp.Status.GetCondition(models.ConditionTypeHealthy); c == nil || c.Status != models.ConditionFalse || c.Reason != "ProbeFailed" {
+		t.Fatalf("Healthy condition = %+v, want False/ProbeFailed", c)
+	}
+	if mp.Status.Phase != models.PhaseRunning || mp.Status.PID != pid {
+		t.Fatalf("process = %s pid %d, want still running as %d", mp.Status.Phase, mp.Status.PID, pid)
+	}
+	if n := probeFailedEvents(t, m, "web"); n < 1 {
+		t.Fatal("no ProbeFailed event")
+	}
+
+	// 端口恢复后一次成功的探针重新标记为健康
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	mp = waitForCondition(t, m, "web", models.ConditionTypeHealthy, models.ConditionTrue)
+	if c := mp.Status.GetCondition(models.ConditionTypeReady); c == nil || c.Status != models.ConditionTrue {
+		t.Fatalf("Ready condition = %+v, want True", c)
+	}
+	if mp.Status.RestartCount != 0 {
+		t.Fatalf("restart count = %d, want 0", mp.Status.RestartCount)
+	}
+}
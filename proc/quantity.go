@@ -0,0 +1,85 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 数量后缀（与 Kubernetes resource.Quantity 保持一致）
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"Pi", 1 << 50},
+	{"Ei", 1 << 60},
+	{"k", 1e3},
+	{"K", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+	{"P", 1e15},
+	{"E", 1e18},
+}
+
+// ParseQuantity 解析数量字符串（如 "512Mi"、"1G"、"1000"），返回整数值
+func ParseQuantity(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty quantity")
+	}
+
+	multiplier := 1.0
+	number := s
+	for _, q := range quantitySuffixes {
+		if strings.HasSuffix(s, q.suffix) {
+			multiplier = q.multiplier
+			number = strings.TrimSuffix(s, q.suffix)
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return int64(v * multiplier), nil
+}
+
+// ParseCPUQuantity 解析 CPU 数量（如 "500m"、"1.5"、"2"），返回毫核数
+func ParseCPUQuantity(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "m") {
+		v, err := strconv.ParseInt(strings.TrimSuffix(s, "m"), 10, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid cpu quantity %q", s)
+		}
+		return v, nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid cpu quantity %q", s)
+	}
+	return int64(v * 1000), nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"1000", 1000},
+		{" 42 ", 42},
+		{"1Ki", 1024},
+		{"512Mi", 512 << 20},
+		{"1.5Gi", 3 << 29},
+		{"2Ti", 2 << 40},
+		{"1k", 1000},
+		{"1K", 1000},
+		{"100M", 100000000},
+		{"2G", 2000000000},
+		{"0.5G", 500000000},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.in)
+		if err != nil {
+			t.Errorf("ParseQuantity(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "  ", "abc", "Mi", "-1", "-1Gi", "1Xi", "1 Gi", "1.2.3"} {
+		if got, err := ParseQuantity(in); err == nil {
+			t.Errorf("ParseQuantity(%q) = %d, want error", in, got)
+		}
+	}
+}
+
+func TestParseCPUQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"500m", 500},
+		{"0m", 0},
+		{"1500m", 1500},
+		{"1", 1000},
+		{"1.5", 1500},
+		{"0.25", 250},
+		{" 2 ", 2000},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPUQuantity(tt.in)
+		if err != nil {
+			t.Errorf("ParseCPUQuantity(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCPUQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "m", "abc", "-1", "-500m", "1.5m", "1Gi"} {
+		if got, err := ParseCPUQuantity(in); err == nil {
+			t.Errorf("ParseCPUQuantity(%q) = %d, want error", in, got)
+		}
+	}
+}
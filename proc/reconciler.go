@@ -0,0 +1,618 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// reconcileEventType 是驱动进程状态转换的事件类型
+type reconcileEventType int
+
+const (
+	// 用户操作
+	eventStart reconcileEventType = iota
+	eventStop
+	eventRestart
+	eventDelete
+	eventUpdate
+	eventScale
+	// eventRecover 在 vigil 启动时按 PID 文件重关联仍在运行的进程
+	eventRecover
+	// 运行时事件
+	eventExited
+	eventProbeFailed
+	eventRestartTimer
+	eventAdopted
+	eventLost
+	eventAdoptedExited
+	eventNotify
+	eventNotifyTimeout
+	eventJobTimer
+	eventWatchdog
+	eventFileChanged
+)
+
+// reconcileEvent 是发送给单个进程 reconcile 协程的事件
+type reconcileEvent struct {
+	kind reconcileEventType
+	// run 是退出的运行实例（eventExited）或发送 notify 消息的运行实例（eventNotify）
+	run *processRun
+	// gen 用于丢弃过期的探针/定时器事件（eventProbeFailed、eventRestartTimer、eventNotifyTimeout、eventJobTimer）
+	gen uint64
+	// pid 是重关联到的进程（eventAdopted、eventLost、eventAdoptedExited）、触发看门狗的进程（eventWatchdog）、监视文件变化时的进程（eventFileChanged）或已校验的 MAINPID（eventNotify）
+	pid int
+	// message 是事件的补充信息（eventProbeFailed、eventWatchdog、eventFileChanged）或 notify 消息（eventNotify）
+	message string
+	// process 是新的进程定义（eventUpdate）
+	process *models.ManagedProcess
+	// replicas 是期望的实例数（eventScale）
+	replicas int
+	// reply 用于返回用户操作的结果
+	reply chan error
+}
+
+// processRun 是由 vigil 启动的一次子进程运行
+type processRun struct {
+	cmd    *exec.Cmd
+	pid    int
+	cgroup *processCgroup
+	// exited 在 cmd.Wait 返回后关闭，err 在关闭前写入
+	exited chan struct{}
+	err    error
+	// outputs 是 stdout 和 stderr 的输出，保留最近的内容
+	outputs []*processOutput
+	// stdin 是 Spec.Stdin 时标准输入管道的写端，进程退出后关闭
+	stdin *os.File
+	// notify 是启用 Spec.Notify 时本次运行的 notify socket
+	notify *notifySocket
+	// followMainPID 表示接受 MAINPID=；进程在独立 PID 命名空间中时报告的 PID 没有意义
+	followMainPID bool
+}
+
+// processEntry 是一个纳管进程的运行时记录。
+// Spec 和 Status 由 mu 保护；所有状态转换只在该进程自己的 reconcile 协程中发生。
+type processEntry struct {
+	key    string
+	events chan reconcileEvent
+	// done 在进程被删除、reconcile 协程退出时关闭
+	done chan struct{}
+
+	mu      sync.RWMutex
+	process models.ManagedProcess
+	// cgroup 是当前运行实例的 cgroup，采样器读取
+	cgroup *processCgroup
+	// adopted 表示 PID 来自重关联，而不是 vigil 启动的子进程
+	adopted bool
+	// adoptedStartTime 是重关联进程的启动时间（Unix 毫秒），为 0 时由采样器按进程特征检查进程是否存在
+	adoptedStartTime int64
+	// stoppedByUser 表示进程被用户停止，不再自动重启或重关联
+	stoppedByUser bool
+	// probeGen 在每次启动/停止探针时递增，用于丢弃过期探针的结果
+	probeGen uint64
+	// instances 是 Replicas 进程的各个实例（按 index 排序），或定时 Job 创建的运行实例
+	instances []*processEntry
+	// index 是实例在 Replicas 进程中的序号，或定时 Job 实例的调度序号
+	index int
+	// parent 是 Replicas 实例或定时 Job 实例所属的进程名
+	parent string
+	// attach 保留最近的输出并分发给 attach 会话
+	attach *attachHub
+	// crashSnapshot 是启用 OnCrash 时运行中进程最近一次保存的 /proc 信息，进程退出后写入崩溃报告
+	crashSnapshot *procSnapshot
+
+	// 以下字段只在 reconcile 协程中访问
+	run          *processRun
+	probeCancel  context.CancelFunc
+	watchCancel  context.CancelFunc
+	restartTimer *time.Timer
+	timerGen     uint64
+	// runStarted 是当前（或上一次）运行实例的启动时间，启动失败时为零值
+	runStarted time.Time
+	// restartTimes 是 RestartBackoff.Window 内的自动重启时间
+	restartTimes []time.Time
+	// active 表示 Replicas 进程已被用户启动，扩容出的实例随之启动
+	active bool
+	// notifyTimer 是等待 READY=1 或看门狗心跳的定时器，watchdog 是当前的看门狗间隔
+	notifyTimer *time.Timer
+	notifyGen   uint64
+	watchdog    time.Duration
+	// jobTimer 是 Job 的运行时间上限，或定时 Job 的下一次调度
+	jobTimer *time.Timer
+	jobGen   uint64
+	// jobAttempt 是本次 Job 中的运行次数，jobStopReason 是停止当前运行的原因
+	jobAttempt    int
+	jobStopReason string
+	// watchdogRules 是 Spec.Watchdog 的编译结果和触发状态，只在采样器中访问
+	watchdogRules *watchdogState
+	// jobSeq 是定时 Job 已创建的实例数，jobLastUnix 是上一个实例的调度时间
+	jobSeq      int
+	jobLastUnix int64
+}
+
+// reconcileEventBuffer 是每个进程事件队列的容量
+const reconcileEventBuffer = 16
+
+func newProcessEntry(process models.ManagedProcess) *processEntry {
+	return &processEntry{
+		key:     fmt.Sprintf("%s/%s", process.Metadata.Namespace, process.Metadata.Name),
+		events:  make(chan reconcileEvent, reconcileEventBuffer),
+		done:    make(chan struct{}),
+		process: process,
+		attach:  newAttachHub(),
+	}
+}
+
+// send 投递事件，进程已删除时返回 false
+func (e *processEntry) send(ev reconcileEvent) bool {
+	select {
+	case e.events <- ev:
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
+// do 投递用户操作并等待 reconcile 协程处理完成
+func (e *processEntry) do(ev reconcileEvent) error {
+	ev.reply = make(chan error, 1)
+	if !e.send(ev) {
+		return fmt.Errorf("process %s is not managed", e.key)
+	}
+	select {
+	case err := <-ev.reply:
+		return err
+	case <-e.done:
+		select {
+		case err := <-ev.reply:
+			return err
+		default:
+			return fmt.Errorf("process %s is not managed", e.key)
+		}
+	}
+}
+
+// snapshot 返回进程的副本，可以在锁外安全读取。Replicas 进程的 Status 由实例状态汇总得出，
+// 定时 Job 的 Status 包含各次运行的实例
+func (e *processEntry) snapshot() models.ManagedProcess {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	mp := cloneProcess(&e.process)
+	if mp.Spec.Replicas != nil {
+		aggregateStatus(&mp.Status, e.instances)
+	} else if isCronJob(&mp) {
+		cronJobStatus(&mp.Status, e.instances)
+	}
+	return mp
+}
+
+// updateStatus 在锁内修改 Status
+func (e *processEntry) updateStatus(fn func(status *models.Status)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(&e.process.Status)
+}
+
+// setPhase 更新进程阶段
+func (e *processEntry) setPhase(phase models.Phase) {
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = phase
+	})
+}
+
+// setCondition 更新进程条件
+func (e *processEntry) setCondition(condType string, status models.ConditionStatus, reason, message string) {
+	e.updateStatus(func(st *models.Status) {
+		st.SetCondition(condType, status, reason, message)
+	})
+}
+
+// setResourceStats 记录采样结果，PID 已变化时丢弃并返回 false
+func (e *processEntry) setResourceStats(pid int, stats *models.ResourceStats) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.process.Status.PID == pid && e.process.Status.Phase == models.PhaseRunning {
+		e.process.Status.ResourceStats = stats
+		return true
+	}
+	return false
+}
+
+// isRunning 进程是否处于运行状态（包括重关联的进程）
+func (e *processEntry) isRunning() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.process.Status.Phase == models.PhaseRunning
+}
+
+// cloneProcess 复制进程，Status 中会被原地修改的部分做深拷贝
+func cloneProcess(mp *models.ManagedProcess) models.ManagedProcess {
+	c := *mp
+	if mp.Status.Conditions != nil {
+		c.Status.Conditions = append([]models.Condition(nil), mp.Status.Conditions...)
+	}
+	if mp.Status.StartTime != nil {
+		t := *mp.Status.StartTime
+		c.Status.StartTime = &t
+	}
+	if mp.Status.NextRetryTime != nil {
+		t := *mp.Status.NextRetryTime
+		c.Status.NextRetryTime = &t
+	}
+	if mp.Status.LastTerminationInfo != nil {
+		info := *mp.Status.LastTerminationInfo
+		c.Status.LastTerminationInfo = &info
+	}
+	if mp.Status.ResourceStats != nil {
+		stats := *mp.Status.ResourceStats
+		c.Status.ResourceStats = &stats
+	}
+	if mp.Status.Job != nil {
+		job := *mp.Status.Job
+		c.Status.Job = &job
+	}
+	return c
+}
+
+// reconcile 是单个进程的事件循环，按顺序处理用户操作和运行时事件
+func (m *Manager) reconcile(e *processEntry) {
+	for ev := range e.events {
+		var err error
+		switch {
+		case e.isReplicated():
+			err = m.handleReplicatedEvent(e, ev)
+		case e.isCronJob():
+			err = m.handleCronJobEvent(e, ev)
+		case e.isJob():
+			err = m.handleJobEvent(e, ev)
+		default:
+			err = m.handleEvent(e, ev)
+		}
+		if ev.reply != nil {
+			ev.reply <- err
+		}
+		if ev.kind == eventDelete && err == nil {
+			close(e.done)
+			e.attach.close()
+			return
+		}
+	}
+}
+
+// handleEvent 处理一个事件
+func (m *Manager) handleEvent(e *processEntry, ev reconcileEvent) error {
+	switch ev.kind {
+	case eventStart:
+		e.cancelRestartTimer()
+		e.resetBackoff()
+		e.setStoppedByUser(false)
+		return m.startRun(e)
+
+	case eventStop:
+		e.cancelRestartTimer()
+		e.setStoppedByUser(true)
+		if e.snapshot().Status.Phase == models.PhaseCrashLoopBackOff {
+			// 正在等待退避重启，取消重启即视为停止
+			e.resetBackoff()
+			e.setPhase(models.PhaseStopped)
+			return nil
+		}
+		return m.stopRun(e)
+
+	case eventRestart:
+		e.cancelRestartTimer()
+		e.resetBackoff()
+		e.setStoppedByUser(false)
+		if e.run != nil || e.isRunning() {
+			if err := m.stopRun(e); err != nil {
+				return err
+			}
+		}
+		if err := m.startRun(e); err != nil {
+			return err
+		}
+		m.emitEvent(e, models.EventRestarted, "UserRequested", "restarted by user")
+
+	case eventDelete:
+		e.cancelRestartTimer()
+		e.resetBackoff()
+		if e.run != nil || e.isRunning() {
+			if err := m.stopRun(e); err != nil {
+				return fmt.Errorf("停止进程失败: %w", err)
+			}
+		}
+		m.stopProbes(e)
+		m.stopWatch(e)
+		return nil
+
+	case eventUpdate:
+		e.mu.Lock()
+		watchChanged := !reflect.DeepEqual(e.process.Spec.Watch, ev.process.Spec.Watch)
+		e.process.Metadata = ev.process.Metadata
+		e.process.Spec = ev.process.Spec
+		e.mu.Unlock()
+		if watchChanged && e.isRunning() {
+			// 文件监视立即按新的配置生效，不需要重启进程
+			m.startWatch(e)
+		}
+		m.emitEvent(e, models.EventConfigChanged, "", "process definition updated")
+		return nil
+
+	case eventExited:
+		if ev.run != e.run {
+			// 已由 stopRun 处理过的运行实例
+			return nil
+		}
+		m.finishRun(e, nil)
+		m.scheduleRestart(e, ev.run.err)
+
+	case eventProbeFailed:
+		e.mu.RLock()
+		current := ev.gen == e.probeGen
+		e.mu.RUnlock()
+		if !current || !e.isRunning() || !restartOnUnhealthy(e.snapshot().Spec.RestartPolicy) {
+			return nil
+		}
+		log.Printf("Process %s is unhealthy, restarting: %s", e.key, ev.message)
+		m.restartRun(e, "Unhealthy", ev.message)
+
+	case eventWatchdog:
+		mp := e.snapshot()
+		if !e.isRunning() || mp.Status.PID != ev.pid {
+			// 触发后进程已经重启或停止
+			return nil
+		}
+		m.restartRun(e, "Watchdog", ev.message)
+
+	case eventFileChanged:
+		mp := e.snapshot()
+		if !e.isRunning() || mp.Status.PID != ev.pid {
+			return nil
+		}
+		m.restartRun(e, "FileChanged", ev.message)
+
+	case eventRestartTimer:
+		if ev.gen != e.timerGen || e.restartTimer == nil {
+			return nil
+		}
+		e.restartTimer = nil
+		if e.run != nil || e.isRunning() || e.isStoppedByUser() {
+			return nil
+		}
+		if err := m.startRun(e); err != nil {
+			// 启动失败同样计入崩溃，继续按退避重试
+			log.Printf("Failed to restart process %s: %v", e.key, err)
+			m.scheduleRestart(e, err)
+			return nil
+		}
+		m.recordRestart(e, "RestartPolicy")
+
+	case eventRecover:
+		if e.run != nil || e.isRunning() {
+			return nil
+		}
+		m.adoptFromPIDFile(e)
+
+	case eventAdopted:
+		if e.run != nil || e.isRunning() || e.isStoppedByUser() {
+			return nil
+		}
+		startTime, err := processStartTime(ev.pid)
+		if err != nil {
+			startTime = 0
+		}
+		m.adopt(e, ev.pid, startTime, fmt.Sprintf("re-associated with running process %d", ev.pid))
+
+	case eventLost:
+		m.loseAdopted(e, ev.pid, "re-associated process is no longer running")
+
+	case eventAdoptedExited:
+		// 退出码未知，按失败处理重启策略
+		if m.loseAdopted(e, ev.pid, "adopted process exited") {
+			m.scheduleRestart(e, errAdoptedExited)
+		}
+
+	case eventNotify:
+		if ev.run == nil || ev.run != e.run {
+			// 已经结束的运行实例
+			return nil
+		}
+		m.handleNotify(e, ev.message, ev.pid)
+
+	case eventNotifyTimeout:
+		if ev.gen != e.notifyGen || e.run == nil {
+			return nil
+		}
+		m.notifyTimedOut(e)
+	}
+	return nil
+}
+
+// restartRun 以 reason 停止并重新启动运行中的进程，启动失败时按重启策略退避（仅在 reconcile 协程中调用）
+func (m *Manager) restartRun(e *processEntry, reason, message string) {
+	if err := m.stopRun(e); err != nil {
+		log.Printf("Failed to stop process %s: %v", e.key, err)
+		return
+	}
+	e.updateStatus(func(status *models.Status) {
+		if status.LastTerminationInfo == nil {
+			status.LastTerminationInfo = &models.TerminationInfo{FinishedAt: time.Now()}
+		}
+		status.LastTerminationInfo.Reason = reason
+		status.LastTerminationInfo.Message = message
+	})
+	if err := m.startRun(e); err != nil {
+		log.Printf("Failed to restart process %s: %v", e.key, err)
+		m.scheduleRestart(e, err)
+		return
+	}
+	m.recordRestart(e, reason)
+}
+
+// 崩溃重启退避的默认值
+const (
+	defaultRestartInterval    = 5 * time.Second
+	defaultBackoffMaxInterval = 5 * time.Minute
+	defaultRestartWindow      = 10 * time.Minute
+	defaultBackoffResetAfter  = 10 * time.Minute
+)
+
+// scheduleRestart 子进程退出后按重启策略安排重启。
+// 重启间隔从 RestartInterval 开始按连续崩溃次数指数增长，直到 MaxInterval；
+// Window 内自动重启次数达到 MaxRestarts 时不再重启，进程置为 Failed。
+func (m *Manager) scheduleRestart(e *processEntry, exitErr error) {
+	if e.isStoppedByUser() {
+		return
+	}
+	mp := e.snapshot()
+
+	shouldRestart := false
+	switch mp.Spec.RestartPolicy {
+	case models.RestartPolicyAlways:
+		shouldRestart = true
+	case models.RestartPolicyOnFailure:
+		shouldRestart = exitErr != nil
+	case models.RestartPolicyOnSuccess:
+		shouldRestart = exitErr == nil
+	case models.RestartPolicyNever:
+		shouldRestart = false
+	}
+	if !shouldRestart {
+		return
+	}
+
+	backoff := models.RestartBackoff{}
+	if mp.Spec.RestartBackoff != nil {
+		backoff = *mp.Spec.RestartBackoff
+	}
+	interval := mp.Spec.RestartInterval
+	if interval <= 0 {
+		interval = defaultRestartInterval
+	}
+	if backoff.MaxInterval <= 0 {
+		backoff.MaxInterval = defaultBackoffMaxInterval
+	}
+	if backoff.Window <= 0 {
+		backoff.Window = defaultRestartWindow
+	}
+	if backoff.ResetAfter <= 0 {
+		backoff.ResetAfter = defaultBackoffResetAfter
+	}
+
+	now := time.Now()
+	count := mp.Status.BackoffCount
+	// 稳定运行超过 ResetAfter 后重置退避
+	if !e.runStarted.IsZero() && now.Sub(e.runStarted) >= backoff.ResetAfter {
+		count = 0
+	}
+
+	// 统计 Window 内的重启次数
+	recent := e.restartTimes[:0]
+	for _, t := range e.restartTimes {
+		if now.Sub(t) < backoff.Window {
+			recent = append(recent, t)
+		}
+	}
+	e.restartTimes = recent
+	if backoff.MaxRestarts > 0 && len(e.restartTimes) >= backoff.MaxRestarts {
+		msg := fmt.Sprintf("restarted %d times within %v, giving up", len(e.restartTimes), backoff.Window)
+		log.Printf("Process %s %s", e.key, msg)
+		e.updateStatus(func(status *models.Status) {
+			status.Phase = models.PhaseFailed
+			status.BackoffCount = count
+			status.NextRetryTime = nil
+			status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "RestartLimitExceeded", msg)
+		})
+		return
+	}
+	e.restartTimes = append(e.restartTimes, now)
+
+	delay := interval
+	for i := int32(0); i < count && delay < backoff.MaxInterval; i++ {
+		delay *= 2
+	}
+	if delay > backoff.MaxInterval {
+		delay = backoff.MaxInterval
+	}
+
+	next := now.Add(delay)
+	e.updateStatus(func(status *models.Status) {
+		status.Phase = models.PhaseCrashLoopBackOff
+		status.BackoffCount = count + 1
+		status.NextRetryTime = &next
+	})
+	e.startRestartTimer(delay)
+}
+
+// recordRestart 记录一次自动重启
+func (m *Manager) recordRestart(e *processEntry, reason string) {
+	var count int32
+	e.updateStatus(func(status *models.Status) {
+		status.RestartCount++
+		count = status.RestartCount
+	})
+	m.emitEvent(e, models.EventRestarted, reason, fmt.Sprintf("restart #%d", count))
+}
+
+// resetBackoff 用户操作后清除退避状态
+func (e *processEntry) resetBackoff() {
+	e.restartTimes = nil
+	e.updateStatus(func(status *models.Status) {
+		status.BackoffCount = 0
+		status.NextRetryTime = nil
+	})
+}
+
+// startRestartTimer 在 d 之后向 reconcile 协程发送重启事件
+func (e *processEntry) startRestartTimer(d time.Duration) {
+	e.cancelRestartTimer()
+	e.timerGen++
+	gen := e.timerGen
+	e.restartTimer = time.AfterFunc(d, func() {
+		e.send(reconcileEvent{kind: eventRestartTimer, gen: gen})
+	})
+}
+
+// cancelRestartTimer 取消尚未触发的重启
+func (e *processEntry) cancelRestartTimer() {
+	if e.restartTimer != nil {
+		e.restartTimer.Stop()
+		e.restartTimer = nil
+	}
+	e.timerGen++
+}
+
+func (e *processEntry) setStoppedByUser(v bool) {
+	e.mu.Lock()
+	e.stoppedByUser = v
+	e.mu.Unlock()
+}
+
+func (e *processEntry) isStoppedByUser() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.stoppedByUser
+}
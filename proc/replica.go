@@ -0,0 +1,418 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// instanceData 是实例模板（{{.Index}}、{{.Name}}）可以引用的数据
+type instanceData struct {
+	Index int
+	Name  string
+}
+
+// instanceName 返回 Replicas 进程第 index 个实例的名称
+func instanceName(name string, index int) string {
+	return fmt.Sprintf("%s-%d", name, index)
+}
+
+// renderTemplate 渲染单个字段，不含模板语法时原样返回
+func renderTemplate(field, text string, data instanceData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", field, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%s: %v", field, err)
+	}
+	return b.String(), nil
+}
+
+// renderInstance 根据 Replicas 进程的定义生成第 index 个实例的定义
+func renderInstance(mp *models.ManagedProcess, index int) (models.ManagedProcess, error) {
+	inst := models.ManagedProcess{Metadata: mp.Metadata, Spec: mp.Spec}
+	data := instanceData{Index: index, Name: instanceName(mp.Metadata.Name, index)}
+	inst.Metadata.Name = data.Name
+	inst.Metadata.ID = instanceName(mp.Metadata.ID, index)
+	inst.Spec.Replicas = nil
+
+	var err error
+	render := func(field string, s *string) {
+		if err == nil {
+			*s, err = renderTemplate(field, *s, data)
+		}
+	}
+	inst.Spec.Exec.Args = append([]string(nil), mp.Spec.Exec.Args...)
+	for i := range inst.Spec.Exec.Args {
+		render(fmt.Sprintf("exec.args[%d]", i), &inst.Spec.Exec.Args[i])
+	}
+	inst.Spec.Env = append([]models.EnvVar(nil), mp.Spec.Env...)
+	for i := range inst.Spec.Env {
+		render("env."+inst.Spec.Env[i].Name, &inst.Spec.Env[i].Value)
+	}
+	render("working_dir", &inst.Spec.WorkingDir)
+	render("log.dir", &inst.Spec.Log.Dir)
+	render("log.stdout_file", &inst.Spec.Log.StdoutFile)
+	render("log.stderr_file", &inst.Spec.Log.StderrFile)
+	return inst, err
+}
+
+// validateReplicas 校验 Replicas 以及实例模板能否渲染
+func validateReplicas(mp *models.ManagedProcess) error {
+	if mp.Spec.Replicas == nil {
+		return nil
+	}
+	if *mp.Spec.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+	_, err := renderInstance(mp, 0)
+	return err
+}
+
+// isReplicated 进程是否设置了 Replicas。Replicas 进程本身不运行，由各实例运行
+func (e *processEntry) isReplicated() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.process.Spec.Replicas != nil
+}
+
+// listInstances 返回 Replicas 进程当前的实例
+func (e *processEntry) listInstances() []*processEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.instances
+}
+
+// setInstances 替换实例列表；读取方可能持有旧的切片，因此总是使用新的切片
+func (e *processEntry) setInstances(instances []*processEntry) {
+	e.mu.Lock()
+	e.instances = instances
+	e.mu.Unlock()
+}
+
+// runEntries 返回实际运行进程的记录：普通进程以及 Replicas 进程和定时 Job 的各个实例
+func (m *Manager) runEntries() []*processEntry {
+	var result []*processEntry
+	for _, e := range m.listEntries() {
+		e.mu.RLock()
+		replicated := e.process.Spec.Replicas != nil || isCronJob(&e.process)
+		instances := e.instances
+		e.mu.RUnlock()
+		if replicated {
+			result = append(result, instances...)
+		} else {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// newInstance 创建第 index 个实例并启动它的 reconcile 协程。
+// saved 是持久化的实例状态（vigil 重启后恢复），可以为空。
+func (m *Manager) newInstance(mp *models.ManagedProcess, index int, saved *models.InstanceStatus) (*processEntry, error) {
+	process, err := renderInstance(mp, index)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil {
+		// 旧版本保存的实例状态可能仍带有运行时信息
+		process.Status = saved.Status
+		clearRuntimeStatus(&process.Status)
+	}
+	inst := newProcessEntry(process)
+	inst.parent = mp.Metadata.Name
+	inst.index = index
+	go m.reconcile(inst)
+	return inst, nil
+}
+
+// initInstances 为新纳管的 Replicas 进程创建实例，按 Index 恢复持久化的实例状态
+func (m *Manager) initInstances(e *processEntry) error {
+	e.mu.RLock()
+	mp := cloneProcess(&e.process)
+	e.mu.RUnlock()
+	saved := make(map[int]*models.InstanceStatus, len(mp.Status.Instances))
+	for i := range mp.Status.Instances {
+		saved[mp.Status.Instances[i].Index] = &mp.Status.Instances[i]
+	}
+	instances := make([]*processEntry, 0, *mp.Spec.Replicas)
+	for i := 0; i < *mp.Spec.Replicas; i++ {
+		inst, err := m.newInstance(&mp, i, saved[i])
+		if err != nil {
+			deleteInstances(instances)
+			return err
+		}
+		instances = append(instances, inst)
+	}
+	e.mu.Lock()
+	e.process.Status.Instances = nil
+	e.instances = instances
+	e.mu.Unlock()
+	return nil
+}
+
+// handleReplicatedEvent 处理 Replicas 进程的用户操作，转发给各实例的 reconcile 协程
+func (m *Manager) handleReplicatedEvent(e *processEntry, ev reconcileEvent) error {
+	switch ev.kind {
+	case eventStart:
+		e.setStoppedByUser(false)
+		e.active = true
+		var errs []error
+		for _, inst := range e.listInstances() {
+			if inst.isRunning() {
+				continue
+			}
+			if err := inst.do(reconcileEvent{kind: eventStart}); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", inst.key, err))
+			}
+		}
+		return errors.Join(errs...)
+
+	case eventStop:
+		e.setStoppedByUser(true)
+		e.active = false
+		var active []*processEntry
+		for _, inst := range e.listInstances() {
+			if isActivePhase(inst.snapshot().Status.Phase) {
+				active = append(active, inst)
+			} else {
+				// 已停止的实例同样不再重关联
+				inst.setStoppedByUser(true)
+			}
+		}
+		return forEachInstance(active, reconcileEvent{kind: eventStop})
+
+	case eventRestart:
+		// 逐个重启，其余实例继续提供服务
+		e.setStoppedByUser(false)
+		e.active = true
+		for _, inst := range e.listInstances() {
+			if err := inst.do(reconcileEvent{kind: eventRestart}); err != nil {
+				return fmt.Errorf("%s: %w", inst.key, err)
+			}
+		}
+		m.emitEvent(e, models.EventRestarted, "UserRequested", "restarted by user")
+
+	case eventDelete:
+		if err := forEachInstance(e.listInstances(), reconcileEvent{kind: eventDelete}); err != nil {
+			// 保留删除失败的实例
+			e.setInstances(liveInstances(e.listInstances()))
+			return fmt.Errorf("停止进程失败: %w", err)
+		}
+		e.setInstances(nil)
+		return nil
+
+	case eventUpdate:
+		e.mu.Lock()
+		e.process.Metadata = ev.process.Metadata
+		e.process.Spec = ev.process.Spec
+		e.mu.Unlock()
+		m.emitEvent(e, models.EventConfigChanged, "", "process definition updated")
+
+		// 运行中的实例与普通进程一样在下次启动时使用新定义
+		var errs []error
+		for _, inst := range e.listInstances() {
+			process, err := renderInstance(ev.process, inst.index)
+			if err == nil {
+				err = inst.do(reconcileEvent{kind: eventUpdate, process: &process})
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", inst.key, err))
+			}
+		}
+		if err := m.scaleInstances(e, *ev.process.Spec.Replicas); err != nil {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+
+	case eventScale:
+		return m.scaleInstances(e, ev.replicas)
+	}
+	return nil
+}
+
+// scaleInstances 把实例数调整为 replicas：扩容时按需启动新实例，缩容时从序号最大的实例开始逐个优雅停止并删除
+// （仅在 reconcile 协程中调用）
+func (m *Manager) scaleInstances(e *processEntry, replicas int) error {
+	mp := e.snapshot()
+	instances := e.listInstances()
+	from := len(instances)
+	if replicas == from {
+		return nil
+	}
+
+	// 实例随 Replicas 进程启动：用户启动过，或者（vigil 重启后）仍有实例在运行
+	start := e.active
+	for _, inst := range instances {
+		start = start || isActivePhase(inst.snapshot().Status.Phase)
+	}
+	start = start && !e.isStoppedByUser()
+
+	var scaleErr error
+	if replicas > from {
+		next := 0
+		if from > 0 {
+			next = instances[from-1].index + 1
+		}
+		for len(instances) < replicas {
+			inst, err := m.newInstance(&mp, next, nil)
+			if err != nil {
+				scaleErr = err
+				break
+			}
+			next++
+			instances = append(instances[:len(instances):len(instances)], inst)
+			e.setInstances(instances)
+			if !start {
+				continue
+			}
+			if err := inst.do(reconcileEvent{kind: eventStart}); err != nil {
+				// 启动失败的实例保留，与普通进程启动失败一致
+				scaleErr = fmt.Errorf("%s: %w", inst.key, err)
+			}
+		}
+	} else {
+		for len(instances) > replicas {
+			inst := instances[len(instances)-1]
+			if err := inst.do(reconcileEvent{kind: eventDelete}); err != nil {
+				scaleErr = fmt.Errorf("%s: %w", inst.key, err)
+				break
+			}
+			instances = instances[:len(instances)-1]
+			e.setInstances(instances)
+		}
+	}
+
+	current := len(e.listInstances())
+	e.mu.Lock()
+	e.process.Spec.Replicas = &current
+	e.mu.Unlock()
+	if current != from {
+		m.emitEvent(e, models.EventScaled, "", fmt.Sprintf("scaled from %d to %d replicas", from, current))
+	}
+	return scaleErr
+}
+
+// ScaleProcess 调整 Replicas 进程的实例数
+func (m *Manager) ScaleProcess(namespace, name string, replicas int) error {
+	if replicas < 0 {
+		return fmt.Errorf("%w: replicas must not be negative", ErrInvalidProcess)
+	}
+	e, exists := m.getEntry(namespace, name)
+	if !exists {
+		return fmt.Errorf("process %s/%s is not managed", namespace, name)
+	}
+	if !e.isReplicated() {
+		return fmt.Errorf("%w: process %s/%s does not have replicas", ErrInvalidProcess, namespace, name)
+	}
+
+	err := e.do(reconcileEvent{kind: eventScale, replicas: replicas})
+	if saveErr := m.saveProcess(e.snapshot()); saveErr != nil {
+		fmt.Printf("Warning: failed to save managed processes: %v\n", saveErr)
+	}
+	return err
+}
+
+// forEachInstance 并行地向实例投递用户操作，等待全部完成
+func forEachInstance(instances []*processEntry, ev reconcileEvent) error {
+	errs := make([]error, len(instances))
+	var wg sync.WaitGroup
+	for i, inst := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := inst.do(ev); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", inst.key, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deleteInstances 删除尚未启动过的实例，只需结束它们的 reconcile 协程
+func deleteInstances(instances []*processEntry) {
+	_ = forEachInstance(instances, reconcileEvent{kind: eventDelete})
+}
+
+// liveInstances 过滤掉已删除的实例
+func liveInstances(instances []*processEntry) []*processEntry {
+	result := make([]*processEntry, 0, len(instances))
+	for _, inst := range instances {
+		select {
+		case <-inst.done:
+		default:
+			result = append(result, inst)
+		}
+	}
+	return result
+}
+
+// aggregateStatus 由实例状态汇总 Replicas 进程的状态（调用方持有 e.mu）
+func aggregateStatus(status *models.Status, instances []*processEntry) {
+	status.PID = 0
+	status.StartTime = nil
+	status.ResourceStats = nil
+	status.RestartCount = 0
+	status.Instances = make([]models.InstanceStatus, 0, len(instances))
+
+	ready := 0
+	counts := make(map[models.Phase]int)
+	for _, inst := range instances {
+		mp := inst.snapshot()
+		status.Instances = append(status.Instances, models.InstanceStatus{Index: inst.index, Name: mp.Metadata.Name, Status: mp.Status})
+		counts[mp.Status.Phase]++
+		status.RestartCount += mp.Status.RestartCount
+		if cond := mp.Status.GetCondition(models.ConditionTypeReady); cond != nil && cond.Status == models.ConditionTrue {
+			ready++
+		}
+	}
+
+	// 有实例正在启停时视为过渡状态；部分实例运行时整体为 Running，由 Ready 条件反映是否全部就绪
+	switch {
+	case counts[models.PhasePending] > 0:
+		status.Phase = models.PhasePending
+	case counts[models.PhaseStopping] > 0:
+		status.Phase = models.PhaseStopping
+	case counts[models.PhaseCrashLoopBackOff] > 0:
+		status.Phase = models.PhaseCrashLoopBackOff
+	case counts[models.PhaseRunning] > 0:
+		status.Phase = models.PhaseRunning
+	case counts[models.PhaseFailed] > 0:
+		status.Phase = models.PhaseFailed
+	default:
+		status.Phase = models.PhaseStopped
+	}
+
+	message := fmt.Sprintf("%d/%d instances ready", ready, len(instances))
+	if ready == len(instances) && ready > 0 {
+		status.SetCondition(models.ConditionTypeReady, models.ConditionTrue, "InstancesReady", message)
+	} else {
+		status.SetCondition(models.ConditionTypeReady, models.ConditionFalse, "InstancesNotReady", message)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestRenderInstance(t *testing.T) {
+	replicas := 2
+	p := testProcess("/srv/{{.Name}}", "web")
+	p.Spec.Replicas = &replicas
+	p.Spec.Exec.Args = []string{"--port", "{{add 8000 .Index}}"}
+	if err := ValidateProcess(&p); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("ValidateProcess with unknown function = %v, want ErrInvalidProcess", err)
+	}
+	p.Spec.Exec.Args = []string{"--id", "{{.Missing}}"}
+	if err := ValidateProcess(&p); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("ValidateProcess with unknown field = %v, want ErrInvalidProcess", err)
+	}
+
+	p.Spec.Exec.Args = []string{"--id", "{{.Index}}"}
+	p.Spec.Env = []models.EnvVar{{Name: "INSTANCE", Value: "{{.Name}}"}}
+	p.Spec.Log = models.LogConfig{Dir: "/var/log/{{.Name}}", StdoutFile: "out-{{.Index}}.log"}
+	if err := ValidateProcess(&p); err != nil {
+		t.Fatalf("ValidateProcess: %v", err)
+	}
+	inst, err := renderInstance(&p, 1)
+	if err != nil {
+		t.Fatalf("renderInstance: %v", err)
+	}
+	if inst.Metadata.Name != "web-1" || inst.Spec.Replicas != nil {
+		t.Fatalf("instance = %s (replicas %v), want web-1 without replicas", inst.Metadata.Name, inst.Spec.Replicas)
+	}
+	if got := fmt.Sprintln(inst.Spec.Exec.Args, inst.Spec.Env[0].Value, inst.Spec.WorkingDir, inst.Spec.Log.Dir, inst.Spec.Log.StdoutFile); got != "[--id 1] web-1 /srv/web-1 /var/log/web-1 out-1.log\n" {
+		t.Fatalf("rendered instance = %s", got)
+	}
+	// 渲染不修改原定义
+	if p.Spec.Exec.Args[1] != "{{.Index}}" || p.Spec.Env[0].Value != "{{.Name}}" {
+		t.Fatalf("renderInstance modified the template: %v %v", p.Spec.Exec.Args, p.Spec.Env)
+	}
+}
+
+func TestManagerReplicas(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	replicas := 2
+	p := testProcess(dir, "web")
+	p.Spec.Replicas = &replicas
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `echo "{{.Index}} $INSTANCE" > {{.Name}}.out; exec sleep 300`}}
+	p.Spec.Env = []models.EnvVar{{Name: "INSTANCE", Value: "{{.Name}}"}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "web"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		name := fmt.Sprintf("web-%d", i)
+		if got, want := waitForFile(t, filepath.Join(dir, name+".out")), fmt.Sprintf("%d %s", i, name); got != want {
+			t.Fatalf("%s wrote %q, want %q", name, got, want)
+		}
+	}
+
+	status, _ := m.GetProcessStatus("test", "web")
+	if status.Status.Phase != models.PhaseRunning || len(status.Status.Instances) != 2 {
+		t.Fatalf("status = %s with %d instances, want Running with 2", status.Status.Phase, len(status.Status.Instances))
+	}
+	for _, inst := range status.Status.Instances {
+		if inst.Phase != models.PhaseRunning || inst.PID <= 0 {
+			t.Fatalf("instance %s = %s pid %d, want running", inst.Name, inst.Phase, inst.PID)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "web-1.stdout.log")); err != nil {
+		t.Fatalf("instance log file: %v", err)
+	}
+
+	// 扩容的实例随进程启动
+	if err := m.ScaleProcess("test", "web", 3); err != nil {
+		t.Fatalf("ScaleProcess up: %v", err)
+	}
+	if got := waitForFile(t, filepath.Join(dir, "web-2.out")); got != "2 web-2" {
+		t.Fatalf("web-2 wrote %q", got)
+	}
+
+	// 缩容优雅停止序号最大的实例
+	status, _ = m.GetProcessStatus("test", "web")
+	removed := []int{status.Status.Instances[1].PID, status.Status.Instances[2].PID}
+	if err := m.ScaleProcess("test", "web", 1); err != nil {
+		t.Fatalf("ScaleProcess down: %v", err)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if len(status.Status.Instances) != 1 || status.Status.Instances[0].Name != "web-0" || *status.Spec.Replicas != 1 {
+		t.Fatalf("after scale down: %+v (replicas %d), want web-0 only", status.Status.Instances, *status.Spec.Replicas)
+	}
+	for _, pid := range removed {
+		if pidAlive(pid) {
+			t.Fatalf("removed instance pid %d is still running", pid)
+		}
+	}
+	if err := m.ScaleProcess("test", "web", -1); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("ScaleProcess -1 = %v, want ErrInvalidProcess", err)
+	}
+
+	// 没有 replicas 的进程不能扩缩容，也不能通过修改定义改变这一点
+	single := testProcess(dir, "single")
+	if err := m.CreateProcess(single); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.ScaleProcess("test", "single", 2); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("ScaleProcess without replicas = %v, want ErrInvalidProcess", err)
+	}
+	single.Spec.Replicas = &replicas
+	if err := m.UpdateProcess(single); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("UpdateProcess adding replicas = %v, want ErrInvalidProcess", err)
+	}
+
+	if err := m.StopProcess("test", "web"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if status.Status.Phase != models.PhaseStopped || status.Status.Instances[0].Phase != models.PhaseStopped {
+		t.Fatalf("after stop: %s / %s, want Stopped", status.Status.Phase, status.Status.Instances[0].Phase)
+	}
+
+	// 停止状态下扩容的实例不启动
+	if err := m.ScaleProcess("test", "web", 2); err != nil {
+		t.Fatalf("ScaleProcess while stopped: %v", err)
+	}
+	status, _ = m.GetProcessStatus("test", "web")
+	if len(status.Status.Instances) != 2 || status.Status.Instances[1].Phase == models.PhaseRunning {
+		t.Fatalf("instances after scaling while stopped = %+v", status.Status.Instances)
+	}
+	if err := m.DeleteProcess("test", "web"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+}
+
+func TestManagerReplicasReload(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	replicas := 2
+	p := testProcess(dir, "web")
+	p.Spec.Replicas = &replicas
+	p.Spec.RestartPolicy = models.RestartPolicyAlways
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "web"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "web")
+	old := map[int]bool{}
+	for _, inst := range status.Status.Instances {
+		if inst.Phase != models.PhaseRunning || inst.PID <= 0 {
+			t.Fatalf("instance %s = %s pid %d, want running", inst.Name, inst.Phase, inst.PID)
+		}
+		old[inst.PID] = true
+	}
+
+	// 模拟服务重启：保存运行中的实例状态，停止进程后由新的 Manager 从同一个存储加载
+	if err := m.SaveManagedProcesses(""); err != nil {
+		t.Fatalf("SaveManagedProcesses: %v", err)
+	}
+	if err := m.StopProcess("test", "web"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	reloaded := NewManager()
+	reloaded.SetStore(m.store)
+	if err := m.store.LoadManagedProcesses(reloaded); err != nil {
+		t.Fatalf("LoadManagedProcesses: %v", err)
+	}
+	t.Cleanup(func() { deleteAllProcesses(reloaded) })
+
+	// 加载后的实例不带运行时状态，随进程自动启动
+	status, _ = reloaded.GetProcessStatus("test", "web")
+	for _, inst := range status.Status.Instances {
+		if inst.PID != 0 {
+			t.Fatalf("loaded instance %s has stale pid %d", inst.Name, inst.PID)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ = reloaded.GetProcessStatus("test", "web")
+		started := 0
+		for _, inst := range status.Status.Instances {
+			if inst.Phase == models.PhaseRunning && pidAlive(inst.PID) && !old[inst.PID] {
+				started++
+			}
+		}
+		if started == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("instances were not started again after reload: %+v", status.Status.Instances)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,500 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultRolloutMinReady 是目标重启后需要稳定运行的默认时长
+	defaultRolloutMinReady = 10 * time.Second
+	// defaultRolloutTimeout 是单个目标从重启到通过检查的默认超时时间
+	defaultRolloutTimeout = 5 * time.Minute
+	// rolloutPollInterval 是检查目标状态的间隔
+	rolloutPollInterval = 200 * time.Millisecond
+	// defaultRolloutProbeInterval 是 HealthCheck 未设置 PeriodSeconds 时滚动重启执行探针的间隔
+	defaultRolloutProbeInterval = time.Second
+	// rolloutHistoryLimit 是保留的已结束滚动重启数
+	rolloutHistoryLimit = 20
+)
+
+var (
+	// ErrRolloutNotFound 表示滚动重启不存在
+	ErrRolloutNotFound = errors.New("rollout not found")
+	// ErrRolloutConflict 表示 namespace 下已有进行中的滚动重启，或滚动重启的状态不允许该操作
+	ErrRolloutConflict = errors.New("rollout conflict")
+)
+
+// rollout 是一次滚动重启的运行时记录，state 由 Manager.rolloutMu 保护
+type rollout struct {
+	state  models.Rollout
+	cancel context.CancelFunc
+	// done 在本次执行结束后关闭，恢复时替换为新的通道
+	done chan struct{}
+}
+
+// StartRollout 按依赖顺序滚动重启 namespace 下匹配 opts.LabelSelector 的进程，Replicas 进程的每个实例各为一个目标。
+// 滚动重启在后台执行，返回创建时的状态；同一 namespace 同时只能有一个进行中的滚动重启
+func (m *Manager) StartRollout(namespace string, opts models.RolloutOptions) (models.Rollout, error) {
+	if opts.MaxUnavailable <= 0 {
+		opts.MaxUnavailable = 1
+	}
+	if opts.MinReady <= 0 {
+		opts.MinReady = defaultRolloutMinReady
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRolloutTimeout
+	}
+	processes, err := m.SelectProcesses(namespace, opts.LabelSelector)
+	if err != nil {
+		return models.Rollout{}, err
+	}
+	ordered, err := sortByDependencies(processes)
+	if err != nil {
+		return models.Rollout{}, err
+	}
+
+	targets := []models.RolloutTarget{}
+	for _, mp := range ordered {
+		// Job 运行到完成，不参与滚动重启
+		if isJob(&mp) {
+			continue
+		}
+		e, exists := m.getEntry(namespace, mp.Metadata.Name)
+		if !exists {
+			continue
+		}
+		if !e.isReplicated() {
+			targets = append(targets, models.RolloutTarget{Name: mp.Metadata.Name, Phase: models.RolloutTargetPending})
+			continue
+		}
+		for _, inst := range e.listInstances() {
+			targets = append(targets, models.RolloutTarget{
+				Name:     mp.Metadata.Name,
+				Instance: inst.snapshot().Metadata.Name,
+				Phase:    models.RolloutTargetPending,
+			})
+		}
+	}
+
+	m.rolloutMu.Lock()
+	defer m.rolloutMu.Unlock()
+	if running := m.runningRollout(namespace); running != nil {
+		return models.Rollout{}, fmt.Errorf("%w: rollout %s is in progress in namespace %s", ErrRolloutConflict, running.state.ID, namespace)
+	}
+	m.rolloutSeq++
+	r := &rollout{state: models.Rollout{
+		ID:        fmt.Sprintf("rollout-%d", m.rolloutSeq),
+		Namespace: namespace,
+		Options:   opts,
+		Phase:     models.RolloutRunning,
+		Targets:   targets,
+		CreatedAt: time.Now(),
+	}}
+	m.rollouts = append(m.rollouts, r)
+	m.pruneRollouts()
+	m.launchRollout(r)
+	log.Printf("Rollout %s started in namespace %s with %d targets", r.state.ID, namespace, len(targets))
+	return cloneRollout(&r.state), nil
+}
+
+// GetRollout 返回滚动重启的当前状态
+func (m *Manager) GetRollout(id string) (models.Rollout, error) {
+	m.rolloutMu.Lock()
+	defer m.rolloutMu.Unlock()
+	r := m.findRollout(id)
+	if r == nil {
+		return models.Rollout{}, fmt.Errorf("%w: %s", ErrRolloutNotFound, id)
+	}
+	return cloneRollout(&r.state), nil
+}
+
+// ListRollouts 按创建顺序返回 namespace 下的滚动重启，namespace 为空时返回全部
+func (m *Manager) ListRollouts(namespace string) []models.Rollout {
+	m.rolloutMu.Lock()
+	defer m.rolloutMu.Unlock()
+	result := []models.Rollout{}
+	for _, r := range m.rollouts {
+		if namespace == "" || r.state.Namespace == namespace {
+			result = append(result, cloneRollout(&r.state))
+		}
+	}
+	return result
+}
+
+// CancelRollout 取消进行中的滚动重启，等待正在检查的目标停止后返回。
+// 已重启但未通过检查的目标回到 Pending，恢复时会重新重启
+func (m *Manager) CancelRollout(id string) (models.Rollout, error) {
+	m.rolloutMu.Lock()
+	r := m.findRollout(id)
+	if r == nil {
+		m.rolloutMu.Unlock()
+		return models.Rollout{}, fmt.Errorf("%w: %s", ErrRolloutNotFound, id)
+	}
+	if r.state.Phase != models.RolloutRunning {
+		phase := r.state.Phase
+		m.rolloutMu.Unlock()
+		return models.Rollout{}, fmt.Errorf("%w: rollout %s is %s", ErrRolloutConflict, id, phase)
+	}
+	cancel, done := r.cancel, r.done
+	m.rolloutMu.Unlock()
+
+	cancel()
+	<-done
+	return m.GetRollout(id)
+}
+
+// ResumeRollout 从第一个未完成的目标继续失败或被取消的滚动重启，已完成和跳过的目标不再重启
+func (m *Manager) ResumeRollout(id string) (models.Rollout, error) {
+	m.rolloutMu.Lock()
+	defer m.rolloutMu.Unlock()
+	r := m.findRollout(id)
+	if r == nil {
+		return models.Rollout{}, fmt.Errorf("%w: %s", ErrRolloutNotFound, id)
+	}
+	if r.state.Phase != models.RolloutFailed && r.state.Phase != models.RolloutCancelled {
+		return models.Rollout{}, fmt.Errorf("%w: rollout %s is %s, only failed or cancelled rollouts can be resumed", ErrRolloutConflict, id, r.state.Phase)
+	}
+	if running := m.runningRollout(r.state.Namespace); running != nil {
+		return models.Rollout{}, fmt.Errorf("%w: rollout %s is in progress in namespace %s", ErrRolloutConflict, running.state.ID, r.state.Namespace)
+	}
+	for i := range r.state.Targets {
+		t := &r.state.Targets[i]
+		if t.Phase != models.RolloutTargetDone && t.Phase != models.RolloutTargetSkipped {
+			*t = models.RolloutTarget{Name: t.Name, Instance: t.Instance, Phase: models.RolloutTargetPending}
+		}
+	}
+	r.state.Phase = models.RolloutRunning
+	r.state.Message = ""
+	r.state.FinishedAt = nil
+	m.launchRollout(r)
+	log.Printf("Rollout %s resumed", id)
+	return cloneRollout(&r.state), nil
+}
+
+// launchRollout 在后台执行滚动重启（调用方持有 rolloutMu）
+func (m *Manager) launchRollout(r *rollout) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go m.runRollout(ctx, r, r.done)
+}
+
+// runningRollout 返回 namespace 下进行中的滚动重启（调用方持有 rolloutMu）
+func (m *Manager) runningRollout(namespace string) *rollout {
+	for _, r := range m.rollouts {
+		if r.state.Namespace == namespace && r.state.Phase == models.RolloutRunning {
+			return r
+		}
+	}
+	return nil
+}
+
+// findRollout 按 ID 查找滚动重启（调用方持有 rolloutMu）
+func (m *Manager) findRollout(id string) *rollout {
+	for _, r := range m.rollouts {
+		if r.state.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// pruneRollouts 只保留最近 rolloutHistoryLimit 个已结束的滚动重启（调用方持有 rolloutMu）
+func (m *Manager) pruneRollouts() {
+	finished := 0
+	for _, r := range m.rollouts {
+		if r.state.Phase != models.RolloutRunning {
+			finished++
+		}
+	}
+	kept := m.rollouts[:0]
+	for _, r := range m.rollouts {
+		if r.state.Phase != models.RolloutRunning && finished > rolloutHistoryLimit {
+			finished--
+			continue
+		}
+		kept = append(kept, r)
+	}
+	m.rollouts = kept
+}
+
+// cloneRollout 返回滚动重启状态的副本，调用方修改副本不影响记录
+func cloneRollout(state *models.Rollout) models.Rollout {
+	c := *state
+	c.Targets = append([]models.RolloutTarget(nil), state.Targets...)
+	return c
+}
+
+// updateRolloutTarget 修改第 i 个目标的状态
+func (m *Manager) updateRolloutTarget(r *rollout, i int, fn func(t *models.RolloutTarget)) {
+	m.rolloutMu.Lock()
+	fn(&r.state.Targets[i])
+	m.rolloutMu.Unlock()
+}
+
+// runRollout 按顺序重启未完成的目标，最多 MaxUnavailable 个同时进行。
+// 第一个失败的目标之后不再开始新的重启，已开始的目标继续完成检查；ctx 取消时立即停止
+func (m *Manager) runRollout(ctx context.Context, r *rollout, done chan struct{}) {
+	defer close(done)
+	m.rolloutMu.Lock()
+	opts := r.state.Options
+	var pending []int
+	for i, t := range r.state.Targets {
+		if t.Phase == models.RolloutTargetPending {
+			pending = append(pending, i)
+		}
+	}
+	m.rolloutMu.Unlock()
+
+	var (
+		wg       sync.WaitGroup
+		failMu   sync.Mutex
+		failure  string
+		abort    = make(chan struct{})
+		sem      = make(chan struct{}, opts.MaxUnavailable)
+		launched = 0
+	)
+	fail := func(message string) {
+		failMu.Lock()
+		defer failMu.Unlock()
+		if failure == "" {
+			failure = message
+			close(abort)
+		}
+	}
+
+launch:
+	for _, i := range pending {
+		select {
+		case sem <- struct{}{}:
+		case <-abort:
+			break launch
+		case <-ctx.Done():
+			break launch
+		}
+		if launched > 0 && opts.PauseBetween > 0 {
+			select {
+			case <-time.After(opts.PauseBetween):
+			case <-abort:
+				break launch
+			case <-ctx.Done():
+				break launch
+			}
+		}
+		// 等待期间可能已有目标失败
+		select {
+		case <-abort:
+			break launch
+		case <-ctx.Done():
+			break launch
+		default:
+		}
+		launched++
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := m.rolloutTarget(ctx, r, i, opts); err != nil && ctx.Err() == nil {
+				m.rolloutMu.Lock()
+				name := r.state.Targets[i].DisplayName()
+				m.rolloutMu.Unlock()
+				fail(fmt.Sprintf("%s: %v", name, err))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	m.rolloutMu.Lock()
+	defer m.rolloutMu.Unlock()
+	now := time.Now()
+	r.state.FinishedAt = &now
+	switch {
+	case failure != "":
+		r.state.Phase = models.RolloutFailed
+		r.state.Message = failure
+	case ctx.Err() != nil:
+		r.state.Phase = models.RolloutCancelled
+		r.state.Message = "cancelled by user"
+	default:
+		r.state.Phase = models.RolloutSucceeded
+	}
+	r.cancel()
+	log.Printf("Rollout %s finished: %s %s", r.state.ID, r.state.Phase, r.state.Message)
+}
+
+// rolloutTarget 重启第 i 个目标并等待它通过检查。未运行或已删除的目标被跳过；
+// ctx 取消时目标回到 Pending
+func (m *Manager) rolloutTarget(ctx context.Context, r *rollout, i int, opts models.RolloutOptions) error {
+	m.rolloutMu.Lock()
+	namespace, target := r.state.Namespace, r.state.Targets[i]
+	m.rolloutMu.Unlock()
+
+	finish := func(phase models.RolloutTargetPhase, pid int, message string) {
+		m.updateRolloutTarget(r, i, func(t *models.RolloutTarget) {
+			now := time.Now()
+			t.Phase = phase
+			t.PID = pid
+			t.Message = message
+			t.FinishedAt = &now
+			if phase == models.RolloutTargetPending {
+				t.FinishedAt = nil
+			}
+		})
+	}
+
+	e := m.rolloutEntry(namespace, &target)
+	if e == nil {
+		finish(models.RolloutTargetSkipped, 0, "process no longer exists")
+		return nil
+	}
+	if !isActivePhase(e.snapshot().Status.Phase) {
+		finish(models.RolloutTargetSkipped, 0, "process is not running")
+		return nil
+	}
+
+	m.updateRolloutTarget(r, i, func(t *models.RolloutTarget) {
+		now := time.Now()
+		t.Phase = models.RolloutTargetRestarting
+		t.StartedAt = &now
+	})
+	if err := e.do(reconcileEvent{kind: eventRestart}); err != nil {
+		finish(models.RolloutTargetFailed, 0, err.Error())
+		return err
+	}
+	m.updateRolloutTarget(r, i, func(t *models.RolloutTarget) {
+		t.Phase = models.RolloutTargetVerifying
+		t.PID = e.snapshot().Status.PID
+	})
+
+	pid, err := m.waitRolloutReady(ctx, e, opts)
+	switch {
+	case ctx.Err() != nil:
+		finish(models.RolloutTargetPending, 0, "cancelled before the process became ready")
+		return ctx.Err()
+	case err != nil:
+		finish(models.RolloutTargetFailed, pid, err.Error())
+		return err
+	}
+	finish(models.RolloutTargetDone, pid, "")
+	return nil
+}
+
+// rolloutEntry 返回目标对应的进程或实例记录，不存在时返回 nil
+func (m *Manager) rolloutEntry(namespace string, target *models.RolloutTarget) *processEntry {
+	e, exists := m.getEntry(namespace, target.Name)
+	if !exists {
+		return nil
+	}
+	if target.Instance == "" {
+		if e.isReplicated() {
+			return nil
+		}
+		return e
+	}
+	key := fmt.Sprintf("%s/%s", namespace, target.Instance)
+	for _, inst := range e.listInstances() {
+		if inst.key == key {
+			return inst
+		}
+	}
+	return nil
+}
+
+// waitRolloutReady 等待重启后的进程保持 Running、PID 不变且（设置了 HealthCheck 时）探针持续通过 opts.MinReady，
+// 返回进程号。探针失败时重新计时，进程退出、重启或超过 opts.Timeout 时返回错误
+func (m *Manager) waitRolloutReady(ctx context.Context, e *processEntry, opts models.RolloutOptions) (int, error) {
+	start := e.snapshot()
+	hc := start.Spec.HealthCheck
+	if hc != nil && hc.Exec == nil && hc.TCP == nil && hc.HTTP == nil {
+		hc = nil
+	}
+	if hc != nil && hc.Exec != nil {
+		// exec 探针使用与进程相同的环境变量
+		if err := m.expandEnv(&start); err != nil {
+			log.Printf("Warning: process %s: %v", e.key, err)
+		}
+	}
+	probeInterval := defaultRolloutProbeInterval
+	if hc != nil && hc.PeriodSeconds > 0 {
+		probeInterval = time.Duration(hc.PeriodSeconds) * time.Second
+	}
+	deadline := time.Now().Add(opts.Timeout)
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	var (
+		pid        int
+		readySince time.Time
+		lastProbe  time.Time
+		probeErr   error
+	)
+	for {
+		mp := e.snapshot()
+		now := time.Now()
+		switch phase := mp.Status.Phase; {
+		case phase == models.PhaseRunning:
+			if pid == 0 {
+				pid = mp.Status.PID
+			} else if mp.Status.PID != pid {
+				return pid, fmt.Errorf("process restarted (pid %d -> %d)", pid, mp.Status.PID)
+			}
+			if mp.Status.RestartCount != start.Status.RestartCount {
+				return pid, fmt.Errorf("process restarted %d time(s) after the rollout restart", mp.Status.RestartCount-start.Status.RestartCount)
+			}
+			if hc == nil {
+				if readySince.IsZero() {
+					readySince = now
+				}
+			} else if now.Sub(lastProbe) >= probeInterval {
+				lastProbe = now
+				if probeErr = RunHealthCheck(ctx, &start, hc); probeErr != nil {
+					readySince = time.Time{}
+				} else if readySince.IsZero() {
+					readySince = now
+				}
+			}
+			if !readySince.IsZero() && now.Sub(readySince) >= opts.MinReady {
+				return pid, nil
+			}
+		case pid == 0 && (phase == models.PhasePending || phase == models.PhaseStopping):
+			// 仍在启动
+		default:
+			return pid, fmt.Errorf("process entered phase %s", phaseOrUnknown(phase))
+		}
+
+		if now.After(deadline) {
+			if probeErr != nil {
+				return pid, fmt.Errorf("not ready after %s: health check failed: %v", opts.Timeout, probeErr)
+			}
+			return pid, fmt.Errorf("not ready after %s (phase %s)", opts.Timeout, phaseOrUnknown(mp.Status.Phase))
+		}
+		select {
+		case <-ctx.Done():
+			return pid, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// waitForRollout 等待滚动重启结束
+func waitForRollout(t *testing.T, m *Manager, id string) models.Rollout {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		r, err := m.GetRollout(id)
+		if err != nil {
+			t.Fatalf("GetRollout: %v", err)
+		}
+		if r.Phase != models.RolloutRunning {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rollout %s did not finish: %+v", id, r)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// rolloutTargets 返回 "目标:状态" 列表
+func rolloutTargets(r models.Rollout) string {
+	var targets []string
+	for _, t := range r.Targets {
+		targets = append(targets, t.DisplayName()+":"+string(t.Phase))
+	}
+	return strings.Join(targets, ",")
+}
+
+func TestManagerRollout(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	replicas := 2
+	web := testProcess(dir, "web")
+	web.Spec.Replicas = &replicas
+	web.Spec.DependsOn = []models.Dependency{{Name: "db"}}
+	gateway := testProcess(dir, "gateway")
+	gateway.Spec.HealthCheck = &models.HealthCheck{Exec: &models.CommandConfig{Command: "test", Args: []string{"-f", filepath.Join(dir, "gateway.ready")}}}
+	pids := map[string]int{}
+	for _, p := range []models.ManagedProcess{web, testProcess(dir, "db"), gateway, testProcess(dir, "idle")} {
+		p.Metadata.Labels = map[string]string{"app": "shop"}
+		if err := m.CreateProcess(p); err != nil {
+			t.Fatalf("CreateProcess %s: %v", p.Metadata.Name, err)
+		}
+		if p.Metadata.Name == "idle" {
+			continue
+		}
+		if err := m.StartProcess("test", p.Metadata.Name); err != nil {
+			t.Fatalf("StartProcess %s: %v", p.Metadata.Name, err)
+		}
+	}
+	for _, e := range m.runEntries() {
+		mp := e.snapshot()
+		pids[mp.Metadata.Name] = mp.Status.PID
+	}
+
+	if _, err := m.StartRollout("test", models.RolloutOptions{LabelSelector: "app in (shop"}); !errors.Is(err, ErrInvalidSelector) {
+		t.Fatalf("StartRollout with an invalid selector = %v, want ErrInvalidSelector", err)
+	}
+
+	// gateway 的健康检查不通过：依赖顺序上在它之前的目标完成，之后的目标不再重启
+	opts := models.RolloutOptions{LabelSelector: "app=shop", MinReady: 200 * time.Millisecond, Timeout: 700 * time.Millisecond}
+	r, err := m.StartRollout("test", opts)
+	if err != nil {
+		t.Fatalf("StartRollout: %v", err)
+	}
+	if _, err := m.StartRollout("test", opts); !errors.Is(err, ErrRolloutConflict) {
+		t.Fatalf("second StartRollout = %v, want ErrRolloutConflict", err)
+	}
+	r = waitForRollout(t, m, r.ID)
+	if got, want := rolloutTargets(r), "db:Done,gateway:Failed,idle:Pending,web-0:Pending,web-1:Pending"; r.Phase != models.RolloutFailed || got != want {
+		t.Fatalf("rollout = %s %s, want Failed %s", r.Phase, got, want)
+	}
+	if !strings.Contains(r.Message, "gateway: not ready after") || !strings.Contains(r.Message, "health check failed") {
+		t.Fatalf("rollout message = %q", r.Message)
+	}
+	if r.Targets[0].PID == pids["db"] || r.Targets[0].PID == 0 {
+		t.Fatalf("db was not restarted: pid %d, was %d", r.Targets[0].PID, pids["db"])
+	}
+	if status, _ := m.GetProcessStatus("test", "web"); status.Status.Instances[0].Status.PID != pids["web-0"] {
+		t.Fatalf("web-0 was restarted after the rollout failed")
+	}
+
+	// 恢复时只重启未完成的目标，上一个目标通过检查后才重启下一个
+	if err := os.WriteFile(filepath.Join(dir, "gateway.ready"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ResumeRollout(r.ID); err != nil {
+		t.Fatalf("ResumeRollout: %v", err)
+	}
+	dbPID := r.Targets[0].PID
+	r = waitForRollout(t, m, r.ID)
+	if got, want := rolloutTargets(r), "db:Done,gateway:Done,idle:Skipped,web-0:Done,web-1:Done"; r.Phase != models.RolloutSucceeded || got != want {
+		t.Fatalf("resumed rollout = %s %s (%s), want Succeeded %s", r.Phase, got, r.Message, want)
+	}
+	if r.Targets[0].PID != dbPID {
+		t.Fatalf("db was restarted again on resume")
+	}
+	for i := 3; i < len(r.Targets); i++ {
+		prev, cur := r.Targets[i-1], r.Targets[i]
+		if cur.PID == pids[cur.Instance] || cur.StartedAt.Before(*prev.FinishedAt) {
+			t.Fatalf("%s restarted before %s was ready (or not restarted)", cur.Instance, prev.DisplayName())
+		}
+	}
+	if _, err := m.ResumeRollout(r.ID); !errors.Is(err, ErrRolloutConflict) {
+		t.Fatalf("ResumeRollout of a succeeded rollout = %v, want ErrRolloutConflict", err)
+	}
+
+	// 取消时正在检查的目标回到 Pending
+	opts.MinReady = time.Minute
+	r, err = m.StartRollout("test", opts)
+	if err != nil {
+		t.Fatalf("StartRollout: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); r.Targets[0].Phase != models.RolloutTargetVerifying; r, _ = m.GetRollout(r.ID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("db is not being verified: %s", rolloutTargets(r))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	r, err = m.CancelRollout(r.ID)
+	if err != nil {
+		t.Fatalf("CancelRollout: %v", err)
+	}
+	if got := rolloutTargets(r); r.Phase != models.RolloutCancelled || !strings.HasPrefix(got, "db:Pending,gateway:Pending") {
+		t.Fatalf("cancelled rollout = %s %s", r.Phase, got)
+	}
+	if list := m.ListRollouts("test"); len(list) != 2 || len(m.ListRollouts("other")) != 0 {
+		t.Fatalf("ListRollouts = %d rollouts, want 2", len(list))
+	}
+}
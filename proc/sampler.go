@@ -0,0 +1,198 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	// sampleInterval 资源采样周期
+	sampleInterval = 5 * time.Second
+	// checkInterval 重关联检查周期
+	checkInterval = 30 * time.Second
+	// sampleWorkers 并发采样的协程数，避免进程很多时一轮采样超过采样周期
+	sampleWorkers = 8
+)
+
+// startSampler 启动所有进程共享的采样器
+func (m *Manager) startSampler() {
+	m.samplerOnce.Do(func() {
+		go m.runSampler()
+	})
+}
+
+// runSampler 定期采样运行中进程的资源使用情况，并检查进程是否需要重关联
+func (m *Manager) runSampler() {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	checkTicker := time.NewTicker(checkInterval)
+	defer checkTicker.Stop()
+	pruneTicker := time.NewTicker(eventPruneInterval)
+	defer pruneTicker.Stop()
+
+	// 按进程缓存 process.Process，CPU 使用率按两次采样之间的增量计算
+	cache := make(map[string]*process.Process)
+	for {
+		select {
+		case <-ticker.C:
+			m.sampleAll(cache)
+		case <-checkTicker.C:
+			m.checkAll()
+		case <-pruneTicker.C:
+			m.pruneEvents()
+		}
+	}
+}
+
+type sampleJob struct {
+	entry *processEntry
+	// pid 是进程状态中的 PID，proc 是实际采样的进程（隔离进程为 init 启动的目标进程）
+	pid  int
+	proc *process.Process
+}
+
+// sampleAll 对所有运行中的进程采样一次
+func (m *Manager) sampleAll(cache map[string]*process.Process) {
+	m.sampleMu.Lock()
+	defer m.sampleMu.Unlock()
+
+	var jobs []sampleJob
+	seen := make(map[string]bool)
+	for _, e := range m.runEntries() {
+		e.mu.RLock()
+		pid := e.process.Status.PID
+		running := e.process.Status.Phase == models.PhaseRunning
+		isolated := e.process.Spec.Isolation != nil
+		e.mu.RUnlock()
+		if !running || pid <= 0 {
+			continue
+		}
+		target := samplePID(isolated, pid)
+
+		p := cache[e.key]
+		if p == nil || int(p.Pid) != target {
+			var err error
+			if p, err = process.NewProcess(int32(target)); err != nil {
+				continue
+			}
+			cache[e.key] = p
+		}
+		seen[e.key] = true
+		jobs = append(jobs, sampleJob{entry: e, pid: pid, proc: p})
+	}
+	for key := range cache {
+		if !seen[key] {
+			delete(cache, key)
+		}
+	}
+
+	queue := make(chan sampleJob)
+	var wg sync.WaitGroup
+	for i := 0; i < sampleWorkers && i < len(jobs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if stats := sampleEntry(job.entry, job.pid, job.proc); stats != nil {
+					m.checkWatchdogs(job.entry, job.pid, stats, time.Now())
+					job.entry.saveProcSnapshot(job.pid, stats)
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// sampleEntry 采集单个进程的资源使用情况，进程已变化时返回 nil
+func sampleEntry(e *processEntry, pid int, p *process.Process) *models.ResourceStats {
+	stats := collectProcessResourceUsage(p, 0)
+
+	// 使用 cgroup 统计覆盖 CPU、内存和 IO（包含子进程）
+	e.mu.RLock()
+	cg := e.cgroup
+	e.mu.RUnlock()
+	if cg != nil {
+		cg.fillStats(stats)
+	}
+
+	stats.SetFormattedValues()
+	if !e.setResourceStats(pid, stats) {
+		return nil
+	}
+	return stats
+}
+
+// checkAll 检查重关联的进程是否仍然存在，并尝试重关联未运行的进程。
+// vigil 启动的子进程通过 cmd.Wait 感知退出，不需要检查。
+func (m *Manager) checkAll() {
+	candidates, err := process.Processes()
+	if err != nil {
+		fmt.Printf("Failed to list system processes: %v\n", err)
+		return
+	}
+	owned := m.ownedPIDs()
+
+	for _, e := range m.runEntries() {
+		e.mu.RLock()
+		mp := cloneProcess(&e.process)
+		adopted := e.adopted
+		fingerprinted := e.adoptedStartTime > 0
+		stoppedByUser := e.stoppedByUser
+		e.mu.RUnlock()
+		if isJob(&mp) {
+			// Job 只跟踪自己启动的子进程
+			continue
+		}
+
+		switch mp.Status.Phase {
+		case models.PhaseRunning:
+			// 有启动时间指纹的重关联进程由 waitProcessExit 跟踪退出
+			if !adopted || fingerprinted || m.pidMatches(&mp, mp.Status.PID) {
+				continue
+			}
+			// 原 PID 已失效，尝试重关联到新的 PID，失败则标记停止
+			e.send(reconcileEvent{kind: eventLost, pid: mp.Status.PID})
+			if pid, err := m.findMatchingPID(&mp, candidates, owned); err == nil && pid > 0 {
+				owned[pid] = true
+				e.send(reconcileEvent{kind: eventAdopted, pid: pid})
+			}
+
+		case models.PhasePending, models.PhaseStopping:
+			// 正在启动或停止，由 reconcile 协程处理
+
+		default:
+			// 非运行态（Failed/Stopped）：尝试发现进程是否已重新起来并重关联
+			if stoppedByUser {
+				continue
+			}
+			if pid, err := m.findMatchingPID(&mp, candidates, owned); err == nil && pid > 0 {
+				owned[pid] = true
+				e.send(reconcileEvent{kind: eventAdopted, pid: pid})
+			}
+		}
+	}
+}
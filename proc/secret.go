@@ -0,0 +1,216 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/casuallc/vigil/crypto"
+	"github.com/casuallc/vigil/docker"
+	"github.com/casuallc/vigil/models"
+)
+
+var (
+	// ErrSecretNotFound 密钥不存在
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrSecretInUse 密钥仍被进程引用
+	ErrSecretInUse = errors.New("secret is in use")
+	// ErrInvalidSecret 密钥名称或值不合法
+	ErrInvalidSecret = errors.New("invalid secret")
+)
+
+// secretRefPattern 匹配环境变量值中的 ${secret:name}
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([^}]*)\}`)
+
+// secretNamePattern 是合法的密钥名称
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// SetEncryptionKey 设置加密密钥使用的 security.encryption_key
+func (m *Manager) SetEncryptionKey(key string) {
+	m.secretKey = key
+}
+
+// secretStoreReady 检查密钥存储是否可用
+func (m *Manager) secretStoreReady() error {
+	if m.store == nil {
+		return fmt.Errorf("secret store requires a process database")
+	}
+	if m.secretKey == "" {
+		return fmt.Errorf("secret store requires security.encryption_key")
+	}
+	return nil
+}
+
+// SetSecret 新建或更新密钥，值加密后保存，返回是否为新建
+func (m *Manager) SetSecret(name, value string) (bool, error) {
+	if !secretNamePattern.MatchString(name) {
+		return false, fmt.Errorf("%w: name %q must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", ErrInvalidSecret, name)
+	}
+	if err := m.secretStoreReady(); err != nil {
+		return false, err
+	}
+	encrypted, err := crypto.Encrypt(value, m.secretKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+	return m.store.SaveSecret(name, encrypted)
+}
+
+// ListSecrets 返回所有密钥的名称和时间，不包含值
+func (m *Manager) ListSecrets() ([]models.Secret, error) {
+	if err := m.secretStoreReady(); err != nil {
+		return nil, err
+	}
+	return m.store.ListSecrets()
+}
+
+// DeleteSecret 删除密钥，仍被进程引用时返回 ErrSecretInUse
+func (m *Manager) DeleteSecret(name string) error {
+	if err := m.secretStoreReady(); err != nil {
+		return err
+	}
+	var users []string
+	for _, e := range m.listEntries() {
+		mp := e.snapshot()
+		for _, ref := range secretRefs(&mp.Spec) {
+			if ref == name {
+				users = append(users, mp.Metadata.Namespace+"/"+mp.Metadata.Name)
+				break
+			}
+		}
+	}
+	if len(users) > 0 {
+		sort.Strings(users)
+		return fmt.Errorf("%w: secret %s is referenced by %s", ErrSecretInUse, name, strings.Join(users, ", "))
+	}
+
+	found, err := m.store.DeleteSecret(name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return nil
+}
+
+// resolveSecret 返回解密后的密钥值
+func (m *Manager) resolveSecret(name string) (string, error) {
+	if err := m.secretStoreReady(); err != nil {
+		return "", err
+	}
+	encrypted, err := m.store.GetSecret(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load secret %s: %v", name, err)
+	}
+	value, err := crypto.Decrypt(encrypted, m.secretKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s (wrong encryption_key?): %v", name, err)
+	}
+	return value, nil
+}
+
+// secretRefs 返回 Spec.Env 中引用的密钥名称
+func secretRefs(spec *models.Spec) []string {
+	var refs []string
+	for _, env := range spec.Env {
+		for _, match := range secretRefPattern.FindAllStringSubmatch(env.Value, -1) {
+			refs = append(refs, match[1])
+		}
+	}
+	return refs
+}
+
+// validateEnv 校验 Env 和 EnvFrom
+func validateEnv(spec *models.Spec) error {
+	for _, env := range spec.Env {
+		if env.Name == "" || strings.ContainsAny(env.Name, "=\x00") {
+			return fmt.Errorf("env: invalid variable name %q", env.Name)
+		}
+	}
+	for _, ref := range secretRefs(spec) {
+		if !secretNamePattern.MatchString(ref) {
+			return fmt.Errorf("env: invalid secret reference ${secret:%s}", ref)
+		}
+	}
+	for _, src := range spec.EnvFrom {
+		if src.File == "" {
+			return fmt.Errorf("env_from: file is required")
+		}
+	}
+	return nil
+}
+
+// expandEnv 把 EnvFrom 中的变量合并到 Spec.Env 之前，并把 ${secret:name} 替换为密钥值。
+// 只作用于运行时使用的副本，展开后的值不会保存或通过接口返回。
+func (m *Manager) expandEnv(mp *models.ManagedProcess) error {
+	var env []models.EnvVar
+	for _, src := range mp.Spec.EnvFrom {
+		path := src.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(processWorkingDir(mp), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			if src.Optional && os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("env_from %s: %v", src.File, err)
+		}
+		vars, err := docker.LoadEnvFile(path)
+		if err != nil {
+			return fmt.Errorf("env_from %s: %v", src.File, err)
+		}
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			env = append(env, models.EnvVar{Name: src.Prefix + name, Value: vars[name]})
+		}
+	}
+	// Env 在后，同名变量以 Env 为准
+	env = append(env, mp.Spec.Env...)
+
+	for i := range env {
+		var resolveErr error
+		env[i].Value = secretRefPattern.ReplaceAllStringFunc(env[i].Value, func(ref string) string {
+			value, err := m.resolveSecret(secretRefPattern.FindStringSubmatch(ref)[1])
+			if err != nil && resolveErr == nil {
+				resolveErr = fmt.Errorf("env %s: %w", env[i].Name, err)
+			}
+			return value
+		})
+		if resolveErr != nil {
+			return resolveErr
+		}
+	}
+
+	mp.Spec.Env = env
+	mp.Spec.EnvFrom = nil
+	return nil
+}
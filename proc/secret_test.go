@@ -0,0 +1,110 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// testEncryptionKey 是 base64 编码的 32 字节测试密钥
+const testEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestManagerSecrets(t *testing.T) {
+	m := newTestManager(t)
+	m.SetEncryptionKey(testEncryptionKey)
+	dir := t.TempDir()
+
+	if created, err := m.SetSecret("db-password", "old"); err != nil || !created {
+		t.Fatalf("SetSecret = %t, %v, want created", created, err)
+	}
+	if created, err := m.SetSecret("db-password", "s3cr=t"); err != nil || created {
+		t.Fatalf("SetSecret update = %t, %v, want updated", created, err)
+	}
+	if _, err := m.SetSecret("bad name", "x"); !errors.Is(err, ErrInvalidSecret) {
+		t.Fatalf("SetSecret with invalid name = %v, want ErrInvalidSecret", err)
+	}
+	secrets, err := m.ListSecrets()
+	if err != nil || len(secrets) != 1 || secrets[0].Name != "db-password" || secrets[0].Value != "" {
+		t.Fatalf("ListSecrets = %+v, %v, want db-password without value", secrets, err)
+	}
+
+	envFile := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(envFile, []byte("# comment\nHOST=db.local\nPORT='5432'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	p := testProcess(dir, "app")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `echo "$APP_HOST:$APP_PORT $DB_PASSWORD" > ` + out + "; sleep 300"}}
+	p.Spec.EnvFrom = []models.EnvFromSource{
+		{File: "app.env", Prefix: "APP_"},
+		{File: "missing.env", Optional: true},
+	}
+	p.Spec.Env = []models.EnvVar{{Name: "DB_PASSWORD", Value: "${secret:db-password}"}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if got, want := waitForFile(t, out), "db.local:5432 s3cr=t"; got != want {
+		t.Fatalf("process env = %q, want %q", got, want)
+	}
+
+	// 解析后的值不会出现在进程定义中
+	got, _ := m.GetProcessStatus("test", "app")
+	if len(got.Spec.Env) != 1 || got.Spec.Env[0].Value != "${secret:db-password}" || len(got.Spec.EnvFrom) != 2 {
+		t.Fatalf("stored spec env = %+v, env_from = %+v, want references only", got.Spec.Env, got.Spec.EnvFrom)
+	}
+
+	// 被引用的密钥不能删除
+	if err := m.DeleteSecret("db-password"); !errors.Is(err, ErrSecretInUse) {
+		t.Fatalf("DeleteSecret in use = %v, want ErrSecretInUse", err)
+	}
+	_ = m.StopProcess("test", "app")
+	if err := m.DeleteProcess("test", "app"); err != nil {
+		t.Fatalf("DeleteProcess: %v", err)
+	}
+	if err := m.DeleteSecret("db-password"); err != nil {
+		t.Fatalf("DeleteSecret: %v", err)
+	}
+	if err := m.DeleteSecret("db-password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("DeleteSecret again = %v, want ErrSecretNotFound", err)
+	}
+
+	// 引用不存在的密钥或缺少必需的 env 文件时启动失败
+	p = testProcess(dir, "broken")
+	p.Spec.Env = []models.EnvVar{{Name: "TOKEN", Value: "${secret:missing}"}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "broken"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("StartProcess with missing secret = %v, want ErrSecretNotFound", err)
+	}
+	p.Spec.Env = nil
+	p.Spec.EnvFrom = []models.EnvFromSource{{File: "missing.env"}}
+	if err := m.UpdateProcess(p); err != nil {
+		t.Fatalf("UpdateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "broken"); err == nil {
+		t.Fatalf("StartProcess with missing env file: want error")
+	}
+}
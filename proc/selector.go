@@ -0,0 +1,187 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// ErrInvalidSelector 表示标签选择器的语法错误，API 层据此返回 400
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+// selectorOperator 是标签选择器条件的操作符
+type selectorOperator string
+
+const (
+	selectorEquals       selectorOperator = "="
+	selectorNotEquals    selectorOperator = "!="
+	selectorIn           selectorOperator = "in"
+	selectorNotIn        selectorOperator = "notin"
+	selectorExists       selectorOperator = "exists"
+	selectorDoesNotExist selectorOperator = "!"
+)
+
+var (
+	// selectorSetPattern 匹配 "key in (a,b)" 和 "key notin (a,b)"
+	selectorSetPattern = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	selectorKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	// selectorValuePattern 匹配标签值，值可以为空
+	selectorValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+)
+
+// labelRequirement 是标签选择器中的一个条件
+type labelRequirement struct {
+	key    string
+	op     selectorOperator
+	values []string
+}
+
+// LabelSelector 是 Kubernetes 风格的标签选择器，各条件之间为“与”
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector 解析标签选择器，条件用逗号分隔：key=value（或 ==）、key!=value、
+// key in (a,b)、key notin (a,b)、key（存在）、!key（不存在）。空字符串匹配所有进程
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, part := range splitSelector(s) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: empty requirement in %q", ErrInvalidSelector, s)
+		}
+		req, err := parseRequirement(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// splitSelector 按括号外的逗号拆分条件
+func splitSelector(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func parseRequirement(s string) (labelRequirement, error) {
+	var req labelRequirement
+	switch {
+	case selectorSetPattern.MatchString(s):
+		m := selectorSetPattern.FindStringSubmatch(s)
+		req = labelRequirement{key: m[1], op: selectorOperator(m[2])}
+		for _, v := range strings.Split(m[3], ",") {
+			req.values = append(req.values, strings.TrimSpace(v))
+		}
+	case strings.HasPrefix(s, "!") && !strings.ContainsAny(s, "=()"):
+		req = labelRequirement{key: strings.TrimSpace(s[1:]), op: selectorDoesNotExist}
+	case strings.Contains(s, "!="):
+		key, value, _ := strings.Cut(s, "!=")
+		req = labelRequirement{key: strings.TrimSpace(key), op: selectorNotEquals, values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(s, "="):
+		key, value, _ := strings.Cut(s, "=")
+		value = strings.TrimPrefix(value, "=")
+		req = labelRequirement{key: strings.TrimSpace(key), op: selectorEquals, values: []string{strings.TrimSpace(value)}}
+	default:
+		req = labelRequirement{key: s, op: selectorExists}
+	}
+
+	if !selectorKeyPattern.MatchString(req.key) {
+		return req, fmt.Errorf("invalid key in %q", s)
+	}
+	for _, v := range req.values {
+		if !selectorValuePattern.MatchString(v) {
+			return req, fmt.Errorf("invalid value %q in %q", v, s)
+		}
+	}
+	return req, nil
+}
+
+// Matches 标签是否满足所有条件
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	value, exists := labels[r.key]
+	switch r.op {
+	case selectorEquals:
+		return exists && value == r.values[0]
+	case selectorNotEquals:
+		return !exists || value != r.values[0]
+	case selectorIn:
+		return exists && containsString(r.values, value)
+	case selectorNotIn:
+		return !exists || !containsString(r.values, value)
+	case selectorExists:
+		return exists
+	case selectorDoesNotExist:
+		return !exists
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectProcesses 返回 namespace 下标签匹配选择器的进程
+func (m *Manager) SelectProcesses(namespace, selector string) ([]models.ManagedProcess, error) {
+	sel, err := ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	processes, _ := m.ListManagedProcesses(namespace)
+	result := make([]models.ManagedProcess, 0, len(processes))
+	for _, p := range processes {
+		if sel.Matches(p.Metadata.Labels) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
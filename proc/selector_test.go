@@ -0,0 +1,64 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	labels := map[string]string{"app": "kafka", "tier": "backend", "env": "prod"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"app=kafka", true},
+		{"app==kafka", true},
+		{"app=zookeeper", false},
+		{"app=kafka,tier!=dev", true},
+		{"app=kafka,tier!=backend", false},
+		{"missing!=x", true},
+		{"env in (prod,stage)", true},
+		{"env in (dev, stage)", false},
+		{"env notin (dev,stage)", true},
+		{"missing notin (a)", true},
+		{"missing in (a)", false},
+		{"tier", true},
+		{"missing", false},
+		{"!missing", true},
+		{"!app", false},
+		{" app = kafka , env in ( prod ) ", true},
+		{"example.com/team=", false},
+	}
+	for _, tt := range tests {
+		s, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q): %v", tt.selector, err)
+		}
+		if got := s.Matches(labels); got != tt.want {
+			t.Errorf("%q matches = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	for _, selector := range []string{"app=kafka,", ",", "=kafka", "app in (a", "app=ka fka", "-app", "app in (a,b c)"} {
+		if _, err := ParseLabelSelector(selector); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("ParseLabelSelector(%q) = %v, want ErrInvalidSelector", selector, err)
+		}
+	}
+}
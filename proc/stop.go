@@ -0,0 +1,152 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+const (
+	// defaultStopGracePeriod 发送停止信号后等待退出的默认时间
+	defaultStopGracePeriod = 10 * time.Second
+	// killWaitTimeout 发送 SIGKILL 后等待进程被回收的时间
+	killWaitTimeout = 5 * time.Second
+)
+
+// stopResult 记录一次信号停止的过程
+type stopResult struct {
+	// signal 是发送的停止信号
+	signal string
+	// escalated 表示宽限期后发送了 SIGKILL
+	escalated bool
+}
+
+// parseSignal 解析信号名（SIGTERM、TERM）或信号编号
+func parseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid signal %q", name)
+		}
+		return syscall.Signal(n), nil
+	}
+	upper := strings.ToUpper(name)
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	if sig, ok := stopSignals[upper]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unsupported signal %q", name)
+}
+
+// signalName 返回信号的名称，未知信号返回编号
+func signalName(sig syscall.Signal) string {
+	for name, s := range stopSignals {
+		if s == sig {
+			return name
+		}
+	}
+	return strconv.Itoa(int(sig))
+}
+
+// stopOptions 返回停止信号、宽限期和 KillMode，未配置时使用默认值
+func stopOptions(ex models.Exec) (syscall.Signal, time.Duration, models.KillMode, error) {
+	sig := syscall.SIGTERM
+	if ex.StopSignal != "" {
+		var err error
+		if sig, err = parseSignal(ex.StopSignal); err != nil {
+			return 0, 0, "", fmt.Errorf("stop_signal: %v", err)
+		}
+	}
+	if ex.StopGracePeriod < 0 {
+		return 0, 0, "", fmt.Errorf("stop_grace_period must not be negative")
+	}
+	grace := ex.StopGracePeriod
+	if grace == 0 {
+		grace = defaultStopGracePeriod
+	}
+	mode := ex.KillMode
+	switch mode {
+	case "":
+		mode = models.KillModeControlGroup
+	case models.KillModeControlGroup, models.KillModeProcess, models.KillModeMixed:
+	default:
+		return 0, 0, "", fmt.Errorf("unsupported kill_mode %q", mode)
+	}
+	return sig, grace, mode, nil
+}
+
+// signalStop 按 Exec 的停止配置终止进程：发送 StopSignal，宽限期内未退出则发送 SIGKILL。
+// vigil 启动的进程是独立会话的首进程，进程组 ID 等于 PID；cgroup 内的进程同样视为进程组成员。
+func (m *Manager) signalStop(run *processRun, pid int, ex models.Exec) (stopResult, error) {
+	sig, grace, mode, err := stopOptions(ex)
+	if err != nil {
+		return stopResult{}, err
+	}
+	result := stopResult{signal: signalName(sig)}
+
+	var cg *processCgroup
+	if run != nil {
+		cg = run.cgroup
+	}
+	// sd_notify 的 MAINPID 可能不是 vigil 启动的进程，进程组仍以启动的进程为准
+	leader := pid
+	if run != nil {
+		leader = run.pid
+	}
+	group := processGroup{pgid: processGroupID(leader, run != nil), cgroup: cg}
+
+	if mode == models.KillModeControlGroup {
+		err = group.signal(pid, sig)
+	} else {
+		err = signalProcess(pid, sig)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	deadline := time.Now().Add(grace)
+	exited := m.waitForExit(run, pid, grace)
+	if exited && mode == models.KillModeControlGroup {
+		// 主进程退出后，在剩余的宽限期内等待进程组内的其他进程退出
+		exited = group.waitEmpty(deadline)
+	}
+
+	switch {
+	case !exited:
+		result.escalated = true
+		if mode == models.KillModeProcess {
+			err = signalProcess(pid, syscall.SIGKILL)
+		} else {
+			err = group.signal(pid, syscall.SIGKILL)
+		}
+		if err != nil {
+			return result, err
+		}
+		m.waitForExit(run, pid, killWaitTimeout)
+	case mode == models.KillModeMixed:
+		// 主进程已退出，清理进程组内的残留进程
+		_ = group.signal(pid, syscall.SIGKILL)
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !windows
+
+package proc
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"SIGTERM", "term", "15"} {
+		if sig, err := parseSignal(name); err != nil || sig != syscall.SIGTERM {
+			t.Fatalf("parseSignal(%q) = %v, %v, want SIGTERM", name, sig, err)
+		}
+	}
+	if _, err := parseSignal("SIGNOPE"); err == nil {
+		t.Fatalf("parseSignal(SIGNOPE): want error")
+	}
+
+	m := NewManager()
+	p := testProcess(t.TempDir(), "bad")
+	p.Spec.Exec.KillMode = "everything"
+	if err := m.CreateProcess(p); !errors.Is(err, ErrInvalidProcess) {
+		t.Fatalf("CreateProcess with unknown kill_mode = %v, want ErrInvalidProcess", err)
+	}
+}
+
+func TestManagerStopProcessGroup(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "child.pid")
+
+	// shell 包装的进程：停止信号必须送达后台的子进程
+	p := testProcess(dir, "wrapper")
+	p.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", "sleep 300 & echo $! > " + pidFile + "; wait"}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "wrapper"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	child, err := strconv.Atoi(waitForFile(t, pidFile))
+	if err != nil {
+		t.Fatalf("child pid: %v", err)
+	}
+
+	if err := m.StopProcess("test", "wrapper"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if pidAlive(child) {
+		_ = syscall.Kill(child, syscall.SIGKILL)
+		t.Fatalf("child %d still running after stop", child)
+	}
+	got, _ := m.GetProcessStatus("test", "wrapper")
+	info := got.Status.LastTerminationInfo
+	if info == nil || info.StopSignal != "SIGTERM" || info.Escalated {
+		t.Fatalf("LastTerminationInfo = %+v, want SIGTERM without escalation", info)
+	}
+}
+
+func TestManagerStopEscalation(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+
+	// 忽略停止信号的进程在宽限期后被 SIGKILL
+	p := testProcess(dir, "stubborn")
+	p.Spec.Exec = models.Exec{
+		Command:         "sh",
+		Args:            []string{"-c", "trap '' INT; echo ok > " + ready + "; while true; do sleep 0.1; done"},
+		StopSignal:      "SIGINT",
+		StopGracePeriod: 300 * time.Millisecond,
+	}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "stubborn"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	waitForFile(t, ready)
+
+	start := time.Now()
+	if err := m.StopProcess("test", "stubborn"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("StopProcess returned after %v, want the grace period to pass", elapsed)
+	}
+	got, _ := m.GetProcessStatus("test", "stubborn")
+	info := got.Status.LastTerminationInfo
+	if info == nil || info.StopSignal != "SIGINT" || !info.Escalated || info.Signal != int(syscall.SIGKILL) {
+		t.Fatalf("LastTerminationInfo = %+v, want SIGINT escalated to SIGKILL", info)
+	}
+
+	events, err := m.ListEvents("test", "stubborn", 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if types := eventTypes(events); len(types) < 2 || types[len(types)-2] != models.EventKilled {
+		t.Fatalf("events = %v, want Killed before Exited", types)
+	}
+}
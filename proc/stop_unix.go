@@ -0,0 +1,111 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !windows
+
+package proc
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// stopSignals 是 stop_signal 支持的信号
+var stopSignals = map[string]syscall.Signal{
+	"SIGHUP":   syscall.SIGHUP,
+	"SIGINT":   syscall.SIGINT,
+	"SIGQUIT":  syscall.SIGQUIT,
+	"SIGABRT":  syscall.SIGABRT,
+	"SIGKILL":  syscall.SIGKILL,
+	"SIGUSR1":  syscall.SIGUSR1,
+	"SIGUSR2":  syscall.SIGUSR2,
+	"SIGTERM":  syscall.SIGTERM,
+	"SIGWINCH": syscall.SIGWINCH,
+}
+
+// applyProcessGroup 让进程在独立的会话中启动，停止时可以向整个进程组发送信号
+func applyProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+}
+
+// processGroupID 返回进程所在的进程组。vigil 启动的进程（owned）是会话首进程，进程组 ID 即 PID；
+// 重关联的进程只有自身是进程组首进程时才按进程组处理，避免误杀同组的无关进程。
+func processGroupID(pid int, owned bool) int {
+	if owned {
+		return pid
+	}
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		return pid
+	}
+	return 0
+}
+
+// signalProcess 只向主进程发送信号，进程已退出时不返回错误
+func signalProcess(pid int, sig syscall.Signal) error {
+	if err := syscall.Kill(pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// processGroup 是主进程所在的进程组及 cgroup
+type processGroup struct {
+	pgid   int
+	cgroup *processCgroup
+}
+
+// signal 向进程组、cgroup 内的所有进程和主进程发送信号；没有进程组时只发送给主进程
+func (g processGroup) signal(pid int, sig syscall.Signal) error {
+	if g.cgroup != nil {
+		// cgroup 还包含脱离了进程组的子进程
+		g.cgroup.signal(sig)
+	}
+	if g.pgid <= 0 {
+		return signalProcess(pid, sig)
+	}
+	if err := syscall.Kill(-g.pgid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	if pid != g.pgid {
+		// 主进程（MAINPID）可能已经脱离了进程组
+		return signalProcess(pid, sig)
+	}
+	return nil
+}
+
+// alive 进程组或 cgroup 中是否还有进程
+func (g processGroup) alive() bool {
+	if g.cgroup != nil && g.cgroup.populated() {
+		return true
+	}
+	return g.pgid > 0 && syscall.Kill(-g.pgid, 0) == nil
+}
+
+// waitEmpty 等待进程组内的进程全部退出，返回是否在 deadline 前退出
+func (g processGroup) waitEmpty(deadline time.Time) bool {
+	for g.alive() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// stopSignals 是 stop_signal 支持的信号。Windows 不支持信号，任何停止信号都等同于终止进程。
+var stopSignals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// applyProcessGroup Windows 下不创建进程组
+func applyProcessGroup(cmd *exec.Cmd) {}
+
+func processGroupID(pid int, owned bool) int { return 0 }
+
+// signalProcess 终止进程
+func signalProcess(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	if err := p.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+type processGroup struct {
+	pgid   int
+	cgroup *processCgroup
+}
+
+func (g processGroup) signal(pid int, sig syscall.Signal) error { return signalProcess(pid, sig) }
+
+func (g processGroup) waitEmpty(deadline time.Time) bool { return true }
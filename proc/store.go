@@ -43,12 +43,16 @@ func NewProcessStore(dbPath string) (*ProcessStore, error) {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// 打开数据库
-	db, err := sql.Open("sqlite", dbPath)
+	// 打开数据库。与其他模块共用数据库文件，写入冲突时等待锁释放而不是直接返回 SQLITE_BUSY；
+	// WAL 模式下读写互不阻塞，也减少频繁写入进程事件时的 fsync
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// SQLite 同一时间只允许一个写入者，进程内的并发写入通过单连接排队
+	db.SetMaxOpenConns(1)
+
 	store := &ProcessStore{db: db}
 
 	// 初始化 schema
@@ -77,15 +81,19 @@ func (s *ProcessStore) Close() error {
 	return nil
 }
 
-// SaveManagedProcesses 保存所有已管理的进程到数据库
-func (s *ProcessStore) SaveManagedProcesses(processes map[string]*models.ManagedProcess) error {
+// SaveManagedProcesses 保存所有已管理的进程到数据库，已删除的进程会同时从数据库中移除
+func (s *ProcessStore) SaveManagedProcesses(processes []models.ManagedProcess) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO procs
+	if _, err := tx.Exec(`DELETE FROM procs`); err != nil {
+		return fmt.Errorf("failed to clear processes: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO procs
 		(namespace, name, config, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -94,42 +102,87 @@ func (s *ProcessStore) SaveManagedProcesses(processes map[string]*models.Managed
 	defer stmt.Close()
 
 	now := time.Now()
-	for key, p := range processes {
-		// 过滤掉运行时的状态信息，只保存配置相关信息
-		processCopy := *p
-		processCopy.Status.Phase = models.PhaseFailed
-		processCopy.Status.PID = 0
-		processCopy.Status.StartTime = &time.Time{}
-		processCopy.Status.ResourceStats = nil
-
-		configJSON, err := json.Marshal(processCopy)
-		if err != nil {
-			return fmt.Errorf("failed to marshal process %s: %w", key, err)
+	for _, p := range processes {
+		if err := saveProcessRow(stmt, p, now); err != nil {
+			return err
 		}
+	}
 
-		_, err = stmt.Exec(
-			p.Metadata.Namespace,
-			p.Metadata.Name,
-			configJSON,
-			p.Metadata.CreationTimestamp,
-			now,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to save process %s: %w", key, err)
+	return tx.Commit()
+}
+
+// SaveManagedProcess 保存单个进程，用于创建和修改时避免重写整张表
+func (s *ProcessStore) SaveManagedProcess(p models.ManagedProcess) error {
+	stmt, err := s.db.Prepare(`INSERT OR REPLACE INTO procs
+		(namespace, name, config, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	return saveProcessRow(stmt, p, time.Now())
+}
+
+// DeleteManagedProcess 从数据库中删除进程
+func (s *ProcessStore) DeleteManagedProcess(namespace, name string) error {
+	if _, err := s.db.Exec(`DELETE FROM procs WHERE namespace = ? AND name = ?`, namespace, name); err != nil {
+		return fmt.Errorf("failed to delete process %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// saveProcessRow 写入一行进程配置
+func saveProcessRow(stmt *sql.Stmt, p models.ManagedProcess, now time.Time) error {
+	key := fmt.Sprintf("%s/%s", p.Metadata.Namespace, p.Metadata.Name)
+	// 过滤掉运行时的状态信息，只保存配置相关信息；Replicas 实例的状态同样处理，避免重启后以失效的 PID 显示为运行中
+	processCopy := p
+	clearRuntimeStatus(&processCopy.Status)
+	if p.Spec.Replicas != nil && len(p.Status.Instances) > 0 {
+		processCopy.Status.Instances = make([]models.InstanceStatus, len(p.Status.Instances))
+		for i, inst := range p.Status.Instances {
+			clearRuntimeStatus(&inst.Status)
+			processCopy.Status.Instances[i] = inst
 		}
 	}
 
-	return tx.Commit()
+	configJSON, err := json.Marshal(processCopy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal process %s: %w", key, err)
+	}
+
+	_, err = stmt.Exec(
+		p.Metadata.Namespace,
+		p.Metadata.Name,
+		configJSON,
+		p.Metadata.CreationTimestamp,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save process %s: %w", key, err)
+	}
+	return nil
+}
+
+// clearRuntimeStatus 清除只在本次 vigil 运行期间有效的状态
+func clearRuntimeStatus(status *models.Status) {
+	status.Phase = models.PhaseFailed
+	status.PID = 0
+	status.StartTime = &time.Time{}
+	status.ResourceStats = nil
+	status.NextRetryTime = nil
+	status.BackoffCount = 0
+	status.Conditions = nil
 }
 
 // LoadManagedProcesses 从数据库加载已管理的进程
 func (s *ProcessStore) LoadManagedProcesses(m *Manager) error {
-	rows, err := s.db.Query(`SELECT namespace, name, config FROM procs`)
+	rows, err := s.db.Query(`SELECT namespace, name, config FROM procs ORDER BY namespace, name`)
 	if err != nil {
 		return fmt.Errorf("failed to query processes: %w", err)
 	}
 	defer rows.Close()
 
+	var autoStart []models.ManagedProcess
 	for rows.Next() {
 		var namespace, name string
 		var configJSON []byte
@@ -145,24 +198,282 @@ func (s *ProcessStore) LoadManagedProcesses(m *Manager) error {
 		}
 
 		// 使用 namespace/name 作为键
-		key := fmt.Sprintf("%s/%s", namespace, name)
-		m.Processes[key] = &process
+		process.Metadata.Namespace = namespace
+		process.Metadata.Name = name
+		e, err := m.addEntry(process)
+		if err != nil {
+			log.Printf("Failed to load proc %s/%s: %v\n", namespace, name, err)
+			continue
+		}
 
-		// 启动监控协程（避免重复）
-		m.StartMonitoring(namespace, name)
+		// Job 不会自动重新运行；定时 Job 未暂停时恢复调度
+		if isJob(&process) {
+			if isCronJob(&process) && !process.Spec.Job.Suspend {
+				if err := e.do(reconcileEvent{kind: eventStart}); err != nil {
+					log.Printf("Failed to resume schedule of proc %s/%s: %v\n", namespace, name, err)
+				}
+			}
+			continue
+		}
 
 		// 自动启动标记为需要重启的进程
 		if process.Spec.RestartPolicy == models.RestartPolicyAlways ||
-			(process.Spec.RestartPolicy == models.RestartPolicyOnFailure && process.Status.LastTerminationInfo.ExitCode != 0) {
-			go func(namespace, name string) {
-				// 延迟启动，避免启动时资源竞争
-				time.Sleep(1 * time.Second)
-				if err := m.StartProcess(namespace, name); err != nil {
-					log.Printf("Failed to start proc %s/%s on startup: %v\n", namespace, name, err)
+			(process.Spec.RestartPolicy == models.RestartPolicyOnFailure &&
+				process.Status.LastTerminationInfo != nil && process.Status.LastTerminationInfo.ExitCode != 0) {
+			autoStart = append(autoStart, process)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 先按 PID 文件重关联 vigil 重启前仍在运行的进程，已运行的进程不会再次启动
+	m.recoverProcesses()
+
+	// 依赖只在 namespace 内，各 namespace 并行按依赖顺序启动
+	byNamespace := make(map[string][]models.ManagedProcess)
+	for _, p := range autoStart {
+		byNamespace[p.Metadata.Namespace] = append(byNamespace[p.Metadata.Namespace], p)
+	}
+	for _, processes := range byNamespace {
+		ordered, err := sortByDependencies(processes)
+		if err != nil {
+			log.Printf("Failed to order procs for startup: %v\n", err)
+			ordered = processes
+		}
+		go func(ordered []models.ManagedProcess) {
+			// 延迟启动，避免启动时资源竞争
+			time.Sleep(1 * time.Second)
+			for _, result := range m.startOrdered(ordered) {
+				if result.Error != "" {
+					log.Printf("Failed to start proc %s/%s on startup: %s\n", result.Namespace, result.Name, result.Error)
 				}
-			}(namespace, name)
+			}
+		}(ordered)
+	}
+
+	return nil
+}
+
+// SaveEvents 在一个事务中批量保存进程事件，事件 ID 由调用方分配
+func (s *ProcessStore) SaveEvents(events []models.ProcessEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO proc_events
+		(id, namespace, name, type, pid, exit_code, signal, reason, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ev := range events {
+		var exitCode interface{}
+		if ev.ExitCode != nil {
+			exitCode = *ev.ExitCode
 		}
+		if _, err := stmt.Exec(ev.ID, ev.Namespace, ev.Name, string(ev.Type), ev.PID, exitCode,
+			ev.Signal, ev.Reason, ev.Message, ev.Timestamp.UTC()); err != nil {
+			return fmt.Errorf("failed to save event: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// MaxEventID 返回已保存事件的最大 ID
+func (s *ProcessStore) MaxEventID() (int64, error) {
+	var id sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(id) FROM proc_events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to query events: %w", err)
 	}
+	return id.Int64, nil
+}
 
-	return rows.Err()
+// ListEvents 按 ID 升序返回 afterID 之后的事件。namespace 或 name 为空时不过滤；
+// limit 大于 0 时只返回最近的 limit 条。
+func (s *ProcessStore) ListEvents(namespace, name string, afterID int64, limit int) ([]models.ProcessEvent, error) {
+	query := `SELECT id, namespace, name, type, pid, exit_code, signal, reason, message, created_at
+		FROM proc_events WHERE id > ?`
+	args := []interface{}{afterID}
+	if namespace != "" {
+		query += ` AND namespace = ?`
+		args = append(args, namespace)
+	}
+	if name != "" {
+		query += ` AND name = ?`
+		args = append(args, name)
+	}
+	query += ` ORDER BY id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ProcessEvent
+	for rows.Next() {
+		var ev models.ProcessEvent
+		var eventType string
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&ev.ID, &ev.Namespace, &ev.Name, &eventType, &ev.PID, &exitCode,
+			&ev.Signal, &ev.Reason, &ev.Message, &ev.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+		ev.Type = models.EventType(eventType)
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			ev.ExitCode = &code
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 查询按 ID 降序以便 LIMIT 取最近的事件，返回时恢复时间顺序
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// PruneEvents 删除早于 before 的事件，并且每个进程最多保留 maxPerProcess 条
+func (s *ProcessStore) PruneEvents(before time.Time, maxPerProcess int) error {
+	if !before.IsZero() {
+		if _, err := s.db.Exec(`DELETE FROM proc_events WHERE created_at < ?`, before.UTC()); err != nil {
+			return fmt.Errorf("failed to prune events: %w", err)
+		}
+	}
+	if maxPerProcess > 0 {
+		if _, err := s.db.Exec(`DELETE FROM proc_events WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY namespace, name ORDER BY id DESC) AS rn
+				FROM proc_events
+			) WHERE rn > ?)`, maxPerProcess); err != nil {
+			return fmt.Errorf("failed to prune events: %w", err)
+		}
+	}
+	return nil
+}
+
+// SaveSecret 保存（新建或覆盖）加密后的密钥，返回是否为新建
+func (s *ProcessStore) SaveSecret(name, encrypted string) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.db.Exec(`UPDATE proc_secrets SET value = ?, updated_at = ? WHERE name = ?`, encrypted, now, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to save secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return false, nil
+	}
+	if _, err := s.db.Exec(`INSERT INTO proc_secrets (name, value, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		name, encrypted, now, now); err != nil {
+		return false, fmt.Errorf("failed to save secret: %w", err)
+	}
+	return true, nil
+}
+
+// GetSecret 返回加密后的密钥值，不存在时返回 sql.ErrNoRows
+func (s *ProcessStore) GetSecret(name string) (string, error) {
+	var value string
+	if err := s.db.QueryRow(`SELECT value FROM proc_secrets WHERE name = ?`, name).Scan(&value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// ListSecrets 按名称返回所有密钥（不含值）
+func (s *ProcessStore) ListSecrets() ([]models.Secret, error) {
+	rows, err := s.db.Query(`SELECT name, created_at, updated_at FROM proc_secrets ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var secrets []models.Secret
+	for rows.Next() {
+		var secret models.Secret
+		if err := rows.Scan(&secret.Name, &secret.CreatedAt, &secret.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan secret row: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, rows.Err()
+}
+
+// DeleteSecret 删除密钥，返回是否存在
+func (s *ProcessStore) DeleteSecret(name string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM proc_secrets WHERE name = ?`, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete secret: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SaveJobRun 保存一条 Job 运行记录并返回其 ID，同时只保留该 Job 最近的 keep 条记录
+func (s *ProcessStore) SaveJobRun(run models.JobRun, keep int) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO proc_job_runs
+		(namespace, name, instance, attempt, pid, exit_code, signal, succeeded, reason, stdout, stderr, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.Namespace, run.Name, run.Instance, run.Attempt, run.PID, run.ExitCode, run.Signal, run.Succeeded,
+		run.Reason, run.Stdout, run.Stderr, run.StartTime.UTC(), run.FinishTime.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to save job run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to save job run: %w", err)
+	}
+	if keep > 0 {
+		if _, err := s.db.Exec(`DELETE FROM proc_job_runs WHERE namespace = ? AND name = ? AND id NOT IN (
+			SELECT id FROM proc_job_runs WHERE namespace = ? AND name = ? ORDER BY id DESC LIMIT ?)`,
+			run.Namespace, run.Name, run.Namespace, run.Name, keep); err != nil {
+			return id, fmt.Errorf("failed to prune job runs: %w", err)
+		}
+	}
+	return id, nil
+}
+
+// ListJobRuns 按时间顺序返回 Job 最近的 limit 条运行记录，limit 为 0 时返回全部
+func (s *ProcessStore) ListJobRuns(namespace, name string, limit int) ([]models.JobRun, error) {
+	query := `SELECT id, namespace, name, instance, attempt, pid, exit_code, signal, succeeded, reason, stdout, stderr, started_at, finished_at
+		FROM proc_job_runs WHERE namespace = ? AND name = ? ORDER BY id DESC`
+	args := []interface{}{namespace, name}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(&run.ID, &run.Namespace, &run.Name, &run.Instance, &run.Attempt, &run.PID, &run.ExitCode,
+			&run.Signal, &run.Succeeded, &run.Reason, &run.Stdout, &run.Stderr, &run.StartTime, &run.FinishTime); err != nil {
+			return nil, fmt.Errorf("failed to scan job run row: %w", err)
+		}
+		run.Duration = run.FinishTime.Sub(run.StartTime)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
 }
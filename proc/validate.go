@@ -0,0 +1,97 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// ErrInvalidProcess 表示进程定义未通过校验，API 层据此返回 400
+var ErrInvalidProcess = errors.New("invalid process")
+
+// ValidateProcess 在创建或修改进程时校验定义，尽早暴露启动时才会出现的错误
+func ValidateProcess(mp *models.ManagedProcess) error {
+	if mp.Metadata.Name == "" {
+		return fmt.Errorf("%w: metadata.name is required", ErrInvalidProcess)
+	}
+	if err := validatePathElement("metadata.name", mp.Metadata.Name); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validatePathElement("metadata.namespace", mp.Metadata.Namespace); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if _, err := resolveRunAsUser(&mp.Spec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if _, err := parseCgroupLimits(mp.Spec.Resources); err != nil {
+		return fmt.Errorf("%w: resources: %v", ErrInvalidProcess, err)
+	}
+	if err := validateDependencies(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if b := mp.Spec.RestartBackoff; b != nil {
+		if b.MaxInterval < 0 || b.MaxRestarts < 0 || b.Window < 0 || b.ResetAfter < 0 {
+			return fmt.Errorf("%w: restart_backoff: values must not be negative", ErrInvalidProcess)
+		}
+	}
+	if err := validateLogConfig(mp.Spec.Log); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if _, _, _, err := stopOptions(mp.Spec.Exec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateIsolation(mp.Spec.Isolation); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateEnv(&mp.Spec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateHealthCheck(mp.Spec.HealthCheck); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateNotify(mp.Spec.Notify); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateReplicas(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateJob(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateWatchdog(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateWatch(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	if err := validateCrashPolicy(mp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcess, err)
+	}
+	return nil
+}
+
+// validatePathElement 确保名称可以作为路径中的单独一级（如 cgroup 目录），不会逃出所在目录
+func validatePathElement(field, value string) error {
+	if value == "." || value == ".." || strings.ContainsAny(value, "/\\\x00") {
+		return fmt.Errorf("%s %q must not be \".\", \"..\" or contain path separators", field, value)
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// defaultWatchDebounce 是文件最后一次变化后触发动作前的默认等待时间
+	defaultWatchDebounce = 2 * time.Second
+	// hookWatch 是监视命令在 lifecycle 日志中的名称
+	hookWatch = "watch"
+)
+
+// SetWatchHook 设置文件变化触发动作后的回调，用于写入审计日志。回调在监视协程中调用，不能阻塞
+func (m *Manager) SetWatchHook(hook func(models.WatchTrigger)) {
+	m.watchHook = hook
+}
+
+// validateWatch 校验 Spec.Watch
+func validateWatch(mp *models.ManagedProcess) error {
+	w := mp.Spec.Watch
+	if w == nil {
+		return nil
+	}
+	if isJob(mp) {
+		return fmt.Errorf("watch: not supported for jobs")
+	}
+	if len(w.Paths) == 0 {
+		return fmt.Errorf("watch: paths is required")
+	}
+	for _, p := range w.Paths {
+		if p == "" {
+			return fmt.Errorf("watch: path must not be empty")
+		}
+		if hasGlobMeta(filepath.Dir(p)) {
+			return fmt.Errorf("watch: path %q: wildcards are only supported in the file name", p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("watch: path %q: %v", p, err)
+		}
+	}
+	if w.Debounce < 0 {
+		return fmt.Errorf("watch: debounce must not be negative")
+	}
+	switch w.Action {
+	case "", models.WatchActionRestart:
+	case models.WatchActionSignal:
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("watch: the signal action is not supported on Windows")
+		}
+		if _, err := parseSignal(w.Signal); err != nil {
+			return fmt.Errorf("watch: %v", err)
+		}
+	case models.WatchActionCommand:
+		if w.Command == nil || w.Command.Command == "" {
+			return fmt.Errorf("watch: command is required for the command action")
+		}
+	default:
+		return fmt.Errorf("watch: unsupported action %q", w.Action)
+	}
+	return nil
+}
+
+// hasGlobMeta 路径中是否包含通配符
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// watchPatterns 返回监视路径的绝对路径，相对路径基于进程的工作目录
+func watchPatterns(mp *models.ManagedProcess) []string {
+	patterns := make([]string, 0, len(mp.Spec.Watch.Paths))
+	for _, p := range mp.Spec.Watch.Paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(processWorkingDir(mp), p)
+		}
+		patterns = append(patterns, filepath.Clean(p))
+	}
+	return patterns
+}
+
+// startWatch 为运行中的进程启动文件监视，已有的监视会先被停止（仅在 reconcile 协程中调用）。
+// 监视的是文件所在的目录，编辑器以改名方式保存的文件同样能被发现
+func (m *Manager) startWatch(e *processEntry) {
+	m.stopWatch(e)
+	mp := e.snapshot()
+	if mp.Spec.Watch == nil {
+		return
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Warning: failed to watch files of process %s: %v", e.key, err)
+		return
+	}
+	patterns := watchPatterns(&mp)
+	watched := 0
+	seen := map[string]bool{}
+	for _, p := range patterns {
+		dir := filepath.Dir(p)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("Warning: process %s: failed to watch %s: %v", e.key, dir, err)
+			continue
+		}
+		watched++
+	}
+	if watched == 0 {
+		watcher.Close()
+		return
+	}
+
+	// 启动时的内容作为比较的基准
+	hashes := hashWatchedFiles(patterns)
+	ctx, cancel := context.WithCancel(context.Background())
+	e.watchCancel = cancel
+	go m.runWatch(ctx, e, &mp, watcher, patterns, hashes)
+}
+
+// stopWatch 停止进程的文件监视（仅在 reconcile 协程中调用）
+func (m *Manager) stopWatch(e *processEntry) {
+	if e.watchCancel != nil {
+		e.watchCancel()
+		e.watchCancel = nil
+	}
+}
+
+// runWatch 在文件变化后等待 Debounce，内容（SHA-256）确实变化时执行动作；只修改时间变化的文件不触发
+func (m *Manager) runWatch(ctx context.Context, e *processEntry, mp *models.ManagedProcess, watcher *fsnotify.Watcher, patterns []string, hashes map[string]string) {
+	defer watcher.Close()
+	debounce := mp.Spec.Watch.Debounce
+	if debounce == 0 {
+		debounce = defaultWatchDebounce
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if matchWatchPattern(patterns, ev.Name) {
+				timer.Reset(debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Warning: process %s: file watch error: %v", e.key, err)
+		case <-timer.C:
+			current := hashWatchedFiles(patterns)
+			changes := diffWatchedFiles(hashes, current)
+			hashes = current
+			if len(changes) > 0 && ctx.Err() == nil {
+				m.fireWatch(e, mp, changes)
+			}
+		}
+	}
+}
+
+// matchWatchPattern 文件是否匹配监视路径
+func matchWatchPattern(patterns []string, name string) bool {
+	name = filepath.Clean(name)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hashWatchedFiles 返回匹配监视路径的文件及其内容的 SHA-256
+func hashWatchedFiles(patterns []string) map[string]string {
+	hashes := map[string]string{}
+	for _, p := range patterns {
+		matches, _ := filepath.Glob(p)
+		for _, path := range matches {
+			if _, done := hashes[path]; done {
+				continue
+			}
+			if sum, err := fileSHA256(path); err == nil {
+				hashes[path] = sum
+			}
+		}
+	}
+	return hashes
+}
+
+// fileSHA256 返回普通文件内容的 SHA-256
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// diffWatchedFiles 返回内容变化、新建和删除的文件，按路径排序
+func diffWatchedFiles(before, after map[string]string) []models.FileChange {
+	var changes []models.FileChange
+	for path, sum := range after {
+		if before[path] != sum {
+			changes = append(changes, models.FileChange{Path: path, SHA256: sum})
+		}
+	}
+	for path := range before {
+		if _, exists := after[path]; !exists {
+			changes = append(changes, models.FileChange{Path: path, Removed: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// describeFileChanges 返回变化文件的描述，如 "/etc/app.yaml (sha256 9f86d0...)"
+func describeFileChanges(changes []models.FileChange) string {
+	parts := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.Removed {
+			parts = append(parts, c.Path+" (removed)")
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (sha256 %s)", c.Path, c.SHA256))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fireWatch 执行文件变化的动作，记录 FileChanged 事件并调用审计回调
+func (m *Manager) fireWatch(e *processEntry, mp *models.ManagedProcess, changes []models.FileChange) {
+	current := e.snapshot()
+	if !isActivePhase(current.Status.Phase) || current.Status.PID == 0 {
+		return
+	}
+	pid := current.Status.PID
+	w := mp.Spec.Watch
+	action := w.Action
+	if action == "" {
+		action = models.WatchActionRestart
+	}
+	trigger := models.WatchTrigger{
+		Namespace: mp.Metadata.Namespace,
+		Name:      mp.Metadata.Name,
+		PID:       pid,
+		Action:    action,
+		Changes:   changes,
+	}
+
+	message := describeFileChanges(changes) + " changed"
+	switch action {
+	case models.WatchActionRestart:
+		message += ", restarting"
+		// 由 reconcile 协程重启，监视协程随之停止
+		go e.send(reconcileEvent{kind: eventFileChanged, pid: pid, message: message})
+	case models.WatchActionSignal:
+		sig, _ := parseSignal(w.Signal)
+		message += fmt.Sprintf(", sent %s", signalName(sig))
+		if err := signalProcess(pid, sig); err != nil {
+			trigger.Error = err.Error()
+			message = fmt.Sprintf("%s changed, failed to send %s: %v", describeFileChanges(changes), signalName(sig), err)
+		}
+	case models.WatchActionCommand:
+		// 在监视协程中执行，命令结束后再记录结果
+		message += fmt.Sprintf(", ran %s", w.Command.Command)
+		if err := m.runWatchCommand(mp, pid, changes); err != nil {
+			trigger.Error = err.Error()
+			message = fmt.Sprintf("%s changed, failed to run %s: %v", describeFileChanges(changes), w.Command.Command, err)
+		}
+	}
+	log.Printf("Process %s watch: %s", e.key, message)
+	m.emitEvent(e, models.EventFileChanged, string(action), message)
+	if m.watchHook != nil {
+		m.watchHook(trigger)
+	}
+}
+
+// runWatchCommand 在进程的环境下执行监视命令，VIGIL_PID 和 VIGIL_CHANGED_FILES（以换行分隔）传入进程和变化的文件
+func (m *Manager) runWatchCommand(mp *models.ManagedProcess, pid int, changes []models.FileChange) error {
+	process := *mp
+	if err := m.expandEnv(&process); err != nil {
+		log.Printf("Warning: process %s/%s: %v", mp.Metadata.Namespace, mp.Metadata.Name, err)
+	}
+	files := make([]string, 0, len(changes))
+	for _, c := range changes {
+		files = append(files, c.Path)
+	}
+	process.Spec.Env = append(append([]models.EnvVar(nil), process.Spec.Env...),
+		models.EnvVar{Name: "VIGIL_PID", Value: strconv.Itoa(pid)},
+		models.EnvVar{Name: "VIGIL_CHANGED_FILES", Value: strings.Join(files, "\n")},
+	)
+	return runLifecycleHook(&process, hookWatch, mp.Spec.Watch.Command)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+)
+
+// fileChangedEvents 返回进程的 FileChanged 事件
+func fileChangedEvents(t *testing.T, m *Manager, name string) []models.ProcessEvent {
+	t.Helper()
+	events, err := m.ListEvents("test", name, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	var changed []models.ProcessEvent
+	for _, ev := range events {
+		if ev.Type == models.EventFileChanged {
+			changed = append(changed, ev)
+		}
+	}
+	return changed
+}
+
+func TestValidateWatch(t *testing.T) {
+	cases := []struct {
+		name  string
+		watch models.WatchConfig
+		job   bool
+	}{
+		{name: "no paths", watch: models.WatchConfig{}},
+		{name: "wildcard directory", watch: models.WatchConfig{Paths: []string{"conf/*/app.yaml"}}},
+		{name: "bad pattern", watch: models.WatchConfig{Paths: []string{"conf/[app.yaml"}}},
+		{name: "negative debounce", watch: models.WatchConfig{Paths: []string{"app.yaml"}, Debounce: -time.Second}},
+		{name: "missing command", watch: models.WatchConfig{Paths: []string{"app.yaml"}, Action: models.WatchActionCommand}},
+		{name: "unknown action", watch: models.WatchConfig{Paths: []string{"app.yaml"}, Action: "reload"}},
+		{name: "job", watch: models.WatchConfig{Paths: []string{"app.yaml"}}, job: true},
+	}
+	for _, c := range cases {
+		p := testProcess(t.TempDir(), "app")
+		p.Spec.Watch = &c.watch
+		if c.job {
+			p.Kind = models.KindJob
+		}
+		if err := ValidateProcess(&p); !errors.Is(err, ErrInvalidProcess) || !strings.Contains(err.Error(), "watch:") {
+			t.Errorf("%s: ValidateProcess = %v, want ErrInvalidProcess", c.name, err)
+		}
+	}
+}
+
+func TestManagerWatch(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+	triggers := make(chan models.WatchTrigger, 10)
+	m.SetWatchHook(func(trigger models.WatchTrigger) { triggers <- trigger })
+
+	conf := filepath.Join(dir, "conf")
+	if err := os.Mkdir(conf, 0755); err != nil {
+		t.Fatal(err)
+	}
+	appYAML := filepath.Join(conf, "app.yaml")
+	if err := os.WriteFile(appYAML, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := testProcess(dir, "app")
+	p.Spec.Watch = &models.WatchConfig{Paths: []string{"conf/*.yaml"}, Debounce: 100 * time.Millisecond}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "app"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	status, _ := m.GetProcessStatus("test", "app")
+	pid := status.Status.PID
+
+	// 内容不变的写入和不匹配的文件都不触发
+	if err := os.WriteFile(appYAML, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(conf, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if events := fileChangedEvents(t, m, "app"); len(events) != 0 {
+		t.Fatalf("unexpected FileChanged events: %+v", events)
+	}
+
+	// 内容变化时重启，事件和审计回调中带有文件的 SHA-256
+	if err := os.WriteFile(appYAML, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("v2"))
+	hash := hex.EncodeToString(sum[:])
+	select {
+	case trigger := <-triggers:
+		if trigger.Action != models.WatchActionRestart || trigger.PID != pid || len(trigger.Changes) != 1 ||
+			trigger.Changes[0].Path != appYAML || trigger.Changes[0].SHA256 != hash {
+			t.Fatalf("trigger = %+v", trigger)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch hook was not called")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ = m.GetProcessStatus("test", "app")
+		if status.Status.RestartCount == 1 && status.Status.PID != pid && status.Status.Phase == models.PhaseRunning {
+			if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "FileChanged" {
+				t.Fatalf("termination info = %+v, want FileChanged", info)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process was not restarted after the file changed: %+v", status.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	events := fileChangedEvents(t, m, "app")
+	if len(events) != 1 || events[0].Reason != string(models.WatchActionRestart) || !strings.Contains(events[0].Message, hash) {
+		t.Fatalf("FileChanged events = %+v", events)
+	}
+
+	// command 动作，VIGIL_CHANGED_FILES 是变化的文件
+	envFile := filepath.Join(dir, "cmd.env")
+	c := testProcess(dir, "cmd")
+	c.Spec.Watch = &models.WatchConfig{
+		Paths:    []string{envFile},
+		Debounce: 50 * time.Millisecond,
+		Action:   models.WatchActionCommand,
+		Command:  &models.CommandConfig{Command: "sh", Args: []string{"-c", `echo "$VIGIL_CHANGED_FILES" > changed.out`}},
+	}
+	if err := m.CreateProcess(c); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "cmd"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if err := os.WriteFile(envFile, []byte("A=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitForFile(t, filepath.Join(dir, "changed.out")); got != envFile {
+		t.Fatalf("changed.out = %q, want %q", got, envFile)
+	}
+	select {
+	case trigger := <-triggers:
+		if trigger.Name != "cmd" || trigger.Action != models.WatchActionCommand || trigger.Error != "" {
+			t.Fatalf("trigger = %+v", trigger)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch hook was not called for the command action")
+	}
+
+	// 命令失败时事件和审计回调中带有错误
+	failFile := filepath.Join(dir, "fail.env")
+	f := testProcess(dir, "fail")
+	f.Spec.Watch = &models.WatchConfig{
+		Paths:    []string{failFile},
+		Debounce: 50 * time.Millisecond,
+		Action:   models.WatchActionCommand,
+		Command:  &models.CommandConfig{Command: "sh", Args: []string{"-c", "exit 3"}},
+	}
+	if err := m.CreateProcess(f); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "fail"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	if err := os.WriteFile(failFile, []byte("B=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case trigger := <-triggers:
+		if trigger.Name != "fail" || !strings.Contains(trigger.Error, "exit status 3") {
+			t.Fatalf("trigger = %+v, want exit status 3", trigger)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch hook was not called for the failed command")
+	}
+	if events := fileChangedEvents(t, m, "fail"); len(events) != 1 || !strings.Contains(events[0].Message, "failed to run sh") {
+		t.Fatalf("FileChanged events = %+v", events)
+	}
+
+	// 停止后不再触发
+	if err := m.StopProcess("test", "cmd"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if err := os.WriteFile(envFile, []byte("A=2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if events := fileChangedEvents(t, m, "cmd"); len(events) != 1 {
+		t.Fatalf("%d FileChanged events after stop, want 1", len(events))
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+const (
+	// defaultWatchdogCooldown 是看门狗规则两次触发之间的默认间隔
+	defaultWatchdogCooldown = 5 * time.Minute
+	// hookWatchdog 是看门狗命令在 lifecycle 日志中的名称
+	hookWatchdog = "watchdog"
+)
+
+var (
+	// watchdogForPattern 匹配规则末尾的 "for <duration>"
+	watchdogForPattern = regexp.MustCompile(`^(.*\S)\s+for\s+(\S+)$`)
+	// watchdogSizePattern 匹配带单位的数值，如 2Gi、512M
+	watchdogSizePattern = regexp.MustCompile(`\b(\d+(?:\.\d+)?)(Ki|Mi|Gi|Ti|K|M|G|T)\b`)
+)
+
+var watchdogSizeUnits = map[string]float64{
+	"K": 1e3, "M": 1e6, "G": 1e9, "T": 1e12,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40,
+}
+
+// watchdogVariables 是规则中可以使用的变量
+var watchdogVariables = []string{
+	"cpu_usage", "cpu_total_time",
+	"memory_rss", "memory_vms", "memory_heap", "memory_usage",
+	"open_fds", "fd_max", "thread_count", "open_files_count", "network_connections_count",
+	"io_read_bytes", "io_write_bytes", "ctx_switches_voluntary", "ctx_switches_involuntary",
+	"restart_count", "uptime",
+}
+
+// watchdogRule 是编译后的看门狗规则
+type watchdogRule struct {
+	spec     models.WatchdogRule
+	name     string
+	program  *vm.Program
+	duration time.Duration
+	cooldown time.Duration
+	signal   syscall.Signal
+}
+
+// watchdogState 是一个进程的看门狗规则及其状态，只在采样器中访问
+type watchdogState struct {
+	// specs 是编译时的规则定义，定义变化后重新编译
+	specs []models.WatchdogRule
+	rules []*watchdogRule
+	// pid 是状态对应的进程，进程重启后条件重新计时
+	pid int
+	// since 是条件开始持续成立的时间，fired 是上一次触发的时间
+	since []time.Time
+	fired []time.Time
+}
+
+// compileWatchdogRule 解析 "for <duration>" 和数值单位，并把条件编译为 expr 程序
+func compileWatchdogRule(spec models.WatchdogRule) (*watchdogRule, error) {
+	r := &watchdogRule{spec: spec, name: spec.Name, cooldown: spec.Cooldown}
+	if r.name == "" {
+		r.name = spec.Rule
+	}
+	if r.cooldown == 0 {
+		r.cooldown = defaultWatchdogCooldown
+	}
+
+	code := strings.TrimSpace(spec.Rule)
+	if m := watchdogForPattern.FindStringSubmatch(code); m != nil {
+		d, err := time.ParseDuration(m[2])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("rule %q: invalid duration %q", spec.Rule, m[2])
+		}
+		code, r.duration = m[1], d
+	}
+	code = watchdogSizePattern.ReplaceAllStringFunc(code, func(s string) string {
+		m := watchdogSizePattern.FindStringSubmatch(s)
+		n, _ := strconv.ParseFloat(m[1], 64)
+		return strconv.FormatFloat(n*watchdogSizeUnits[m[2]], 'f', -1, 64)
+	})
+	if code == "" {
+		return nil, fmt.Errorf("rule is empty")
+	}
+
+	env := make(map[string]interface{}, len(watchdogVariables))
+	for _, name := range watchdogVariables {
+		env[name] = 0.0
+	}
+	program, err := expr.Compile(code, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %v", spec.Rule, err)
+	}
+	r.program = program
+
+	switch spec.Action {
+	case "", models.WatchdogActionEvent, models.WatchdogActionRestart:
+	case models.WatchdogActionCommand:
+		if spec.Command == nil || spec.Command.Command == "" {
+			return nil, fmt.Errorf("rule %q: command is required for the command action", spec.Rule)
+		}
+	case models.WatchdogActionSignal:
+		if runtime.GOOS == "windows" {
+			return nil, fmt.Errorf("rule %q: the signal action is not supported on Windows", spec.Rule)
+		}
+		if r.signal, err = parseSignal(spec.Signal); err != nil {
+			return nil, fmt.Errorf("rule %q: %v", spec.Rule, err)
+		}
+	default:
+		return nil, fmt.Errorf("rule %q: unsupported action %q", spec.Rule, spec.Action)
+	}
+	if spec.Cooldown < 0 {
+		return nil, fmt.Errorf("rule %q: cooldown must not be negative", spec.Rule)
+	}
+	return r, nil
+}
+
+// validateWatchdog 校验看门狗规则
+func validateWatchdog(mp *models.ManagedProcess) error {
+	for _, spec := range mp.Spec.Watchdog {
+		if _, err := compileWatchdogRule(spec); err != nil {
+			return fmt.Errorf("watchdog: %v", err)
+		}
+		if spec.Action == models.WatchdogActionRestart && isJob(mp) {
+			return fmt.Errorf("watchdog: the restart action is not supported for jobs")
+		}
+	}
+	return nil
+}
+
+// watchdogRuleEnv 返回规则求值使用的变量
+func watchdogRuleEnv(mp *models.ManagedProcess, stats *models.ResourceStats, now time.Time) map[string]interface{} {
+	uptime := 0.0
+	if mp.Status.StartTime != nil {
+		uptime = now.Sub(*mp.Status.StartTime).Seconds()
+	}
+	return map[string]interface{}{
+		"cpu_usage":                 stats.CPUUsage,
+		"cpu_total_time":            stats.CPUTotalTime,
+		"memory_rss":                float64(stats.MemoryRSS),
+		"memory_vms":                float64(stats.MemoryVMS),
+		"memory_heap":               float64(stats.MemoryHeap),
+		"memory_usage":              float64(stats.MemoryUsage),
+		"open_fds":                  float64(stats.OpenFDs),
+		"fd_max":                    float64(stats.FDLimit),
+		"thread_count":              float64(stats.ThreadCount),
+		"open_files_count":          float64(stats.OpenFilesCount),
+		"network_connections_count": float64(stats.NetworkConnectionsCount),
+		"io_read_bytes":             float64(stats.IOReadBytes),
+		"io_write_bytes":            float64(stats.IOWriteBytes),
+		"ctx_switches_voluntary":    float64(stats.CtxSwitchesVoluntary),
+		"ctx_switches_involuntary":  float64(stats.CtxSwitchesInvoluntary),
+		"restart_count":             float64(mp.Status.RestartCount),
+		"uptime":                    uptime,
+	}
+}
+
+// checkWatchdogs 用一次采样结果对进程的看门狗规则求值。条件持续成立 for 指定的时间后触发，
+// 之后条件仍成立时每隔 cooldown 再次触发（仅在采样器中调用）
+func (m *Manager) checkWatchdogs(e *processEntry, pid int, stats *models.ResourceStats, now time.Time) {
+	mp := e.snapshot()
+	if len(mp.Spec.Watchdog) == 0 {
+		e.watchdogRules = nil
+		return
+	}
+
+	st := e.watchdogRules
+	if st == nil || !reflect.DeepEqual(st.specs, mp.Spec.Watchdog) {
+		st = &watchdogState{
+			specs: mp.Spec.Watchdog,
+			rules: make([]*watchdogRule, len(mp.Spec.Watchdog)),
+			since: make([]time.Time, len(mp.Spec.Watchdog)),
+			fired: make([]time.Time, len(mp.Spec.Watchdog)),
+		}
+		for i, spec := range mp.Spec.Watchdog {
+			rule, err := compileWatchdogRule(spec)
+			if err != nil {
+				log.Printf("Warning: process %s: watchdog %v", e.key, err)
+				continue
+			}
+			st.rules[i] = rule
+		}
+		e.watchdogRules = st
+	}
+	if st.pid != pid {
+		// 新的运行实例重新计时，冷却时间跨重启保留，避免反复重启
+		st.pid = pid
+		for i := range st.since {
+			st.since[i] = time.Time{}
+		}
+	}
+
+	env := watchdogRuleEnv(&mp, stats, now)
+	for i, rule := range st.rules {
+		if rule == nil {
+			continue
+		}
+		out, err := expr.Run(rule.program, env)
+		if matched, _ := out.(bool); err != nil || !matched {
+			st.since[i] = time.Time{}
+			continue
+		}
+		if st.since[i].IsZero() {
+			st.since[i] = now
+		}
+		if now.Sub(st.since[i]) < rule.duration {
+			continue
+		}
+		if !st.fired[i].IsZero() && now.Sub(st.fired[i]) < rule.cooldown {
+			continue
+		}
+		st.fired[i] = now
+		m.fireWatchdog(e, &mp, pid, rule)
+	}
+}
+
+// fireWatchdog 执行规则的动作并记录 WatchdogTriggered 事件
+func (m *Manager) fireWatchdog(e *processEntry, mp *models.ManagedProcess, pid int, rule *watchdogRule) {
+	message := fmt.Sprintf("%s matched", rule.spec.Rule)
+	switch rule.spec.Action {
+	case models.WatchdogActionCommand:
+		message += fmt.Sprintf(", running %s", rule.spec.Command.Command)
+		go m.runWatchdogCommand(mp, samplePID(mp.Spec.Isolation != nil, pid), rule)
+	case models.WatchdogActionSignal:
+		message += fmt.Sprintf(", sent %s", signalName(rule.signal))
+		if err := signalProcess(pid, rule.signal); err != nil {
+			message = fmt.Sprintf("%s matched, failed to send %s: %v", rule.spec.Rule, signalName(rule.signal), err)
+		}
+	case models.WatchdogActionRestart:
+		message += ", restarting"
+		// 由 reconcile 协程重启，不阻塞采样
+		go e.send(reconcileEvent{kind: eventWatchdog, pid: pid, message: message})
+	}
+	log.Printf("Process %s watchdog %s: %s", e.key, rule.name, message)
+	m.emitEvent(e, models.EventWatchdogTriggered, rule.name, message)
+}
+
+// runWatchdogCommand 在进程的环境下执行看门狗命令，VIGIL_PID 和 VIGIL_WATCHDOG_RULE 传入触发的进程和规则
+func (m *Manager) runWatchdogCommand(mp *models.ManagedProcess, pid int, rule *watchdogRule) {
+	process := *mp
+	if err := m.expandEnv(&process); err != nil {
+		log.Printf("Warning: process %s/%s: %v", mp.Metadata.Namespace, mp.Metadata.Name, err)
+	}
+	process.Spec.Env = append(append([]models.EnvVar(nil), process.Spec.Env...),
+		models.EnvVar{Name: "VIGIL_PID", Value: strconv.Itoa(pid)},
+		models.EnvVar{Name: "VIGIL_WATCHDOG_RULE", Value: rule.name},
+	)
+	if err := runLifecycleHook(&process, hookWatchdog, rule.spec.Command); err != nil {
+		log.Printf("Process %s/%s %v", mp.Metadata.Namespace, mp.Metadata.Name, err)
+	}
+}
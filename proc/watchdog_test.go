@@ -0,0 +1,183 @@
+/*
+Copyright 2025 Vigil Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proc
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casuallc/vigil/models"
+	"github.com/expr-lang/expr"
+)
+
+func TestCompileWatchdogRule(t *testing.T) {
+	stats := &models.ResourceStats{MemoryRSS: 3 << 30, OpenFDs: 950, FDLimit: 1024, CPUUsage: 97}
+	env := watchdogRuleEnv(&models.ManagedProcess{}, stats, time.Now())
+	tests := []struct {
+		rule     string
+		duration time.Duration
+		want     bool
+	}{
+		{"memory_rss > 2Gi for 2m", 2 * time.Minute, true},
+		{"memory_rss > 3.5Gi", 0, false},
+		{"memory_rss > 3000M", 0, true},
+		{"open_fds > 0.9*fd_max", 0, true},
+		{"cpu_usage > 95 for 10m", 10 * time.Minute, true},
+		{"cpu_usage > 95 && open_fds < 100", 0, false},
+	}
+	for _, tt := range tests {
+		r, err := compileWatchdogRule(models.WatchdogRule{Rule: tt.rule})
+		if err != nil {
+			t.Fatalf("compile %q: %v", tt.rule, err)
+		}
+		out, err := expr.Run(r.program, env)
+		if err != nil || out != tt.want || r.duration != tt.duration {
+			t.Errorf("%q = %v (%v), for %s; want %v for %s", tt.rule, out, err, r.duration, tt.want, tt.duration)
+		}
+	}
+
+	for _, rule := range []models.WatchdogRule{
+		{Rule: ""},
+		{Rule: "memory_rss >"},
+		{Rule: "unknown_metric > 1"},
+		{Rule: "memory_rss"},
+		{Rule: "cpu_usage > 95 for ever"},
+		{Rule: "cpu_usage > 95", Action: "page"},
+		{Rule: "cpu_usage > 95", Action: models.WatchdogActionCommand},
+		{Rule: "cpu_usage > 95", Action: models.WatchdogActionSignal, Signal: "SIGNOPE"},
+	} {
+		if _, err := compileWatchdogRule(rule); err == nil {
+			t.Errorf("compileWatchdogRule(%+v) accepted an invalid rule", rule)
+		}
+	}
+}
+
+// watchdogEvents 返回进程的 WatchdogTriggered 事件数
+func watchdogEvents(t *testing.T, m *Manager, name string) int {
+	t.Helper()
+	events, err := m.ListEvents("test", name, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	n := 0
+	for _, ev := range events {
+		if ev.Type == models.EventWatchdogTriggered {
+			n++
+		}
+	}
+	return n
+}
+
+func TestManagerWatchdog(t *testing.T) {
+	m := newTestManager(t)
+	dir := t.TempDir()
+
+	// 条件需要持续 for 指定的时间，触发后在 cooldown 内不重复触发
+	p := testProcess(dir, "leaky")
+	p.Spec.Watchdog = []models.WatchdogRule{{Name: "rss", Rule: "memory_rss > 1Gi for 2m", Cooldown: 10 * time.Minute}}
+	if err := m.CreateProcess(p); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "leaky"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	e, _ := m.getEntry("test", "leaky")
+	pid := e.snapshot().Status.PID
+	high := &models.ResourceStats{MemoryRSS: 2 << 30}
+	low := &models.ResourceStats{MemoryRSS: 1 << 20}
+	t0 := time.Now()
+	steps := []struct {
+		offset time.Duration
+		stats  *models.ResourceStats
+		want   int
+	}{
+		{0, high, 0},
+		{time.Minute, low, 0},
+		{2 * time.Minute, high, 0},
+		{3 * time.Minute, high, 0},
+		{4 * time.Minute, high, 1},
+		{5 * time.Minute, high, 1},
+		{14 * time.Minute, high, 2},
+	}
+	for _, step := range steps {
+		m.checkWatchdogs(e, pid, step.stats, t0.Add(step.offset))
+		if got := watchdogEvents(t, m, "leaky"); got != step.want {
+			t.Fatalf("after +%s: %d WatchdogTriggered events, want %d", step.offset, got, step.want)
+		}
+	}
+
+	// signal 和 command 动作
+	sig := testProcess(dir, "sig")
+	sig.Spec.Exec = models.Exec{Command: "sh", Args: []string{"-c", `trap 'echo usr1 > sig.out' USR1; while :; do sleep 0.05; done`}}
+	sig.Spec.Watchdog = []models.WatchdogRule{
+		{Rule: "open_fds > 0.9*fd_max", Action: models.WatchdogActionSignal, Signal: "USR1"},
+		{Name: "fds", Rule: "open_fds > 0.9*fd_max", Action: models.WatchdogActionCommand,
+			Command: &models.CommandConfig{Command: "sh", Args: []string{"-c", `echo "$VIGIL_PID $VIGIL_WATCHDOG_RULE" > cmd.out`}}},
+	}
+	if err := m.CreateProcess(sig); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "sig"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond) // 等待 trap 生效
+	e, _ = m.getEntry("test", "sig")
+	pid = e.snapshot().Status.PID
+	m.checkWatchdogs(e, pid, &models.ResourceStats{OpenFDs: 95, FDLimit: 100}, time.Now())
+	if got := waitForFile(t, filepath.Join(dir, "sig.out")); got != "usr1" {
+		t.Fatalf("sig.out = %q", got)
+	}
+	if got, want := waitForFile(t, filepath.Join(dir, "cmd.out")), fmt.Sprintf("%d fds", pid); got != want {
+		t.Fatalf("cmd.out = %q, want %q", got, want)
+	}
+	// sig 是无限循环的 shell，不会自行退出
+	if err := m.StopProcess("test", "sig"); err != nil {
+		t.Fatalf("StopProcess: %v", err)
+	}
+	if processRunning(pid) {
+		t.Fatalf("process %d is still running after stop", pid)
+	}
+
+	// restart 动作
+	r := testProcess(dir, "busy")
+	r.Spec.Watchdog = []models.WatchdogRule{{Rule: "cpu_usage > 95", Action: models.WatchdogActionRestart}}
+	if err := m.CreateProcess(r); err != nil {
+		t.Fatalf("CreateProcess: %v", err)
+	}
+	if err := m.StartProcess("test", "busy"); err != nil {
+		t.Fatalf("StartProcess: %v", err)
+	}
+	e, _ = m.getEntry("test", "busy")
+	pid = e.snapshot().Status.PID
+	m.checkWatchdogs(e, pid, &models.ResourceStats{CPUUsage: 99}, time.Now())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := m.GetProcessStatus("test", "busy")
+		if status.Status.RestartCount == 1 && status.Status.PID != pid && status.Status.Phase == models.PhaseRunning {
+			if info := status.Status.LastTerminationInfo; info == nil || info.Reason != "Watchdog" {
+				t.Fatalf("termination info = %+v, want Watchdog", info)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process was not restarted by the watchdog: %+v", status.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,2 @@
+-- 回滚：删除进程事件表
+DROP TABLE IF EXISTS proc_events;
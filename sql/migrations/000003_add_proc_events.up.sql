@@ -0,0 +1,16 @@
+-- 进程生命周期事件表
+CREATE TABLE IF NOT EXISTS proc_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    namespace TEXT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    pid INTEGER DEFAULT 0,
+    exit_code INTEGER,
+    signal INTEGER DEFAULT 0,
+    reason TEXT DEFAULT '',
+    message TEXT DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_proc_events_process ON proc_events(namespace, name, id);
+CREATE INDEX IF NOT EXISTS idx_proc_events_created_at ON proc_events(created_at);
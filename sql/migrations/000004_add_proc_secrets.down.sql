@@ -0,0 +1,2 @@
+-- 回滚：删除进程密钥表
+DROP TABLE IF EXISTS proc_secrets;
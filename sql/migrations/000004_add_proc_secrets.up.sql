@@ -0,0 +1,7 @@
+-- 进程密钥表，value 使用 security.encryption_key 加密保存
+CREATE TABLE IF NOT EXISTS proc_secrets (
+    name TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
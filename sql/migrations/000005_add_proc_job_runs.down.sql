@@ -0,0 +1,2 @@
+-- 回滚：删除 Job 运行记录表
+DROP TABLE IF EXISTS proc_job_runs;
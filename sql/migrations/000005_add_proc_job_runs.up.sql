@@ -0,0 +1,19 @@
+-- Job 运行记录表
+CREATE TABLE IF NOT EXISTS proc_job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    namespace TEXT NOT NULL,
+    name TEXT NOT NULL,
+    instance TEXT DEFAULT '',
+    attempt INTEGER DEFAULT 0,
+    pid INTEGER DEFAULT 0,
+    exit_code INTEGER DEFAULT 0,
+    signal INTEGER DEFAULT 0,
+    succeeded INTEGER DEFAULT 0,
+    reason TEXT DEFAULT '',
+    stdout TEXT DEFAULT '',
+    stderr TEXT DEFAULT '',
+    started_at DATETIME,
+    finished_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_proc_job_runs_process ON proc_job_runs(namespace, name, id);